@@ -18,6 +18,8 @@ type File struct {
 
 	Pkg     *PackageSpec  // 包信息
 	Imports []*ImportSpec // 导入包信息
+	Types   []*TypeSpec   // 类型声明
+	Consts  []*ConstSpec  // 包级常量
 	Globals []*VarSpec    // 全局变量
 	Funcs   []*FuncDecl   // 函数列表
 }
@@ -35,17 +37,41 @@ type ImportSpec struct {
 	Path      string
 }
 
+// TypeSpec 类型声明 type Name Type 或别名声明 type Name = Type
+type TypeSpec struct {
+	TypePos token.Pos // type 关键字位置
+	Name    *Ident    // 类型名字
+	Assign  token.Pos // 别名声明中 '=' 的位置, 不是别名时为 0
+	Type    Expr      // 类型定义
+}
+
 // VarSpec 变量信息
 type VarSpec struct {
 	VarPos token.Pos // var 关键字位置
 	Name   *Ident    // 变量名字
-	Type   *Ident    // 变量类型
+	Type   Expr      // 变量类型
 	Value  Expr      // 变量表达式
 }
 
+// ConstSpec 常量声明 const Name Type = Value
+type ConstSpec struct {
+	ConstPos token.Pos // const 关键字位置
+	Name     *Ident    // 常量名字
+	Type     Expr      // 常量类型, 可以为 nil
+	Value    Expr      // 常量表达式, 省略时和上一个常量相同
+	Iota     int       // 在常量声明组中的下标, 即 iota 的值
+}
+
+// ConstDecl 函数中的常量声明, 可以是一个括号中的常量声明组
+type ConstDecl struct {
+	ConstPos token.Pos // const 关键字位置
+	Specs    []*ConstSpec
+}
+
 // FuncDecl 函数信息
 type FuncDecl struct {
 	FuncPos token.Pos
+	Recv    *FieldList // 方法的接收者, 函数为 nil
 	NamePos token.Pos
 	Name    string
 	Type    *FuncType
@@ -60,9 +86,9 @@ type FuncLit struct {
 
 // FuncType 函数类型
 type FuncType struct {
-	Func   token.Pos
-	Params *FieldList
-	Result *Ident
+	Func    token.Pos
+	Params  *FieldList
+	Results *FieldList // 返回值列表, 没有返回值时为 nil
 }
 
 // FieldList 参数/属性 列表
@@ -72,10 +98,10 @@ type FieldList struct {
 	Closing token.Pos
 }
 
-// Field 参数/属性
+// Field 参数/属性, 没有名字的返回值 Name 为 nil
 type Field struct {
 	Name *Ident
-	Type *Ident
+	Type Expr
 }
 
 // DeferStmt defer 语句
@@ -84,16 +110,29 @@ type DeferStmt struct {
 	Call     *CallExpr
 }
 
+// GoStmt go 语句
+type GoStmt struct {
+	GoPos token.Pos
+	Call  *CallExpr
+}
+
+// SendStmt 发送语句 ch <- v
+type SendStmt struct {
+	Chan  Expr
+	Arrow token.Pos // '<-' 位置
+	Value Expr
+}
+
 // ReturnStmt return 语句
 type ReturnStmt struct {
-	Return token.Pos
-	Result Expr
+	Return  token.Pos
+	Results []Expr
 }
 
 // BranchStmt 分支语句
 type BranchStmt struct {
 	TokPos  token.Pos
-	TokType token.TokenType // BREAK, CONTINUE, GOTO
+	TokType token.TokenType // BREAK, CONTINUE, GOTO, FALLTHROUGH
 	Label   *Ident
 }
 
@@ -123,12 +162,19 @@ type ExprStmt struct {
 
 // AssignStmt 表示一个赋值语句节点.
 type AssignStmt struct {
-	Target []*Ident        // 要赋值的目标
+	Target []Expr          // 要赋值的目标, *Ident 或 *IndexExpr
 	OpPos  token.Pos       // Op 的位置
-	Op     token.TokenType // '=' or ':='
+	Op     token.TokenType // '=', ':=' 或复合赋值如 '+='
 	Value  []Expr          // 值
 }
 
+// IncDecStmt 自增自减语句 x++ 或 x--
+type IncDecStmt struct {
+	X      Expr
+	TokPos token.Pos       // Tok 的位置
+	Tok    token.TokenType // INC 或 DEC
+}
+
 // IfStmt 表示一个 if 语句节点.
 type IfStmt struct {
 	If   token.Pos  // if 关键字的位置
@@ -147,6 +193,56 @@ type ForStmt struct {
 	Body *BlockStmt // 循环对应的语句列表
 }
 
+// RangeStmt 表示一个 for range 语句节点.
+type RangeStmt struct {
+	For    token.Pos       // for 关键字的位置
+	Key    Expr            // 第一个迭代变量, 没有时为 nil
+	Value  Expr            // 第二个迭代变量, 没有时为 nil
+	TokPos token.Pos       // ':=' 或 '=' 的位置
+	Tok    token.TokenType // ':=' 或 '=', 没有迭代变量时不使用
+	Range  token.Pos       // range 关键字的位置
+	X      Expr            // 被迭代的值
+	Body   *BlockStmt      // 循环对应的语句列表
+}
+
+// SwitchStmt 表示一个 switch 语句节点.
+type SwitchStmt struct {
+	Switch token.Pos  // switch 关键字的位置
+	Init   Stmt       // 初始化语句
+	Tag    Expr       // switch 的值, 没有时为 nil
+	Body   *BlockStmt // 只包含 *CaseClause
+}
+
+// TypeSwitchStmt 类型 switch 语句, 如 switch x := v.(type) { ... }
+type TypeSwitchStmt struct {
+	Switch token.Pos  // switch 关键字的位置
+	Init   Stmt       // 初始化语句
+	Assign Stmt       // x := v.(type) 或 v.(type)
+	Body   *BlockStmt // 只包含 *CaseClause, case 的值为类型
+}
+
+// CaseClause switch 中的 case 或 default 分支
+type CaseClause struct {
+	Case  token.Pos // case 或 default 关键字的位置
+	List  []Expr    // case 的值列表, default 分支为 nil
+	Colon token.Pos // 冒号 ":" 位置
+	Body  []Stmt    // 分支的语句列表
+}
+
+// SelectStmt select 语句
+type SelectStmt struct {
+	Select token.Pos  // select 关键字的位置
+	Body   *BlockStmt // 只包含 *CommClause
+}
+
+// CommClause select 中的 case 或 default 分支
+type CommClause struct {
+	Case  token.Pos // case 或 default 关键字的位置
+	Comm  Stmt      // 发送语句或接收语句 <-ch, v := <-ch, v, ok = <-ch; default 分支为 nil
+	Colon token.Pos // 冒号 ":" 位置
+	Body  []Stmt    // 分支的语句列表
+}
+
 type Expr interface {
 	Pos() token.Pos
 	End() token.Pos
@@ -157,21 +253,20 @@ type Expr interface {
 type Ident struct {
 	NamePos token.Pos
 	Name    string
-	Type    string
 }
 
 // Int 整型
 type Int struct {
 	ValuePos token.Pos
 	ValueEnd token.Pos
-	Value    int
+	Value    string // 字面值的源码, 由类型检查转换为精确的常量值
 }
 
 // Float 浮点数
 type Float struct {
 	ValuePos token.Pos
 	ValueEnd token.Pos
-	Value    float64
+	Value    string // 字面值的源码, 由类型检查转换为精确的常量值
 }
 
 // Char 字符
@@ -181,6 +276,13 @@ type Char struct {
 	Value    int
 }
 
+// StringLit 字符串字面值
+type StringLit struct {
+	ValuePos token.Pos
+	ValueEnd token.Pos
+	Value    string // 解码转义字符后的值
+}
+
 // BinaryExpr 二元表达式
 type BinaryExpr struct {
 	OpPos token.Pos       // 运算符位置
@@ -196,6 +298,12 @@ type UnaryExpr struct {
 	X     Expr            // 运算对象
 }
 
+// StarExpr 表示 *X, 可以是指针解引用或指针类型
+type StarExpr struct {
+	Star token.Pos // '*' 位置
+	X    Expr
+}
+
 // ParenExpr 表示一个圆括弧表达式.
 type ParenExpr struct {
 	Lparen token.Pos // "(" 的位置
@@ -203,17 +311,107 @@ type ParenExpr struct {
 	Rparen token.Pos // ")" 的位置
 }
 
+// IndexExpr 表示 x[index] 下标表达式
+type IndexExpr struct {
+	X      Expr      // 被索引的对象
+	Lbrack token.Pos // '[' 位置
+	Index  Expr      // 下标
+	Rbrack token.Pos // ']' 位置
+}
+
+// SliceExpr 表示 x[Low:High] 切片表达式
+type SliceExpr struct {
+	X      Expr      // 被切片的对象
+	Lbrack token.Pos // '[' 位置
+	Low    Expr      // 起始下标, 可以为 nil
+	High   Expr      // 结束下标, 可以为 nil
+	Rbrack token.Pos // ']' 位置
+}
+
 // CallExpr 表示一个函数调用
 type CallExpr struct {
+	Fun      Expr      // 被调用的函数值, 如 f(1)(2) 中的 f(1); 按名字调用时为 nil
+	Recv     Expr      // 方法调用的接收者, 如 a.b.Move() 中的 a.b; x.Move() 的接收者在 Pkg 中
 	Pkg      *Ident    // 对应的包
 	FuncName *Ident    // 函数名字
 	Lparen   token.Pos // '(' 位置
 	Args     []Expr    // 调用参数列表
+	Ellipsis token.Pos // f(xs...) 中 '...' 的位置, 没有时为 NoPos
 	Rparen   token.Pos // ')' 位置
 }
 
+// Ellipsis 可变参数的类型 ...Elt, 只用于参数列表的最后一个参数
+type Ellipsis struct {
+	Ellipsis token.Pos // '...' 位置
+	Elt      Expr      // 元素类型
+}
+
+// ArrayType 数组类型 [Len]Elem 或切片类型 []Elem
+type ArrayType struct {
+	Lbrack token.Pos // '[' 位置
+	Len    Expr      // 数组长度, 切片类型为 nil
+	Elem   Expr      // 元素类型
+}
+
+// MapType map 类型 map[Key]Value
+type MapType struct {
+	Map   token.Pos // map 关键字位置
+	Key   Expr      // 键的类型
+	Value Expr      // 值的类型
+}
+
+// ChanDir channel 类型的方向
+type ChanDir int
+
+const (
+	SEND ChanDir = 1 << iota // chan<- T
+	RECV                     // <-chan T
+)
+
+// ChanType channel 类型 chan T, chan<- T 或 <-chan T
+type ChanType struct {
+	Begin token.Pos // chan 关键字或 '<-' 的位置
+	Dir   ChanDir   // 双向 channel 为 SEND | RECV
+	Value Expr      // 元素类型
+}
+
+// StructType 结构体类型
+type StructType struct {
+	Struct token.Pos  // struct 关键字位置
+	Fields *FieldList // 字段列表
+}
+
+// InterfaceType 接口类型, 字段的名字为方法名, 类型为 *FuncType
+type InterfaceType struct {
+	Interface token.Pos  // interface 关键字位置
+	Methods   *FieldList // 方法列表
+}
+
+// CompositeLit 复合字面值 T{...}
+type CompositeLit struct {
+	Type   Expr      // 字面值的类型
+	Lbrace token.Pos // '{' 位置
+	Elts   []Expr    // 元素列表
+	Rbrace token.Pos // '}' 位置
+}
+
+// KeyValueExpr 复合字面值中的 key: value
+type KeyValueExpr struct {
+	Key   Expr
+	Colon token.Pos // ':' 位置
+	Value Expr
+}
+
 // SelectorExpr 表示 x.Name 属性选择表达式
 type SelectorExpr struct {
 	X   Expr
 	Sel *Ident
 }
+
+// TypeAssertExpr 类型断言 x.(T), 类型 switch 中的 x.(type) 的 Type 为 nil
+type TypeAssertExpr struct {
+	X      Expr
+	Lparen token.Pos // '(' 位置
+	Type   Expr      // 断言的类型
+	Rparen token.Pos // ')' 位置
+}
@@ -11,27 +11,36 @@ func (e *ExprStmt) Pos() token.Pos {
 }
 
 func (c *CallExpr) Pos() token.Pos {
-	return token.NoPos
+	if c.Fun != nil {
+		return c.Fun.Pos()
+	}
+	if c.Recv != nil {
+		return c.Recv.Pos()
+	}
+	if c.Pkg != nil {
+		return c.Pkg.NamePos
+	}
+	return c.FuncName.NamePos
 }
 
 func (b *BinaryExpr) Pos() token.Pos {
-	return token.NoPos
+	return b.X.Pos()
 }
 
 func (n *Int) Pos() token.Pos {
-	return token.NoPos
+	return n.ValuePos
 }
 
 func (u *UnaryExpr) Pos() token.Pos {
-	return token.NoPos
+	return u.OpPos
 }
 
 func (p *ParenExpr) Pos() token.Pos {
-	return token.NoPos
+	return p.Lparen
 }
 
 func (i *Ident) Pos() token.Pos {
-	return token.NoPos
+	return i.NamePos
 }
 
 func (p *File) Pos() token.Pos {
@@ -50,10 +59,22 @@ func (v *VarSpec) Pos() token.Pos {
 	return token.NoPos
 }
 
+func (c *ConstSpec) Pos() token.Pos {
+	return c.ConstPos
+}
+
+func (c *ConstDecl) Pos() token.Pos {
+	return c.ConstPos
+}
+
 func (a *AssignStmt) Pos() token.Pos {
 	return token.NoPos
 }
 
+func (s *IncDecStmt) Pos() token.Pos {
+	return s.X.Pos()
+}
+
 func (i *IfStmt) Pos() token.Pos {
 	return token.NoPos
 }
@@ -62,6 +83,38 @@ func (f *ForStmt) Pos() token.Pos {
 	return token.NoPos
 }
 
+func (s *RangeStmt) Pos() token.Pos {
+	return s.For
+}
+
+func (s *SwitchStmt) Pos() token.Pos {
+	return s.Switch
+}
+
+func (s *TypeSwitchStmt) Pos() token.Pos {
+	return s.Switch
+}
+
+func (c *CaseClause) Pos() token.Pos {
+	return c.Case
+}
+
+func (s *SelectStmt) Pos() token.Pos {
+	return s.Select
+}
+
+func (c *CommClause) Pos() token.Pos {
+	return c.Case
+}
+
+func (g *GoStmt) Pos() token.Pos {
+	return g.GoPos
+}
+
+func (s *SendStmt) Pos() token.Pos {
+	return s.Chan.Pos()
+}
+
 func (r *ReturnStmt) Pos() token.Pos {
 	return token.NoPos
 }
@@ -71,14 +124,18 @@ func (i *ImportSpec) Pos() token.Pos {
 }
 
 func (s *SelectorExpr) Pos() token.Pos {
-	return token.NoPos
+	return s.X.Pos()
+}
+
+func (t *TypeAssertExpr) Pos() token.Pos {
+	return t.X.Pos()
 }
 func (b BranchStmt) Pos() token.Pos {
 	return token.NoPos
 }
 
 func (f *Float) Pos() token.Pos {
-	return token.NoPos
+	return f.ValuePos
 }
 
 func (l LabeledStmt) Pos() token.Pos {
@@ -86,9 +143,73 @@ func (l LabeledStmt) Pos() token.Pos {
 }
 
 func (c *Char) Pos() token.Pos {
+	return c.ValuePos
+}
+
+func (d *DeferStmt) Pos() token.Pos {
 	return token.NoPos
 }
 
+func (s *StringLit) Pos() token.Pos {
+	return s.ValuePos
+}
+
+func (x *IndexExpr) Pos() token.Pos {
+	return x.X.Pos()
+}
+
+func (x *SliceExpr) Pos() token.Pos {
+	return x.X.Pos()
+}
+
+func (s *StarExpr) Pos() token.Pos {
+	return s.Star
+}
+
+func (t *TypeSpec) Pos() token.Pos {
+	return t.TypePos
+}
+
+func (s *StructType) Pos() token.Pos {
+	return s.Struct
+}
+
+func (t *InterfaceType) Pos() token.Pos {
+	return t.Interface
+}
+
+func (f *FuncType) Pos() token.Pos {
+	return f.Func
+}
+
+func (f *FuncLit) Pos() token.Pos {
+	return f.Type.Func
+}
+
+func (c *CompositeLit) Pos() token.Pos {
+	return c.Type.Pos()
+}
+
+func (k *KeyValueExpr) Pos() token.Pos {
+	return k.Key.Pos()
+}
+
+func (a *ArrayType) Pos() token.Pos {
+	return a.Lbrack
+}
+
+func (e *Ellipsis) Pos() token.Pos {
+	return e.Ellipsis
+}
+
+func (m *MapType) Pos() token.Pos {
+	return m.Map
+}
+
+func (c *ChanType) Pos() token.Pos {
+	return c.Begin
+}
+
 func (b *BlockStmt) End() token.Pos {
 	return token.NoPos
 }
@@ -137,10 +258,22 @@ func (v *VarSpec) End() token.Pos {
 	return token.NoPos
 }
 
+func (c *ConstSpec) End() token.Pos {
+	return token.NoPos
+}
+
+func (c *ConstDecl) End() token.Pos {
+	return token.NoPos
+}
+
 func (a *AssignStmt) End() token.Pos {
 	return token.NoPos
 }
 
+func (s *IncDecStmt) End() token.Pos {
+	return s.TokPos + 2
+}
+
 func (i *IfStmt) End() token.Pos {
 	return token.NoPos
 }
@@ -149,6 +282,38 @@ func (f *ForStmt) End() token.Pos {
 	return token.NoPos
 }
 
+func (s *RangeStmt) End() token.Pos {
+	return s.Body.Rbrace + 1
+}
+
+func (s *SwitchStmt) End() token.Pos {
+	return s.Body.Rbrace + 1
+}
+
+func (s *TypeSwitchStmt) End() token.Pos {
+	return s.Body.Rbrace + 1
+}
+
+func (c *CaseClause) End() token.Pos {
+	return token.NoPos
+}
+
+func (s *SelectStmt) End() token.Pos {
+	return s.Body.Rbrace + 1
+}
+
+func (c *CommClause) End() token.Pos {
+	return token.NoPos
+}
+
+func (g *GoStmt) End() token.Pos {
+	return g.Call.End()
+}
+
+func (s *SendStmt) End() token.Pos {
+	return s.Value.End()
+}
+
 func (r *ReturnStmt) End() token.Pos {
 	return token.NoPos
 }
@@ -161,6 +326,10 @@ func (s *SelectorExpr) End() token.Pos {
 	return token.NoPos
 }
 
+func (t *TypeAssertExpr) End() token.Pos {
+	return t.Rparen + 1
+}
+
 func (f *Float) End() token.Pos {
 	return token.NoPos
 }
@@ -177,6 +346,73 @@ func (c *Char) End() token.Pos {
 	return token.NoPos
 }
 
+func (d *DeferStmt) End() token.Pos {
+	return token.NoPos
+}
+
+func (s *StringLit) End() token.Pos {
+	return token.NoPos
+}
+
+func (x *IndexExpr) End() token.Pos {
+	return token.NoPos
+}
+
+func (x *SliceExpr) End() token.Pos {
+	return token.NoPos
+}
+
+func (s *StarExpr) End() token.Pos {
+	return s.X.End()
+}
+
+func (t *TypeSpec) End() token.Pos {
+	return token.NoPos
+}
+
+func (s *StructType) End() token.Pos {
+	return token.NoPos
+}
+
+func (t *InterfaceType) End() token.Pos {
+	return t.Methods.Closing + 1
+}
+
+func (f *FuncType) End() token.Pos {
+	if f.Results != nil {
+		return f.Results.Closing + 1
+	}
+	return f.Params.Closing + 1
+}
+
+func (f *FuncLit) End() token.Pos {
+	return f.Body.Rbrace + 1
+}
+
+func (c *CompositeLit) End() token.Pos {
+	return c.Rbrace + 1
+}
+
+func (k *KeyValueExpr) End() token.Pos {
+	return k.Value.End()
+}
+
+func (a *ArrayType) End() token.Pos {
+	return token.NoPos
+}
+
+func (e *Ellipsis) End() token.Pos {
+	return e.Elt.End()
+}
+
+func (m *MapType) End() token.Pos {
+	return m.Value.End()
+}
+
+func (c *ChanType) End() token.Pos {
+	return c.Value.End()
+}
+
 func (b *BlockStmt) stmtType() {
 
 }
@@ -237,10 +473,22 @@ func (v *VarSpec) nodeType() {
 
 }
 
+func (c *ConstSpec) nodeType() {
+
+}
+
+func (c *ConstDecl) stmtType() {
+
+}
+
 func (a *AssignStmt) stmtType() {
 
 }
 
+func (s *IncDecStmt) stmtType() {
+
+}
+
 func (i *IfStmt) stmtType() {
 
 }
@@ -249,6 +497,38 @@ func (f *ForStmt) stmtType() {
 
 }
 
+func (s *RangeStmt) stmtType() {
+
+}
+
+func (s *SwitchStmt) stmtType() {
+
+}
+
+func (s *TypeSwitchStmt) stmtType() {
+
+}
+
+func (c *CaseClause) stmtType() {
+
+}
+
+func (s *SelectStmt) stmtType() {
+
+}
+
+func (c *CommClause) stmtType() {
+
+}
+
+func (g *GoStmt) stmtType() {
+
+}
+
+func (s *SendStmt) stmtType() {
+
+}
+
 func (r *ReturnStmt) stmtType() {
 
 }
@@ -261,6 +541,10 @@ func (s *SelectorExpr) exprType() {
 
 }
 
+func (t *TypeAssertExpr) exprType() {
+
+}
+
 func (f *Float) exprType() {
 
 }
@@ -276,3 +560,67 @@ func (l LabeledStmt) stmtType() {
 func (c *Char) exprType() {
 
 }
+
+func (d *DeferStmt) stmtType() {
+
+}
+
+func (s *StringLit) exprType() {
+
+}
+
+func (x *IndexExpr) exprType() {
+
+}
+
+func (a *ArrayType) exprType() {
+
+}
+
+func (e *Ellipsis) exprType() {
+
+}
+
+func (m *MapType) exprType() {
+
+}
+
+func (c *ChanType) exprType() {
+
+}
+
+func (x *SliceExpr) exprType() {
+
+}
+
+func (t *TypeSpec) nodeType() {
+
+}
+
+func (s *StructType) exprType() {
+
+}
+
+func (t *InterfaceType) exprType() {
+
+}
+
+func (f *FuncType) exprType() {
+
+}
+
+func (f *FuncLit) exprType() {
+
+}
+
+func (c *CompositeLit) exprType() {
+
+}
+
+func (k *KeyValueExpr) exprType() {
+
+}
+
+func (s *StarExpr) exprType() {
+
+}
@@ -0,0 +1,196 @@
+package ast
+
+// Inspect 深度优先遍历语法树, 对每个结点调用 f, f 返回 false 时不再遍历该结点的子结点.
+// node 可以是 Node, Stmt 或 Expr.
+func Inspect(node interface{}, f func(node interface{}) bool) {
+	if node == nil || !f(node) {
+		return
+	}
+
+	switch n := node.(type) {
+	case *File:
+		for _, x := range n.Types {
+			Inspect(x, f)
+		}
+		for _, x := range n.Consts {
+			Inspect(x, f)
+		}
+		for _, x := range n.Globals {
+			Inspect(x, f)
+		}
+		for _, x := range n.Funcs {
+			Inspect(x, f)
+		}
+	case *TypeSpec:
+		Inspect(n.Name, f)
+		inspectExpr(n.Type, f)
+	case *FuncDecl:
+		if n.Recv != nil {
+			Inspect(n.Recv, f)
+		}
+		Inspect(n.Type, f)
+		if n.Body != nil {
+			Inspect(n.Body, f)
+		}
+	case *FuncType:
+		Inspect(n.Params, f)
+		if n.Results != nil {
+			Inspect(n.Results, f)
+		}
+	case *FieldList:
+		for _, x := range n.List {
+			Inspect(x, f)
+		}
+	case *Field:
+		if n.Name != nil {
+			Inspect(n.Name, f)
+		}
+		inspectExpr(n.Type, f)
+
+	// 语句
+	case *BlockStmt:
+		for _, x := range n.List {
+			inspectStmt(x, f)
+		}
+	case *ExprStmt:
+		inspectExpr(n.X, f)
+	case *VarSpec:
+		Inspect(n.Name, f)
+		inspectExpr(n.Type, f)
+		inspectExpr(n.Value, f)
+	case *ConstDecl:
+		for _, x := range n.Specs {
+			Inspect(x, f)
+		}
+	case *ConstSpec:
+		Inspect(n.Name, f)
+		inspectExpr(n.Type, f)
+		inspectExpr(n.Value, f)
+	case *IncDecStmt:
+		inspectExpr(n.X, f)
+	case *AssignStmt:
+		for _, x := range n.Target {
+			inspectExpr(x, f)
+		}
+		for _, x := range n.Value {
+			inspectExpr(x, f)
+		}
+	case *ReturnStmt:
+		for _, x := range n.Results {
+			inspectExpr(x, f)
+		}
+	case *DeferStmt:
+		Inspect(n.Call, f)
+	case *GoStmt:
+		Inspect(n.Call, f)
+	case *SendStmt:
+		inspectExpr(n.Chan, f)
+		inspectExpr(n.Value, f)
+	case *IfStmt:
+		inspectStmt(n.Init, f)
+		inspectExpr(n.Cond, f)
+		Inspect(n.Body, f)
+		inspectStmt(n.Else, f)
+	case *ForStmt:
+		inspectStmt(n.Init, f)
+		inspectExpr(n.Cond, f)
+		inspectStmt(n.Post, f)
+		Inspect(n.Body, f)
+	case *RangeStmt:
+		inspectExpr(n.Key, f)
+		inspectExpr(n.Value, f)
+		inspectExpr(n.X, f)
+		Inspect(n.Body, f)
+	case *SwitchStmt:
+		inspectStmt(n.Init, f)
+		inspectExpr(n.Tag, f)
+		Inspect(n.Body, f)
+	case *TypeSwitchStmt:
+		inspectStmt(n.Init, f)
+		inspectStmt(n.Assign, f)
+		Inspect(n.Body, f)
+	case *CaseClause:
+		for _, x := range n.List {
+			inspectExpr(x, f)
+		}
+		for _, x := range n.Body {
+			inspectStmt(x, f)
+		}
+	case *SelectStmt:
+		Inspect(n.Body, f)
+	case *CommClause:
+		inspectStmt(n.Comm, f)
+		for _, x := range n.Body {
+			inspectStmt(x, f)
+		}
+	case *LabeledStmt:
+		inspectStmt(n.Stmt, f)
+
+	// 表达式
+	case *BinaryExpr:
+		inspectExpr(n.X, f)
+		inspectExpr(n.Y, f)
+	case *UnaryExpr:
+		inspectExpr(n.X, f)
+	case *StarExpr:
+		inspectExpr(n.X, f)
+	case *ParenExpr:
+		inspectExpr(n.X, f)
+	case *IndexExpr:
+		inspectExpr(n.X, f)
+		inspectExpr(n.Index, f)
+	case *SliceExpr:
+		inspectExpr(n.X, f)
+		inspectExpr(n.Low, f)
+		inspectExpr(n.High, f)
+	case *SelectorExpr:
+		inspectExpr(n.X, f)
+	case *TypeAssertExpr:
+		inspectExpr(n.X, f)
+		inspectExpr(n.Type, f)
+	case *CallExpr:
+		inspectExpr(n.Fun, f)
+		inspectExpr(n.Recv, f)
+		for _, x := range n.Args {
+			inspectExpr(x, f)
+		}
+	case *CompositeLit:
+		inspectExpr(n.Type, f)
+		for _, x := range n.Elts {
+			inspectExpr(x, f)
+		}
+	case *KeyValueExpr:
+		inspectExpr(n.Key, f)
+		inspectExpr(n.Value, f)
+	case *ArrayType:
+		inspectExpr(n.Len, f)
+		inspectExpr(n.Elem, f)
+	case *Ellipsis:
+		inspectExpr(n.Elt, f)
+	case *MapType:
+		inspectExpr(n.Key, f)
+		inspectExpr(n.Value, f)
+	case *ChanType:
+		inspectExpr(n.Value, f)
+	case *StructType:
+		Inspect(n.Fields, f)
+	case *InterfaceType:
+		Inspect(n.Methods, f)
+	case *FuncLit:
+		Inspect(n.Type, f)
+		Inspect(n.Body, f)
+	}
+}
+
+// inspectExpr 和 inspectStmt 过滤掉值为 nil 的接口, 避免 f 收到有类型的 nil
+func inspectExpr(x Expr, f func(node interface{}) bool) {
+	if x != nil {
+		Inspect(x, f)
+	}
+}
+
+func inspectStmt(x Stmt, f func(node interface{}) bool) {
+	if x != nil {
+		Inspect(x, f)
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"tiny-go/lexer"
 	"tiny-go/parser"
 	"tiny-go/token"
+	"tiny-go/types"
 )
 
 type Option struct {
@@ -88,8 +89,11 @@ func (p *Context) ASM(fileName string, src interface{}) (ll string, err error) {
 	if err != nil {
 		return "", err
 	}
-	ll = new(compiler.Compiler).Compile(f)
-	return
+	info, err := types.Check(f)
+	if err != nil {
+		return "", err
+	}
+	return compiler.NewCompiler().Compile(f, info), nil
 }
 
 func (p *Context) Build(fileName string, src interface{}, outFIle string) (output []byte, err error) {
@@ -105,25 +109,29 @@ func (p *Context) build(fileName string, src interface{}, outFile, goos, goarch
 	if err != nil {
 		return nil, err
 	}
+	info, err := types.Check(f)
+	if err != nil {
+		return nil, err
+	}
 
 	const (
-		_a_out_ll         = ".\\builtin\\_a.out.ll"
-		_a_out_ll_o       = ".\\builtin\\_a.out.ll.o"
-		_a_out_builtin_ll = ".\\builtin\\_a.out.builtin.ll"
+		_a_out_ll        = ".\\builtin\\_a.out.ll"
+		_a_out_ll_o      = ".\\builtin\\_a.out.ll.o"
+		_a_out_builtin_c = ".\\builtin\\_a.out.builtin.c"
 	)
 	if !p.opt.Debug {
 		defer os.Remove(_a_out_ll)
 		defer os.Remove(_a_out_ll_o)
-		defer os.Remove(_a_out_builtin_ll)
+		defer os.Remove(_a_out_builtin_c)
 	}
 
-	llBuiltin := builtin.GetBuiltinLL(p.opt.GOOS, p.opt.GOARCH)
-	err = os.WriteFile(_a_out_builtin_ll, []byte(llBuiltin), 0666)
+	cBuiltin := builtin.GetBuiltinC(p.opt.GOOS, p.opt.GOARCH)
+	err = os.WriteFile(_a_out_builtin_c, []byte(cBuiltin), 0666)
 	if err != nil {
 		return nil, err
 	}
 
-	ll := compiler.NewCompiler().Compile(f)
+	ll := compiler.NewCompiler().Compile(f, info)
 	err = os.WriteFile(_a_out_ll, []byte(ll), 0666)
 	if err != nil {
 		return nil, err
@@ -147,10 +155,9 @@ func (p *Context) build(fileName string, src interface{}, outFile, goos, goarch
 		data, err := cmdWasmLD.CombinedOutput()
 		return data, err
 	}
-	cmd := exec.Command(
-		p.opt.Clang, "-Wno-override-module", "-o", outFile,
-		_a_out_ll, _a_out_builtin_ll,
-	)
+	// goroutine 运行在 pthread 线程上, Windows 上需要 MinGW 的 winpthreads 等 pthread 库
+	args := []string{"-Wno-override-module", "-o", outFile, _a_out_ll, _a_out_builtin_c, "-lpthread"}
+	cmd := exec.Command(p.opt.Clang, args...)
 
 	data, err := cmd.CombinedOutput()
 	return data, err
@@ -0,0 +1,82 @@
+package build
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"tiny-go/builtin"
+)
+
+// link 把 LLVM IR 和 C 运行时编译为可执行文件, 优先使用 clang,
+// 没有 clang 时用 llc 和 cc, 都没有时跳过测试
+func link(t *testing.T, dir, ll string) string {
+	t.Helper()
+	llFile := filepath.Join(dir, "a.out.ll")
+	cFile := filepath.Join(dir, "a.out.builtin.c")
+	exe := filepath.Join(dir, "a.out")
+	if err := os.WriteFile(llFile, []byte(ll), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(cFile, []byte(builtin.GetBuiltinC(runtime.GOOS, runtime.GOARCH)), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	var cmds [][]string
+	if clang, err := exec.LookPath("clang"); err == nil {
+		cmds = [][]string{{clang, "-Wno-override-module", "-o", exe, llFile, cFile, "-lpthread", "-lm"}}
+	} else {
+		llc, err1 := exec.LookPath("llc")
+		cc, err2 := exec.LookPath("cc")
+		if err1 != nil || err2 != nil {
+			t.Skip("clang, or llc and cc, not found")
+		}
+		sFile := filepath.Join(dir, "a.out.s")
+		cmds = [][]string{
+			{llc, "-relocation-model=pic", "-o", sFile, llFile},
+			{cc, "-o", exe, sFile, cFile, "-lpthread", "-lm"},
+		}
+	}
+	for _, args := range cmds {
+		if data, err := exec.Command(args[0], args[1:]...).CombinedOutput(); err != nil {
+			t.Fatalf("%s: %v\n%s", filepath.Base(args[0]), err, data)
+		}
+	}
+	return exe
+}
+
+// TestRun 编译并运行 testdata 下的程序, 输出与同名的 .out 文件比较
+func TestRun(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "*.tgo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		file := file
+		name := strings.TrimSuffix(filepath.Base(file), ".tgo")
+		t.Run(name, func(t *testing.T) {
+			want, err := os.ReadFile(strings.TrimSuffix(file, ".tgo") + ".out")
+			if err != nil {
+				t.Fatal(err)
+			}
+			ll, err := NewContext(nil).ASM(file, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			exe := link(t, t.TempDir(), ll)
+			// 未恢复的 panic 使程序以非零状态退出, 退出状态附加在输出的末尾
+			got, err := exec.Command(exe).CombinedOutput()
+			if exitErr, ok := err.(*exec.ExitError); ok {
+				got = append(got, fmt.Sprintf("exit status %d\n", exitErr.ExitCode())...)
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("output:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
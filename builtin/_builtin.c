@@ -1,11 +1,1669 @@
+// goroutine 使用 pthread 线程, Windows 上需要 MinGW 的 winpthreads
+#include <pthread.h>
+#include <setjmp.h>
+#include <stdarg.h>
 #include <stdio.h>
 #include <stdlib.h>
-
-int tiny_go_builtin_println(int x){
-    return printf("%d\n",x);
-}
+#include <string.h>
 
 int tiny_go_builtin_exit(int x){
     exit(x);
     return 0;
-}
\ No newline at end of file
+}
+
+static void tiny_go_runtime_panic(char *pos, int npos, const char *format, ...);
+
+// 下标越界, pos 为越界下标在源码中的位置
+void tiny_go_builtin_panic_index(char *pos, int npos, int index, int length){
+    if (index < 0) {
+        tiny_go_runtime_panic(pos, npos, "runtime error: index out of range [%d]", index);
+    }
+    tiny_go_runtime_panic(pos, npos, "runtime error: index out of range [%d] with length %d", index, length);
+}
+
+void tiny_go_builtin_panic_nil(char *pos, int npos){
+    tiny_go_runtime_panic(pos, npos, "runtime error: invalid memory address or nil pointer dereference");
+}
+
+void tiny_go_builtin_panic_divide(char *pos, int npos){
+    tiny_go_runtime_panic(pos, npos, "runtime error: integer divide by zero");
+}
+
+void tiny_go_builtin_panic_shift(char *pos, int npos){
+    tiny_go_runtime_panic(pos, npos, "runtime error: negative shift amount");
+}
+
+// 切片的内存布局, 和 LLVM 中的 { T*, i32, i32 } 一致
+typedef struct {
+    char *ptr;
+    int len;
+    int cap;
+} tiny_go_slice;
+
+// 在堆上分配 size 字节的内存, 内容初始化为 0
+void *tiny_go_builtin_alloc(int size){
+    void *p = calloc(1, size > 0 ? size : 1);
+    if (p == NULL) {
+        fflush(stdout);
+        fprintf(stderr, "fatal error: out of memory\n");
+        exit(2);
+    }
+    return p;
+}
+
+void tiny_go_builtin_make_slice(tiny_go_slice *s, int len, int cap, int elem_size, char *pos, int npos){
+    if (len < 0) {
+        tiny_go_runtime_panic(pos, npos, "runtime error: makeslice: len out of range");
+    }
+    if (cap < len) {
+        tiny_go_runtime_panic(pos, npos, "runtime error: makeslice: cap out of range");
+    }
+    s->ptr = tiny_go_builtin_alloc(cap * elem_size);
+    s->len = len;
+    s->cap = cap;
+}
+
+// 把切片的长度扩展为 new_len, 容量不足时按两倍扩容并复制原有元素
+void tiny_go_builtin_slice_grow(tiny_go_slice *s, int new_len, int elem_size){
+    if (new_len > s->cap) {
+        int new_cap = s->cap * 2;
+        if (new_cap < new_len) {
+            new_cap = new_len;
+        }
+        char *ptr = tiny_go_builtin_alloc(new_cap * elem_size);
+        if (s->len > 0) {
+            memcpy(ptr, s->ptr, s->len * elem_size);
+        }
+        s->ptr = ptr;
+        s->cap = new_cap;
+    }
+    s->len = new_len;
+}
+
+// 切片下标越界, is_string 为 1 时 max 为字符串长度
+void tiny_go_builtin_panic_slice(char *pos, int npos, int low, int high, int max, int is_string){
+    if (high < 0 || high > max) {
+        tiny_go_runtime_panic(pos, npos, "runtime error: slice bounds out of range [:%d] with %s %d",
+            high, is_string ? "length" : "capacity", max);
+    }
+    tiny_go_runtime_panic(pos, npos, "runtime error: slice bounds out of range [%d:%d]", low, high);
+}
+
+// 拼接两个字符串, 返回新分配的字节数组
+char *tiny_go_builtin_string_concat(char *a, int na, char *b, int nb){
+    char *s = tiny_go_builtin_alloc(na + nb);
+    memcpy(s, a, na);
+    memcpy(s + na, b, nb);
+    return s;
+}
+
+// 按字节比较两个字符串, 返回 -1, 0 或 1
+int tiny_go_builtin_string_compare(char *a, int na, char *b, int nb){
+    int n = na < nb ? na : nb;
+    int r = memcmp(a, b, n);
+    if (r != 0) {
+        return r < 0 ? -1 : 1;
+    }
+    if (na == nb) {
+        return 0;
+    }
+    return na < nb ? -1 : 1;
+}
+
+// 类型描述符的内存布局, 和 LLVM 中的 %tiny_go_type 一致.
+// equal 比较两个数据指针指向的值, 不能比较的类型为 NULL; kind 为值的种类, 见 TINY_GO_KIND_*
+typedef struct {
+    char *name;
+    int n;
+    int (*equal)(void *x, void *y);
+    int kind;
+} tiny_go_type;
+
+// 获取接口的动态类型, itab 的第一个元素为类型描述符, nil 接口返回 NULL
+tiny_go_type *tiny_go_builtin_iface_type(tiny_go_type **itab){
+    return itab == NULL ? NULL : itab[0];
+}
+
+// 比较两个接口值, 动态类型相同且值相等时返回 1
+int tiny_go_builtin_iface_equal(tiny_go_type **itab_x, void *x, tiny_go_type **itab_y, void *y, char *pos, int npos){
+    tiny_go_type *tx = tiny_go_builtin_iface_type(itab_x);
+    tiny_go_type *ty = tiny_go_builtin_iface_type(itab_y);
+    if (tx != ty) {
+        return 0;
+    }
+    if (tx == NULL) {
+        return 1;
+    }
+    if (tx->equal == NULL) {
+        tiny_go_runtime_panic(pos, npos, "runtime error: comparing uncomparable type %.*s", tx->n, tx->name);
+    }
+    return tx->equal(x, y);
+}
+
+// 类型断言失败, iface 为接口的静态类型, have 为动态类型, want_iface 为 1 时断言的类型是接口
+void tiny_go_builtin_panic_assert(char *pos, int npos, char *iface, int niface, tiny_go_type *have,
+    char *want, int nwant, int want_iface){
+    if (have == NULL) {
+        tiny_go_runtime_panic(pos, npos, "interface conversion: %.*s is nil, not %.*s", niface, iface, nwant, want);
+    }
+    if (want_iface) {
+        tiny_go_runtime_panic(pos, npos, "interface conversion: %.*s is not %.*s", have->n, have->name, nwant, want);
+    }
+    tiny_go_runtime_panic(pos, npos, "interface conversion: %.*s is %.*s, not %.*s",
+        niface, iface, have->n, have->name, nwant, want);
+}
+
+// 字符串的内存布局, 和 LLVM 中的 %string 一致
+typedef struct {
+    char *ptr;
+    int len;
+} tiny_go_string;
+
+// 类型描述符中值的种类, 和 panic.go 中的 kind* 一致, 用于打印 panic 的值和打印函数的参数.
+// 命名类型的种类带有 TINY_GO_KIND_NAMED 标记, 指针的数据就是指针本身
+enum {
+    TINY_GO_KIND_OTHER,
+    TINY_GO_KIND_BOOL,
+    TINY_GO_KIND_INT8,
+    TINY_GO_KIND_INT16,
+    TINY_GO_KIND_INT32,
+    TINY_GO_KIND_INT64,
+    TINY_GO_KIND_UINT8,
+    TINY_GO_KIND_UINT16,
+    TINY_GO_KIND_UINT32,
+    TINY_GO_KIND_UINT64,
+    TINY_GO_KIND_FLOAT32,
+    TINY_GO_KIND_FLOAT64,
+    TINY_GO_KIND_STRING,
+    TINY_GO_KIND_POINTER,
+    TINY_GO_KIND_NAMED = 0x100,
+};
+
+int tiny_go_builtin_decode_rune(char *s, int n, int i, int *width);
+
+// 打印函数的参数, 和 LLVM 中的 interface{} 一致, nil 接口的 itab 为 NULL
+typedef struct {
+    tiny_go_type **itab;
+    void *data;
+} tiny_go_iface;
+
+// 格式化输出的缓冲区, 打印函数格式化全部参数后一次写出
+typedef struct {
+    char *ptr;
+    int len;
+    int cap;
+} tiny_go_buf;
+
+static void tiny_go_buf_write(tiny_go_buf *b, const char *s, int n){
+    if (b->len + n > b->cap) {
+        b->cap = b->cap * 2 > b->len + n ? b->cap * 2 : b->len + n + 64;
+        b->ptr = realloc(b->ptr, b->cap);
+        if (b->ptr == NULL) {
+            fflush(stdout);
+            fprintf(stderr, "fatal error: out of memory\n");
+            exit(2);
+        }
+    }
+    memcpy(b->ptr + b->len, s, n);
+    b->len += n;
+}
+
+static void tiny_go_buf_str(tiny_go_buf *b, const char *s){
+    tiny_go_buf_write(b, s, strlen(s));
+}
+
+static void tiny_go_buf_byte(tiny_go_buf *b, char c){
+    tiny_go_buf_write(b, &c, 1);
+}
+
+static void tiny_go_buf_printf(tiny_go_buf *b, const char *format, ...){
+    char tmp[128];
+    va_list ap;
+    va_start(ap, format);
+    int n = vsnprintf(tmp, sizeof(tmp), format, ap);
+    va_end(ap);
+    if (n < (int)sizeof(tmp)) {
+        tiny_go_buf_write(b, tmp, n);
+        return;
+    }
+    char *s = malloc(n + 1);
+    va_start(ap, format);
+    vsnprintf(s, n + 1, format, ap);
+    va_end(ap);
+    tiny_go_buf_write(b, s, n);
+    free(s);
+}
+
+// 把缓冲区的内容写到 f 并释放缓冲区, 返回写出的字节数
+static int tiny_go_buf_flush(tiny_go_buf *b, FILE *f){
+    int n = b->len;
+    fwrite(b->ptr, 1, n, f);
+    free(b->ptr);
+    return n;
+}
+
+// 把码点编码为 UTF-8, 无效的码点编码为 U+FFFD, 返回字节数
+static int tiny_go_encode_rune(char *p, int r){
+    if (r < 0 || r > 0x10FFFF || (r >= 0xD800 && r <= 0xDFFF)) {
+        r = 0xFFFD;
+    }
+    if (r < 0x80) {
+        p[0] = r;
+        return 1;
+    }
+    if (r < 0x800) {
+        p[0] = 0xC0 | r >> 6;
+        p[1] = 0x80 | (r & 0x3F);
+        return 2;
+    }
+    if (r < 0x10000) {
+        p[0] = 0xE0 | r >> 12;
+        p[1] = 0x80 | (r >> 6 & 0x3F);
+        p[2] = 0x80 | (r & 0x3F);
+        return 3;
+    }
+    p[0] = 0xF0 | r >> 18;
+    p[1] = 0x80 | (r >> 12 & 0x3F);
+    p[2] = 0x80 | (r >> 6 & 0x3F);
+    p[3] = 0x80 | (r & 0x3F);
+    return 4;
+}
+
+static int tiny_go_kind_is_int(int kind){
+    return kind >= TINY_GO_KIND_INT8 && kind <= TINY_GO_KIND_UINT64;
+}
+
+static int tiny_go_kind_is_signed(int kind){
+    return kind >= TINY_GO_KIND_INT8 && kind <= TINY_GO_KIND_INT64;
+}
+
+// 读取整数, 有符号整数先扩展为 long long
+static unsigned long long tiny_go_load_int(int kind, void *data){
+    switch (kind) {
+    case TINY_GO_KIND_INT8:
+        return (long long)*(signed char *)data;
+    case TINY_GO_KIND_INT16:
+        return (long long)*(short *)data;
+    case TINY_GO_KIND_INT32:
+        return (long long)*(int *)data;
+    case TINY_GO_KIND_INT64:
+        return *(long long *)data;
+    case TINY_GO_KIND_UINT8:
+        return *(unsigned char *)data;
+    case TINY_GO_KIND_UINT16:
+        return *(unsigned short *)data;
+    case TINY_GO_KIND_UINT32:
+        return *(unsigned int *)data;
+    default:
+        return *(unsigned long long *)data;
+    }
+}
+
+static double tiny_go_load_float(int kind, void *data){
+    if (kind == TINY_GO_KIND_FLOAT32) {
+        return *(float *)data;
+    }
+    return *(double *)data;
+}
+
+// 无穷大和 NaN 按 Go 的格式打印为 +Inf, -Inf 和 NaN, 其他值返回 NULL
+static const char *tiny_go_float_special(double x){
+    if (x != x) {
+        return "NaN";
+    }
+    if (x > 1.7976931348623157e308) {
+        return "+Inf";
+    }
+    if (x < -1.7976931348623157e308) {
+        return "-Inf";
+    }
+    return NULL;
+}
+
+// 按 Go 内置的 print 的格式写浮点数, 如 +1.500000e+000
+static void tiny_go_write_float(tiny_go_buf *b, double x){
+    const char *special = tiny_go_float_special(x);
+    if (special != NULL) {
+        tiny_go_buf_str(b, special);
+        return;
+    }
+    char tmp[40];
+    snprintf(tmp, sizeof(tmp), "%+.6e", x);
+    char *e = strchr(tmp, 'e');
+    tiny_go_buf_printf(b, "%.*se%c%03d", (int)(e - tmp), tmp, e[1], atoi(e + 2));
+}
+
+// 写带引号的字符串, 按 Go 的规则转义, 无效的 UTF-8 字节写为 \x..
+static void tiny_go_write_quoted(tiny_go_buf *b, char *s, int n, char quote){
+    tiny_go_buf_byte(b, quote);
+    for (int i = 0; i < n;) {
+        int width;
+        int r = tiny_go_builtin_decode_rune(s, n, i, &width);
+        unsigned char c = s[i];
+        if (r == 0xFFFD && width == 1) {
+            tiny_go_buf_printf(b, "\\x%02x", c);
+        } else if (r == quote || r == '\\') {
+            tiny_go_buf_byte(b, '\\');
+            tiny_go_buf_byte(b, r);
+        } else if ((r >= 0x20 && r < 0x7F) || r >= 0xA0) {
+            tiny_go_buf_write(b, s + i, width);
+        } else {
+            switch (r) {
+            case '\a': tiny_go_buf_str(b, "\\a"); break;
+            case '\b': tiny_go_buf_str(b, "\\b"); break;
+            case '\f': tiny_go_buf_str(b, "\\f"); break;
+            case '\n': tiny_go_buf_str(b, "\\n"); break;
+            case '\r': tiny_go_buf_str(b, "\\r"); break;
+            case '\t': tiny_go_buf_str(b, "\\t"); break;
+            case '\v': tiny_go_buf_str(b, "\\v"); break;
+            default:
+                if (r < 0x80) {
+                    tiny_go_buf_printf(b, "\\x%02x", r);
+                } else {
+                    tiny_go_buf_printf(b, "\\u%04x", r);
+                }
+            }
+        }
+        i += width;
+    }
+    tiny_go_buf_byte(b, quote);
+}
+
+// 按 Go 内置的 print 和 println 的格式写一个参数
+static void tiny_go_write_arg(tiny_go_buf *b, tiny_go_iface *arg){
+    if (arg->itab == NULL) {
+        tiny_go_buf_str(b, "<nil>");
+        return;
+    }
+    tiny_go_type *t = arg->itab[0];
+    int kind = t->kind & ~TINY_GO_KIND_NAMED;
+    if (tiny_go_kind_is_signed(kind)) {
+        tiny_go_buf_printf(b, "%lld", (long long)tiny_go_load_int(kind, arg->data));
+    } else if (tiny_go_kind_is_int(kind)) {
+        tiny_go_buf_printf(b, "%llu", tiny_go_load_int(kind, arg->data));
+    } else if (kind == TINY_GO_KIND_FLOAT32 || kind == TINY_GO_KIND_FLOAT64) {
+        tiny_go_write_float(b, tiny_go_load_float(kind, arg->data));
+    } else if (kind == TINY_GO_KIND_BOOL) {
+        tiny_go_buf_str(b, *(char *)arg->data ? "true" : "false");
+    } else if (kind == TINY_GO_KIND_STRING) {
+        tiny_go_string *s = arg->data;
+        tiny_go_buf_write(b, s->ptr, s->len);
+    } else if (kind == TINY_GO_KIND_POINTER) {
+        tiny_go_buf_printf(b, "0x%llx", (unsigned long long)(size_t)arg->data);
+    } else {
+        tiny_go_buf_printf(b, "(%.*s) 0x%llx", t->n, t->name, (unsigned long long)(size_t)arg->data);
+    }
+}
+
+// print(args...), 参数之间没有空格
+int tiny_go_builtin_print(tiny_go_iface *args, int n){
+    tiny_go_buf b = {0};
+    for (int i = 0; i < n; i++) {
+        tiny_go_write_arg(&b, &args[i]);
+    }
+    return tiny_go_buf_flush(&b, stdout);
+}
+
+// println(args...), 参数之间用空格分隔, 最后换行
+int tiny_go_builtin_println(tiny_go_iface *args, int n){
+    tiny_go_buf b = {0};
+    for (int i = 0; i < n; i++) {
+        if (i > 0) {
+            tiny_go_buf_byte(&b, ' ');
+        }
+        tiny_go_write_arg(&b, &args[i]);
+    }
+    tiny_go_buf_byte(&b, '\n');
+    return tiny_go_buf_flush(&b, stdout);
+}
+
+// printf 的格式说明 %[flags][width][.prec]verb, width 和 prec 为 -1 表示没有指定
+typedef struct {
+    int minus, plus, sharp, zero, space;
+    int width, prec;
+    int verb;
+} tiny_go_spec;
+
+// 按宽度用空格或 0 填充, 宽度按字符计算; 数字的 0 填在符号之后, 由 sign 给出符号的字节数
+static void tiny_go_fmt_pad(tiny_go_buf *b, tiny_go_spec *sp, const char *s, int n, int sign){
+    int runes = 0;
+    for (int i = 0; i < n; i++) {
+        if ((s[i] & 0xC0) != 0x80) {
+            runes++;
+        }
+    }
+    int pad = sp->width - runes;
+    if (pad <= 0) {
+        tiny_go_buf_write(b, s, n);
+        return;
+    }
+    if (sp->minus) {
+        tiny_go_buf_write(b, s, n);
+        for (int i = 0; i < pad; i++) {
+            tiny_go_buf_byte(b, ' ');
+        }
+        return;
+    }
+    if (sp->zero) {
+        tiny_go_buf_write(b, s, sign);
+        for (int i = 0; i < pad; i++) {
+            tiny_go_buf_byte(b, '0');
+        }
+        tiny_go_buf_write(b, s + sign, n - sign);
+        return;
+    }
+    for (int i = 0; i < pad; i++) {
+        tiny_go_buf_byte(b, ' ');
+    }
+    tiny_go_buf_write(b, s, n);
+}
+
+// 格式化整数, verb 为 d, b, o, x, X, c, q 或 U
+static void tiny_go_fmt_int(tiny_go_buf *b, tiny_go_spec *sp, unsigned long long u, int is_signed){
+    char tmp[96];
+    int n = 0;
+    if (sp->verb == 'c' || sp->verb == 'q' || sp->verb == 'U') {
+        long long r = is_signed ? (long long)u : (long long)(u > 0x7FFFFFFF ? 0xFFFD : u);
+        if (r < 0 || r > 0x10FFFF) {
+            r = 0xFFFD;
+        }
+        if (sp->verb == 'c') {
+            n = tiny_go_encode_rune(tmp, r);
+            tiny_go_fmt_pad(b, sp, tmp, n, 0);
+            return;
+        }
+        if (sp->verb == 'U') {
+            n = snprintf(tmp, sizeof(tmp), "U+%04llX", r);
+            tiny_go_fmt_pad(b, sp, tmp, n, 0);
+            return;
+        }
+        tiny_go_buf q = {0};
+        n = tiny_go_encode_rune(tmp, r);
+        tiny_go_write_quoted(&q, tmp, n, '\'');
+        tiny_go_fmt_pad(b, sp, q.ptr, q.len, 0);
+        free(q.ptr);
+        return;
+    }
+
+    int neg = is_signed && (long long)u < 0;
+    if (neg) {
+        u = -u;
+    }
+    int base = 10;
+    const char *digits = "0123456789abcdef";
+    switch (sp->verb) {
+    case 'b': base = 2; break;
+    case 'o': base = 8; break;
+    case 'x': base = 16; break;
+    case 'X': base = 16, digits = "0123456789ABCDEF"; break;
+    }
+
+    // 从后向前写数字
+    char num[80];
+    int i = sizeof(num);
+    while (u > 0) {
+        num[--i] = digits[u % base];
+        u /= base;
+    }
+    int prec = sp->prec >= 0 ? sp->prec : 1;
+    while ((int)sizeof(num) - i < prec && i > 0) {
+        num[--i] = '0';
+    }
+
+    if (neg) {
+        tmp[n++] = '-';
+    } else if (sp->plus) {
+        tmp[n++] = '+';
+    } else if (sp->space) {
+        tmp[n++] = ' ';
+    }
+    if (sp->sharp) {
+        if (base == 2) {
+            tmp[n++] = '0', tmp[n++] = 'b';
+        } else if (base == 16) {
+            tmp[n++] = '0', tmp[n++] = sp->verb;
+        } else if (base == 8 && (i == (int)sizeof(num) || num[i] != '0')) {
+            tmp[n++] = '0';
+        }
+    }
+    int sign = n;
+    memcpy(tmp + n, num + i, sizeof(num) - i);
+    n += sizeof(num) - i;
+
+    // 指定精度时不用 0 填充
+    int zero = sp->zero;
+    if (sp->prec >= 0) {
+        sp->zero = 0;
+    }
+    tiny_go_fmt_pad(b, sp, tmp, n, sign);
+    sp->zero = zero;
+}
+
+// 按 Go 的 %g 格式化浮点数, 使用能准确还原原值的最少的有效数字. is32 为 1 时按 float32 还原
+static int tiny_go_format_shortest(char *out, double x, int is32, int upper){
+    char tmp[40];
+    for (int prec = 1; prec <= 17; prec++) {
+        snprintf(tmp, sizeof(tmp), "%.*e", prec - 1, x);
+        double y = strtod(tmp, NULL);
+        if (is32 ? (float)y == (float)x : y == x) {
+            break;
+        }
+    }
+
+    // tmp 的格式为 [-]d.ddde±dd, 取出有效数字并去掉末尾的 0
+    char *e = strchr(tmp, 'e');
+    int exp = atoi(e + 1);
+    char digits[24];
+    int nd = 0;
+    for (char *p = tmp; p < e; p++) {
+        if (*p >= '0' && *p <= '9') {
+            digits[nd++] = *p;
+        }
+    }
+    while (nd > 1 && digits[nd - 1] == '0') {
+        nd--;
+    }
+
+    int n = 0;
+    if (tmp[0] == '-') {
+        out[n++] = '-';
+    }
+    if (exp < -4 || exp >= 6) {
+        out[n++] = digits[0];
+        if (nd > 1) {
+            out[n++] = '.';
+            memcpy(out + n, digits + 1, nd - 1);
+            n += nd - 1;
+        }
+        n += sprintf(out + n, "%c%c%02d", upper ? 'E' : 'e', exp < 0 ? '-' : '+', exp < 0 ? -exp : exp);
+    } else if (exp < 0) {
+        out[n++] = '0';
+        out[n++] = '.';
+        for (int i = 0; i < -exp - 1; i++) {
+            out[n++] = '0';
+        }
+        memcpy(out + n, digits, nd);
+        n += nd;
+    } else {
+        for (int i = 0; i <= exp; i++) {
+            out[n++] = i < nd ? digits[i] : '0';
+        }
+        if (nd > exp + 1) {
+            out[n++] = '.';
+            memcpy(out + n, digits + exp + 1, nd - exp - 1);
+            n += nd - exp - 1;
+        }
+    }
+    out[n] = 0;
+    return n;
+}
+
+// 格式化浮点数, verb 为 e, E, f, F, g, G 或 v
+static void tiny_go_fmt_float(tiny_go_buf *b, tiny_go_spec *sp, double x, int is32){
+    char tmp[512];
+    int n = 0;
+    const char *special = tiny_go_float_special(x);
+    if (special != NULL) {
+        // +Inf 只在有 + 标记时带符号, 有空格标记时符号为空格
+        if (special[0] == '+') {
+            special += !sp->plus;
+        }
+        n = snprintf(tmp, sizeof(tmp), "%s%s", special[0] == 'I' && sp->space ? " " : "", special);
+        int zero = sp->zero;
+        sp->zero = 0;
+        tiny_go_fmt_pad(b, sp, tmp, n, 0);
+        sp->zero = zero;
+        return;
+    }
+
+    int verb = sp->verb == 'v' ? 'g' : sp->verb == 'F' ? 'f' : sp->verb;
+    if ((verb == 'g' || verb == 'G') && sp->prec < 0) {
+        if (x >= 0 && sp->plus) {
+            tmp[n++] = '+';
+        } else if (x >= 0 && sp->space) {
+            tmp[n++] = ' ';
+        }
+        n += tiny_go_format_shortest(tmp + n, x, is32, verb == 'G');
+    } else {
+        char format[16];
+        snprintf(format, sizeof(format), "%%%s%s%s.*%c", sp->plus ? "+" : "", sp->space ? " " : "", sp->sharp ? "#" : "", verb);
+        n = snprintf(tmp, sizeof(tmp), format, sp->prec >= 0 ? sp->prec : 6, x);
+        if (n >= (int)sizeof(tmp)) {
+            n = sizeof(tmp) - 1;
+        }
+    }
+    int sign = tmp[0] == '-' || tmp[0] == '+' || tmp[0] == ' ';
+    tiny_go_fmt_pad(b, sp, tmp, n, sign);
+}
+
+// 格式化字符串, verb 为 s, q, x, X 或 v; 精度为最多输出的字符数
+static void tiny_go_fmt_string(tiny_go_buf *b, tiny_go_spec *sp, char *s, int len){
+    if (sp->prec >= 0) {
+        int i = 0;
+        for (int runes = 0; i < len && runes < sp->prec; runes++) {
+            int width;
+            tiny_go_builtin_decode_rune(s, len, i, &width);
+            i += width;
+        }
+        len = i;
+    }
+    tiny_go_buf q = {0};
+    switch (sp->verb) {
+    case 'q':
+        tiny_go_write_quoted(&q, s, len, '"');
+        break;
+    case 'x':
+    case 'X':
+        for (int i = 0; i < len; i++) {
+            if (sp->space && i > 0) {
+                tiny_go_buf_byte(&q, ' ');
+            }
+            if (sp->sharp && (sp->space || i == 0)) {
+                tiny_go_buf_str(&q, sp->verb == 'x' ? "0x" : "0X");
+            }
+            tiny_go_buf_printf(&q, sp->verb == 'x' ? "%02x" : "%02X", (unsigned char)s[i]);
+        }
+        break;
+    default:
+        tiny_go_buf_write(&q, s, len);
+    }
+    int zero = sp->zero;
+    sp->zero = 0;
+    tiny_go_fmt_pad(b, sp, q.ptr, q.len, 0);
+    sp->zero = zero;
+    free(q.ptr);
+}
+
+// 类型和值不匹配: %!verb(type=value)
+static void tiny_go_fmt_bad_verb(tiny_go_buf *b, tiny_go_spec *sp, tiny_go_iface *arg);
+
+// 按格式说明格式化一个参数
+static void tiny_go_fmt_arg(tiny_go_buf *b, tiny_go_spec *sp, tiny_go_iface *arg){
+    if (arg->itab == NULL) {
+        if (sp->verb == 'v' || sp->verb == 'T') {
+            tiny_go_fmt_pad(b, sp, "<nil>", 5, 0);
+            return;
+        }
+        tiny_go_fmt_bad_verb(b, sp, arg);
+        return;
+    }
+    tiny_go_type *t = arg->itab[0];
+    int kind = t->kind & ~TINY_GO_KIND_NAMED;
+    int verb = sp->verb;
+    if (verb == 'T') {
+        tiny_go_fmt_pad(b, sp, t->name, t->n, 0);
+        return;
+    }
+
+    if (tiny_go_kind_is_int(kind) && strchr("vdbcoqxXU", verb)) {
+        if (verb == 'v') {
+            sp->verb = 'd';
+        }
+        tiny_go_fmt_int(b, sp, tiny_go_load_int(kind, arg->data), tiny_go_kind_is_signed(kind));
+        sp->verb = verb;
+    } else if ((kind == TINY_GO_KIND_FLOAT32 || kind == TINY_GO_KIND_FLOAT64) && strchr("veEfFgG", verb)) {
+        tiny_go_fmt_float(b, sp, tiny_go_load_float(kind, arg->data), kind == TINY_GO_KIND_FLOAT32);
+    } else if (kind == TINY_GO_KIND_STRING && strchr("vsqxX", verb)) {
+        tiny_go_string *s = arg->data;
+        tiny_go_fmt_string(b, sp, s->ptr, s->len);
+    } else if (kind == TINY_GO_KIND_BOOL && (verb == 'v' || verb == 't')) {
+        const char *s = *(char *)arg->data ? "true" : "false";
+        tiny_go_fmt_pad(b, sp, s, strlen(s), 0);
+    } else if (kind == TINY_GO_KIND_POINTER && verb == 'v' && arg->data == NULL) {
+        tiny_go_fmt_pad(b, sp, "<nil>", 5, 0);
+    } else if (kind == TINY_GO_KIND_POINTER && (verb == 'v' || verb == 'p')) {
+        char tmp[32];
+        int n = snprintf(tmp, sizeof(tmp), "0x%llx", (unsigned long long)(size_t)arg->data);
+        tiny_go_fmt_pad(b, sp, tmp, n, 0);
+    } else if (kind == TINY_GO_KIND_OTHER && verb == 'v') {
+        tiny_go_buf q = {0};
+        tiny_go_write_arg(&q, arg);
+        tiny_go_fmt_pad(b, sp, q.ptr, q.len, 0);
+        free(q.ptr);
+    } else {
+        tiny_go_fmt_bad_verb(b, sp, arg);
+    }
+}
+
+static void tiny_go_fmt_bad_verb(tiny_go_buf *b, tiny_go_spec *sp, tiny_go_iface *arg){
+    tiny_go_buf_str(b, "%!");
+    char verb[4];
+    tiny_go_buf_write(b, verb, tiny_go_encode_rune(verb, sp->verb));
+    tiny_go_buf_byte(b, '(');
+    if (arg->itab == NULL) {
+        tiny_go_buf_str(b, "<nil>");
+    } else {
+        tiny_go_type *t = arg->itab[0];
+        tiny_go_spec v = {0};
+        v.width = v.prec = -1;
+        v.verb = 'v';
+        tiny_go_buf_write(b, t->name, t->n);
+        tiny_go_buf_byte(b, '=');
+        tiny_go_fmt_arg(b, &v, arg);
+    }
+    tiny_go_buf_byte(b, ')');
+}
+
+// 解析格式说明中的十进制数, 没有数字时返回 -1
+static int tiny_go_parse_num(char *format, int n, int *i){
+    int x = -1;
+    while (*i < n && format[*i] >= '0' && format[*i] <= '9') {
+        x = (x < 0 ? 0 : x) * 10 + format[*i] - '0';
+        (*i)++;
+    }
+    return x;
+}
+
+// printf(format, args...), 支持 Go 的 %v %d %b %o %x %X %c %q %U %e %f %g %s %t %p %T 和 %%.
+// 参数不足时输出 %!verb(MISSING), 多余的参数输出为 %!(EXTRA type=value, ...)
+int tiny_go_builtin_printf(char *format, int n, tiny_go_iface *args, int nargs){
+    tiny_go_buf b = {0};
+    int argi = 0;
+    for (int i = 0; i < n;) {
+        int start = i;
+        while (i < n && format[i] != '%') {
+            i++;
+        }
+        tiny_go_buf_write(&b, format + start, i - start);
+        if (i >= n) {
+            break;
+        }
+        i++;
+
+        tiny_go_spec sp = {0};
+        for (; i < n; i++) {
+            char c = format[i];
+            if (c == '-') {
+                sp.minus = 1;
+            } else if (c == '+') {
+                sp.plus = 1;
+            } else if (c == '#') {
+                sp.sharp = 1;
+            } else if (c == '0') {
+                sp.zero = 1;
+            } else if (c == ' ') {
+                sp.space = 1;
+            } else {
+                break;
+            }
+        }
+        sp.width = tiny_go_parse_num(format, n, &i);
+        sp.prec = -1;
+        if (i < n && format[i] == '.') {
+            i++;
+            sp.prec = tiny_go_parse_num(format, n, &i);
+            if (sp.prec < 0) {
+                sp.prec = 0;
+            }
+        }
+        if (sp.minus) {
+            sp.zero = 0;
+        }
+        if (i >= n) {
+            tiny_go_buf_str(&b, "%!(NOVERB)");
+            break;
+        }
+        int width;
+        sp.verb = tiny_go_builtin_decode_rune(format, n, i, &width);
+        i += width;
+
+        if (sp.verb == '%') {
+            tiny_go_buf_byte(&b, '%');
+            continue;
+        }
+        if (argi >= nargs) {
+            char verb[4];
+            tiny_go_buf_str(&b, "%!");
+            tiny_go_buf_write(&b, verb, tiny_go_encode_rune(verb, sp.verb));
+            tiny_go_buf_str(&b, "(MISSING)");
+            continue;
+        }
+        tiny_go_fmt_arg(&b, &sp, &args[argi++]);
+    }
+
+    if (argi < nargs) {
+        tiny_go_buf_str(&b, "%!(EXTRA ");
+        for (; argi < nargs; argi++) {
+            tiny_go_iface *arg = &args[argi];
+            if (arg->itab == NULL) {
+                tiny_go_buf_str(&b, "<nil>");
+            } else {
+                tiny_go_spec v = {0};
+                v.width = v.prec = -1;
+                v.verb = 'v';
+                tiny_go_buf_write(&b, arg->itab[0]->name, arg->itab[0]->n);
+                tiny_go_buf_byte(&b, '=');
+                tiny_go_fmt_arg(&b, &v, arg);
+            }
+            if (argi < nargs - 1) {
+                tiny_go_buf_str(&b, ", ");
+            }
+        }
+        tiny_go_buf_byte(&b, ')');
+    }
+    return tiny_go_buf_flush(&b, stdout);
+}
+
+// 调用栈上的一帧, 和 LLVM 中的 %tiny_go_frame 一致.
+// 函数在入口处把帧压入当前 goroutine 的调用栈, 调用其他函数前在 pos 中记录调用的位置.
+// 有 defer 的函数在 jmp 中保存 setjmp 的现场, panic 时跳转回这个函数执行延迟调用
+typedef struct tiny_go_frame {
+    struct tiny_go_frame *prev;
+    char *name;
+    int nname;
+    char *pos;
+    int npos;
+    void *jmp;
+} tiny_go_frame;
+
+// 编译器在函数的栈上为 jmp_buf 分配 512 字节
+_Static_assert(sizeof(jmp_buf) <= 512, "jmp_buf is larger than the space reserved by the compiler");
+
+// panic 发生时调用栈中的一项
+typedef struct {
+    char *name;
+    int nname;
+    char *pos;
+    int npos;
+} tiny_go_trace;
+
+// 正在进行的 panic, link 为更早的 panic. frame 为正在为它执行延迟调用的函数;
+// 延迟调用中发生的新 panic 离开 frame 后, 更早的 panic 被中断, aborted 为 1
+typedef struct tiny_go_panic {
+    tiny_go_type **itab;
+    void *data;
+    tiny_go_frame *frame;
+    int recovered;
+    int aborted;
+    tiny_go_trace *trace;
+    int ntrace;
+    struct tiny_go_panic *link;
+} tiny_go_panic;
+
+// 编译器生成的 string 实现 interface{} 的 itab, 运行时错误的值为 string 类型的错误信息
+extern tiny_go_type **tiny_go_string_itab;
+
+// 每个 goroutine 的调用栈, panic 和编号, main 函数所在的 goroutine 编号为 1
+static __thread tiny_go_frame *tiny_go_frames;
+static __thread tiny_go_panic *tiny_go_panics;
+static __thread int tiny_go_goid = 1;
+
+void tiny_go_builtin_frame_push(tiny_go_frame *f, char *name, int nname, void *jmp){
+    f->prev = tiny_go_frames;
+    f->name = name;
+    f->nname = nname;
+    f->pos = NULL;
+    f->npos = 0;
+    f->jmp = jmp;
+    tiny_go_frames = f;
+}
+
+// 打印 panic 的值: 基本类型打印值, 命名的基本类型打印为 T(v), 其他类型打印类型和数据指针
+static void tiny_go_print_value(tiny_go_type **itab, void *data){
+    tiny_go_type *t = itab[0];
+    int kind = t->kind & ~TINY_GO_KIND_NAMED;
+    tiny_go_iface arg = {itab, data};
+    tiny_go_buf b = {0};
+    if (kind == TINY_GO_KIND_OTHER || kind == TINY_GO_KIND_POINTER) {
+        tiny_go_buf_printf(&b, "(%.*s) 0x%llx", t->n, t->name, (unsigned long long)(size_t)data);
+    } else if (!(t->kind & TINY_GO_KIND_NAMED)) {
+        tiny_go_write_arg(&b, &arg);
+    } else if (kind == TINY_GO_KIND_STRING) {
+        tiny_go_string *s = data;
+        tiny_go_buf_write(&b, t->name, t->n);
+        tiny_go_buf_byte(&b, '(');
+        tiny_go_buf_byte(&b, '"');
+        tiny_go_buf_write(&b, s->ptr, s->len);
+        tiny_go_buf_str(&b, "\")");
+    } else {
+        tiny_go_buf_write(&b, t->name, t->n);
+        tiny_go_buf_byte(&b, '(');
+        tiny_go_write_arg(&b, &arg);
+        tiny_go_buf_byte(&b, ')');
+    }
+    tiny_go_buf_flush(&b, stderr);
+}
+
+// 从最早的 panic 开始打印, 之后的 panic 缩进一级
+static void tiny_go_print_panics(tiny_go_panic *p){
+    if (p->link != NULL) {
+        tiny_go_print_panics(p->link);
+        fprintf(stderr, "\t");
+    }
+    fprintf(stderr, "panic: ");
+    tiny_go_print_value(p->itab, p->data);
+    if (p->recovered) {
+        fprintf(stderr, " [recovered]");
+    }
+    fprintf(stderr, "\n");
+}
+
+// panic 没有被 recover, 打印所有的 panic 和最后一个 panic 发生时的调用栈, 以状态码 2 退出
+static void tiny_go_fatal_panic(void){
+    tiny_go_panic *p = tiny_go_panics;
+    fflush(stdout);
+    tiny_go_print_panics(p);
+    fprintf(stderr, "\ngoroutine %d [running]:\n", tiny_go_goid);
+    for (int i = 0; i < p->ntrace; i++) {
+        tiny_go_trace *t = &p->trace[i];
+        fprintf(stderr, "%.*s()\n", t->nname, t->name);
+        if (t->pos != NULL) {
+            fprintf(stderr, "\t%.*s\n", t->npos, t->pos);
+        }
+    }
+    exit(2);
+}
+
+// 从帧 f 开始向外查找有 defer 的函数, 跳转到这个函数执行延迟调用, 没有时结束程序
+static void tiny_go_unwind(tiny_go_panic *p, tiny_go_frame *f){
+    for (; f != NULL; f = f->prev) {
+        if (f->jmp != NULL) {
+            p->frame = f;
+            tiny_go_frames = f;
+            longjmp(*(jmp_buf *)f->jmp, 1);
+        }
+    }
+    tiny_go_fatal_panic();
+}
+
+// 开始 panic, 记录调用栈, 最内层的函数的位置为 panic 发生的位置
+static void tiny_go_gopanic(tiny_go_type **itab, void *data, char *pos, int npos){
+    tiny_go_panic *p = tiny_go_builtin_alloc(sizeof(tiny_go_panic));
+    p->itab = itab;
+    p->data = data;
+    for (tiny_go_frame *f = tiny_go_frames; f != NULL; f = f->prev) {
+        p->ntrace++;
+    }
+    p->trace = tiny_go_builtin_alloc(p->ntrace * sizeof(tiny_go_trace));
+    int i = 0;
+    for (tiny_go_frame *f = tiny_go_frames; f != NULL; f = f->prev, i++) {
+        p->trace[i].name = f->name;
+        p->trace[i].nname = f->nname;
+        p->trace[i].pos = i == 0 ? pos : f->pos;
+        p->trace[i].npos = i == 0 ? npos : f->npos;
+    }
+    p->link = tiny_go_panics;
+    tiny_go_panics = p;
+    tiny_go_unwind(p, tiny_go_frames);
+}
+
+// 运行时错误, panic 的值为格式化后的错误信息
+static void tiny_go_runtime_panic(char *pos, int npos, const char *format, ...){
+    va_list ap;
+    va_start(ap, format);
+    int n = vsnprintf(NULL, 0, format, ap);
+    va_end(ap);
+
+    tiny_go_string *s = tiny_go_builtin_alloc(sizeof(tiny_go_string));
+    s->ptr = tiny_go_builtin_alloc(n + 1);
+    s->len = n;
+    va_start(ap, format);
+    vsnprintf(s->ptr, n + 1, format, ap);
+    va_end(ap);
+    tiny_go_gopanic(tiny_go_string_itab, s, pos, npos);
+}
+
+// panic(v), itab 为 NULL 时 v 为 nil. 不会返回, 返回值只是为了和 close 等内置函数一致
+int tiny_go_builtin_panic(tiny_go_type **itab, void *data, char *pos, int npos){
+    if (itab == NULL) {
+        tiny_go_runtime_panic(pos, npos, "panic called with nil argument");
+    }
+    tiny_go_gopanic(itab, data, pos, npos);
+    return 0;
+}
+
+// recover 只在被延迟调用的函数中直接调用时有效, f 为调用 recover 的函数的帧.
+// value 保存 panic 的值, 没有可以恢复的 panic 时为 nil
+void tiny_go_builtin_recover(tiny_go_frame *f, void **value){
+    tiny_go_panic *p = tiny_go_panics;
+    value[0] = NULL;
+    value[1] = NULL;
+    if (p == NULL || p->recovered || f->prev != p->frame) {
+        return;
+    }
+    p->recovered = 1;
+    value[0] = p->itab;
+    value[1] = p->data;
+}
+
+// 函数返回前弹出帧. 正在为这个函数执行延迟调用的 panic 被 recover 时函数正常返回, 否则继续 panic
+void tiny_go_builtin_frame_pop(tiny_go_frame *f){
+    tiny_go_panic *p = tiny_go_panics;
+    if (p != NULL && p->frame == f) {
+        if (!p->recovered) {
+            for (tiny_go_panic *q = p->link; q != NULL; q = q->link) {
+                if (q->frame == f) {
+                    q->aborted = 1;
+                }
+            }
+            tiny_go_unwind(p, f->prev);
+        }
+        // 被 recover 的 panic 和被它中断的 panic 都结束了
+        do {
+            p = p->link;
+        } while (p != NULL && (p->aborted || p->frame == f));
+        tiny_go_panics = p;
+    }
+    tiny_go_frames = f->prev;
+}
+
+// map 的键的种类, 和 map.go 中的 mapKey* 一致. 浮点数按值比较, +0 和 -0 相等, NaN 和任何键都不相等
+enum {
+    TINY_GO_KEY_MEM,
+    TINY_GO_KEY_STRING,
+    TINY_GO_KEY_FLOAT32,
+    TINY_GO_KEY_FLOAT64,
+};
+
+// map 的条目, 之后依次保存键和值, 值的偏移为键的大小按 8 字节对齐.
+// 条目删除后不释放, 因此 map_assign 返回的值的地址在扩容后仍然有效.
+// 所有条目按插入的顺序组成双向链表, 用于 for range 迭代
+typedef struct tiny_go_map_entry {
+    struct tiny_go_map_entry *next;
+    unsigned long long hash;
+    struct tiny_go_map_entry *order_next;
+    struct tiny_go_map_entry *order_prev;
+    int deleted;
+} tiny_go_map_entry;
+
+// map 的哈希表, 每个桶是条目的链表, 桶的个数为 2 的幂
+typedef struct {
+    tiny_go_map_entry **buckets;
+    tiny_go_map_entry *head; // 插入顺序链表的头和尾
+    tiny_go_map_entry *tail;
+    int nbuckets;
+    int count;
+    int key_size;
+    int elem_size;
+    int key_kind;
+} tiny_go_map;
+
+static char *tiny_go_map_key(tiny_go_map_entry *e){
+    return (char *)(e + 1);
+}
+
+static char *tiny_go_map_elem(tiny_go_map *m, tiny_go_map_entry *e){
+    return tiny_go_map_key(e) + ((m->key_size + 7) & ~7);
+}
+
+// FNV-1a 哈希
+static unsigned long long tiny_go_hash_bytes(const char *p, int n){
+    unsigned long long h = 14695981039346656037ULL;
+    for (int i = 0; i < n; i++) {
+        h ^= (unsigned char)p[i];
+        h *= 1099511628211ULL;
+    }
+    return h;
+}
+
+static unsigned long long tiny_go_map_hash(tiny_go_map *m, void *key){
+    switch (m->key_kind) {
+    case TINY_GO_KEY_STRING: {
+        tiny_go_string *s = key;
+        return tiny_go_hash_bytes(s->ptr, s->len);
+    }
+    case TINY_GO_KEY_FLOAT32: {
+        float f = *(float *)key;
+        if (f == 0) {
+            f = 0;
+        }
+        return tiny_go_hash_bytes((char *)&f, sizeof(f));
+    }
+    case TINY_GO_KEY_FLOAT64: {
+        double f = *(double *)key;
+        if (f == 0) {
+            f = 0;
+        }
+        return tiny_go_hash_bytes((char *)&f, sizeof(f));
+    }
+    }
+    return tiny_go_hash_bytes(key, m->key_size);
+}
+
+static int tiny_go_map_key_equal(tiny_go_map *m, void *x, void *y){
+    switch (m->key_kind) {
+    case TINY_GO_KEY_STRING: {
+        tiny_go_string *sx = x, *sy = y;
+        return sx->len == sy->len && memcmp(sx->ptr, sy->ptr, sx->len) == 0;
+    }
+    case TINY_GO_KEY_FLOAT32:
+        return *(float *)x == *(float *)y;
+    case TINY_GO_KEY_FLOAT64:
+        return *(double *)x == *(double *)y;
+    }
+    return memcmp(x, y, m->key_size) == 0;
+}
+
+// 创建 map, hint 为预计的元素个数, 负数按 0 处理
+tiny_go_map *tiny_go_builtin_map_make(int key_size, int elem_size, int key_kind, int hint){
+    tiny_go_map *m = tiny_go_builtin_alloc(sizeof(tiny_go_map));
+    m->nbuckets = 8;
+    while (m->nbuckets < hint) {
+        m->nbuckets *= 2;
+    }
+    m->buckets = tiny_go_builtin_alloc(m->nbuckets * sizeof(tiny_go_map_entry *));
+    m->key_size = key_size;
+    m->elem_size = elem_size;
+    m->key_kind = key_kind;
+    return m;
+}
+
+static tiny_go_map_entry *tiny_go_map_find(tiny_go_map *m, void *key, unsigned long long hash){
+    tiny_go_map_entry *e = m->buckets[hash & (m->nbuckets - 1)];
+    for (; e != NULL; e = e->next) {
+        if (e->hash == hash && tiny_go_map_key_equal(m, tiny_go_map_key(e), key)) {
+            return e;
+        }
+    }
+    return NULL;
+}
+
+// 元素个数超过桶的个数时把桶扩大一倍, 条目移动到新的桶中
+static void tiny_go_map_grow(tiny_go_map *m){
+    int nbuckets = m->nbuckets * 2;
+    tiny_go_map_entry **buckets = tiny_go_builtin_alloc(nbuckets * sizeof(tiny_go_map_entry *));
+    for (int i = 0; i < m->nbuckets; i++) {
+        tiny_go_map_entry *e = m->buckets[i];
+        while (e != NULL) {
+            tiny_go_map_entry *next = e->next;
+            int j = e->hash & (nbuckets - 1);
+            e->next = buckets[j];
+            buckets[j] = e;
+            e = next;
+        }
+    }
+    free(m->buckets);
+    m->buckets = buckets;
+    m->nbuckets = nbuckets;
+}
+
+// 查找键对应的值的地址, 没有找到或 map 为 nil 时返回 NULL
+void *tiny_go_builtin_map_access(tiny_go_map *m, void *key){
+    if (m == NULL || m->count == 0) {
+        return NULL;
+    }
+    tiny_go_map_entry *e = tiny_go_map_find(m, key, tiny_go_map_hash(m, key));
+    return e == NULL ? NULL : tiny_go_map_elem(m, e);
+}
+
+// 返回键对应的值的地址, 键不存在时插入值为零值的条目, pos 为赋值在源码中的位置
+void *tiny_go_builtin_map_assign(tiny_go_map *m, void *key, char *pos, int npos){
+    if (m == NULL) {
+        tiny_go_runtime_panic(pos, npos, "assignment to entry in nil map");
+    }
+    unsigned long long hash = tiny_go_map_hash(m, key);
+    tiny_go_map_entry *e = tiny_go_map_find(m, key, hash);
+    if (e != NULL) {
+        return tiny_go_map_elem(m, e);
+    }
+
+    if (m->count >= m->nbuckets) {
+        tiny_go_map_grow(m);
+    }
+    e = tiny_go_builtin_alloc(sizeof(tiny_go_map_entry) + ((m->key_size + 7) & ~7) + m->elem_size);
+    e->hash = hash;
+    memcpy(tiny_go_map_key(e), key, m->key_size);
+    int i = hash & (m->nbuckets - 1);
+    e->next = m->buckets[i];
+    m->buckets[i] = e;
+    e->order_prev = m->tail;
+    if (m->tail == NULL) {
+        m->head = e;
+    } else {
+        m->tail->order_next = e;
+    }
+    m->tail = e;
+    m->count++;
+    return tiny_go_map_elem(m, e);
+}
+
+// 删除键, 键不存在或 map 为 nil 时什么也不做. 返回 0, 以便和没有返回值的函数一样被 defer 调用
+int tiny_go_builtin_map_delete(tiny_go_map *m, void *key){
+    if (m == NULL || m->count == 0) {
+        return 0;
+    }
+    unsigned long long hash = tiny_go_map_hash(m, key);
+    tiny_go_map_entry **link = &m->buckets[hash & (m->nbuckets - 1)];
+    for (; *link != NULL; link = &(*link)->next) {
+        tiny_go_map_entry *e = *link;
+        if (e->hash == hash && tiny_go_map_key_equal(m, tiny_go_map_key(e), key)) {
+            *link = e->next;
+            m->count--;
+            // 从插入顺序链表中移除, 但保留 e->order_next, 停在这个条目上的迭代可以继续
+            e->deleted = 1;
+            if (e->order_prev == NULL) {
+                m->head = e->order_next;
+            } else {
+                e->order_prev->order_next = e->order_next;
+            }
+            if (e->order_next == NULL) {
+                m->tail = e->order_prev;
+            } else {
+                e->order_next->order_prev = e->order_prev;
+            }
+            break;
+        }
+    }
+    return 0;
+}
+
+int tiny_go_builtin_map_len(tiny_go_map *m){
+    return m == NULL ? 0 : m->count;
+}
+
+// 按插入的顺序迭代 map, e 为上一次返回的条目, 第一次为 NULL. 返回下一个条目, 键和值的地址保存到 *key 和 *elem,
+// 迭代结束时返回 NULL. 迭代中删除的还没有迭代到的条目不会被返回, 迭代中插入的条目不一定被返回
+void *tiny_go_builtin_map_next(tiny_go_map *m, tiny_go_map_entry *e, void **key, void **elem){
+    if (m == NULL) {
+        return NULL;
+    }
+    e = e == NULL ? m->head : e->order_next;
+    while (e != NULL && e->deleted) {
+        e = e->order_next;
+    }
+    if (e != NULL) {
+        *key = tiny_go_map_key(e);
+        *elem = tiny_go_map_elem(m, e);
+    }
+    return e;
+}
+
+// 解码 s[i:n] 开头的 UTF-8 字符, 字节数保存到 *width. 编码无效时返回 U+FFFD, 字节数为 1
+int tiny_go_builtin_decode_rune(char *s, int n, int i, int *width){
+    const unsigned char *p = (const unsigned char *)s + i;
+    int left = n - i;
+    unsigned c = p[0];
+    *width = 1;
+    if (c < 0x80) {
+        return c;
+    }
+
+    int size;
+    unsigned r, min;
+    if (c >= 0xC2 && c <= 0xDF) {
+        size = 2, r = c & 0x1F, min = 0x80;
+    } else if (c >= 0xE0 && c <= 0xEF) {
+        size = 3, r = c & 0x0F, min = 0x800;
+    } else if (c >= 0xF0 && c <= 0xF4) {
+        size = 4, r = c & 0x07, min = 0x10000;
+    } else {
+        return 0xFFFD;
+    }
+    if (left < size) {
+        return 0xFFFD;
+    }
+    for (int k = 1; k < size; k++) {
+        if ((p[k] & 0xC0) != 0x80) {
+            return 0xFFFD;
+        }
+        r = r << 6 | (p[k] & 0x3F);
+    }
+    // 过长的编码, 代理区和超出范围的码点都是无效的
+    if (r < min || (r >= 0xD800 && r <= 0xDFFF) || r > 0x10FFFF) {
+        return 0xFFFD;
+    }
+    *width = size;
+    return r;
+}
+
+// goroutine 是分离的 pthread 线程, 所有 channel 的操作由一把全局锁保护.
+// 阻塞的 goroutine 在自己的条件变量上等待, 由完成操作的另一方复制值并唤醒.
+// tiny_go_running 为没有阻塞的 goroutine 个数, 变为 0 时任何 goroutine 都无法继续, 报告死锁
+static pthread_mutex_t tiny_go_sched_mu = PTHREAD_MUTEX_INITIALIZER;
+static int tiny_go_running = 1;
+
+// 阻塞的 goroutine, select 的多个分支共用一个
+typedef struct {
+    pthread_cond_t cond;
+    int done;  // 操作已经完成
+    int index; // 完成的 select 分支
+    int ok;    // 0 表示因为 channel 关闭而完成
+} tiny_go_waiter;
+
+// channel 等待队列中的一项, elem 为发送的值或保存接收的值的地址
+typedef struct tiny_go_sudog {
+    tiny_go_waiter *w;
+    int index;
+    char *elem;
+    struct tiny_go_sudog *next;
+} tiny_go_sudog;
+
+typedef struct {
+    tiny_go_sudog *head;
+    tiny_go_sudog *tail;
+} tiny_go_waitq;
+
+// channel, 缓冲区是长度为 cap 的环形队列
+typedef struct {
+    int elem_size;
+    int cap;
+    char *buf;
+    int head;
+    int count;
+    int closed;
+    tiny_go_waitq sendq;
+    tiny_go_waitq recvq;
+} tiny_go_chan;
+
+// goroutine 的入口函数, 参数帧和编号
+typedef struct {
+    int (*fn)(void *);
+    void *frame;
+    int goid;
+} tiny_go_start;
+
+static int tiny_go_next_goid = 1;
+
+
+static void tiny_go_deadlock(void){
+    fflush(stdout);
+    fprintf(stderr, "fatal error: all goroutines are asleep - deadlock!\n");
+    exit(2);
+}
+
+static void *tiny_go_goroutine(void *arg){
+    tiny_go_start start = *(tiny_go_start *)arg;
+    free(arg);
+    tiny_go_goid = start.goid;
+    start.fn(start.frame);
+
+    pthread_mutex_lock(&tiny_go_sched_mu);
+    if (--tiny_go_running == 0) {
+        tiny_go_deadlock();
+    }
+    pthread_mutex_unlock(&tiny_go_sched_mu);
+    return NULL;
+}
+
+// 启动 goroutine, 在新的线程中调用 fn(frame)
+int tiny_go_builtin_go(int (*fn)(void *), void *frame){
+    tiny_go_start *start = tiny_go_builtin_alloc(sizeof(tiny_go_start));
+    start->fn = fn;
+    start->frame = frame;
+
+    pthread_mutex_lock(&tiny_go_sched_mu);
+    tiny_go_running++;
+    start->goid = ++tiny_go_next_goid;
+    pthread_mutex_unlock(&tiny_go_sched_mu);
+
+    pthread_attr_t attr;
+    pthread_t thread;
+    pthread_attr_init(&attr);
+    pthread_attr_setdetachstate(&attr, PTHREAD_CREATE_DETACHED);
+    if (pthread_create(&thread, &attr, tiny_go_goroutine, start) != 0) {
+        fflush(stdout);
+        fprintf(stderr, "fatal error: cannot create goroutine\n");
+        exit(2);
+    }
+    pthread_attr_destroy(&attr);
+    return 0;
+}
+
+static void tiny_go_enqueue(tiny_go_waitq *q, tiny_go_sudog *s){
+    s->next = NULL;
+    if (q->tail == NULL) {
+        q->head = s;
+    } else {
+        q->tail->next = s;
+    }
+    q->tail = s;
+}
+
+// 取出第一个还在等待的项, select 中已经由其他分支完成的项直接丢弃
+static tiny_go_sudog *tiny_go_dequeue(tiny_go_waitq *q){
+    while (q->head != NULL) {
+        tiny_go_sudog *s = q->head;
+        q->head = s->next;
+        if (q->head == NULL) {
+            q->tail = NULL;
+        }
+        if (!s->w->done) {
+            return s;
+        }
+    }
+    return NULL;
+}
+
+static void tiny_go_waitq_remove(tiny_go_waitq *q, tiny_go_sudog *s){
+    tiny_go_sudog *prev = NULL;
+    for (tiny_go_sudog **link = &q->head; *link != NULL; prev = *link, link = &(*link)->next) {
+        if (*link == s) {
+            *link = s->next;
+            if (q->tail == s) {
+                q->tail = prev;
+            }
+            return;
+        }
+    }
+}
+
+// 唤醒等待的 goroutine, 调用时持有锁
+static void tiny_go_wake(tiny_go_sudog *s, int ok){
+    s->w->done = 1;
+    s->w->index = s->index;
+    s->w->ok = ok;
+    tiny_go_running++;
+    pthread_cond_signal(&s->w->cond);
+}
+
+// 阻塞当前 goroutine 直到被唤醒, 调用时持有锁
+static void tiny_go_park(tiny_go_waiter *w){
+    if (--tiny_go_running == 0) {
+        tiny_go_deadlock();
+    }
+    while (!w->done) {
+        pthread_cond_wait(&w->cond, &tiny_go_sched_mu);
+    }
+}
+
+static char *tiny_go_chan_slot(tiny_go_chan *c, int i){
+    return c->buf + (c->head + i) % c->cap * c->elem_size;
+}
+
+// 不阻塞地发送, 成功时返回 1. 有等待的接收方时直接复制给它, 否则放入缓冲区
+static int tiny_go_chan_try_send(tiny_go_chan *c, void *elem, char *pos, int npos){
+    if (c->closed) {
+        pthread_mutex_unlock(&tiny_go_sched_mu);
+        tiny_go_runtime_panic(pos, npos, "send on closed channel");
+    }
+    tiny_go_sudog *s = tiny_go_dequeue(&c->recvq);
+    if (s != NULL) {
+        memcpy(s->elem, elem, c->elem_size);
+        tiny_go_wake(s, 1);
+        return 1;
+    }
+    if (c->count < c->cap) {
+        memcpy(tiny_go_chan_slot(c, c->count), elem, c->elem_size);
+        c->count++;
+        return 1;
+    }
+    return 0;
+}
+
+// 不阻塞地接收, 成功时返回 1. channel 已关闭并且没有值时得到零值, *ok 为 0
+static int tiny_go_chan_try_recv(tiny_go_chan *c, void *elem, int *ok){
+    if (c->count > 0) {
+        memcpy(elem, tiny_go_chan_slot(c, 0), c->elem_size);
+        c->head = (c->head + 1) % c->cap;
+        c->count--;
+        // 缓冲区空出了位置, 等待的发送方把值放入缓冲区
+        tiny_go_sudog *s = tiny_go_dequeue(&c->sendq);
+        if (s != NULL) {
+            memcpy(tiny_go_chan_slot(c, c->count), s->elem, c->elem_size);
+            c->count++;
+            tiny_go_wake(s, 1);
+        }
+        *ok = 1;
+        return 1;
+    }
+    tiny_go_sudog *s = tiny_go_dequeue(&c->sendq);
+    if (s != NULL) {
+        memcpy(elem, s->elem, c->elem_size);
+        tiny_go_wake(s, 1);
+        *ok = 1;
+        return 1;
+    }
+    if (c->closed) {
+        memset(elem, 0, c->elem_size);
+        *ok = 0;
+        return 1;
+    }
+    return 0;
+}
+
+tiny_go_chan *tiny_go_builtin_chan_make(int elem_size, int cap, char *pos, int npos){
+    if (cap < 0) {
+        tiny_go_runtime_panic(pos, npos, "runtime error: makechan: size out of range");
+    }
+    tiny_go_chan *c = tiny_go_builtin_alloc(sizeof(tiny_go_chan));
+    c->elem_size = elem_size;
+    c->cap = cap;
+    c->buf = tiny_go_builtin_alloc(cap * elem_size);
+    return c;
+}
+
+// 发送 elem 指向的值, 没有接收方并且缓冲区已满时阻塞, nil channel 永远阻塞
+void tiny_go_builtin_chan_send(tiny_go_chan *c, void *elem, char *pos, int npos){
+    pthread_mutex_lock(&tiny_go_sched_mu);
+    if (c != NULL && tiny_go_chan_try_send(c, elem, pos, npos)) {
+        pthread_mutex_unlock(&tiny_go_sched_mu);
+        return;
+    }
+    tiny_go_waiter w = {.done = 0};
+    tiny_go_sudog s = {.w = &w, .elem = elem};
+    pthread_cond_init(&w.cond, NULL);
+    if (c != NULL) {
+        tiny_go_enqueue(&c->sendq, &s);
+    }
+    tiny_go_park(&w);
+    pthread_mutex_unlock(&tiny_go_sched_mu);
+    pthread_cond_destroy(&w.cond);
+    if (!w.ok) {
+        tiny_go_runtime_panic(pos, npos, "send on closed channel");
+    }
+}
+
+// 接收值保存到 elem, 返回值是否来自发送方. 没有值时阻塞, nil channel 永远阻塞
+int tiny_go_builtin_chan_recv(tiny_go_chan *c, void *elem){
+    int ok;
+    pthread_mutex_lock(&tiny_go_sched_mu);
+    if (c != NULL && tiny_go_chan_try_recv(c, elem, &ok)) {
+        pthread_mutex_unlock(&tiny_go_sched_mu);
+        return ok;
+    }
+    tiny_go_waiter w = {.done = 0};
+    tiny_go_sudog s = {.w = &w, .elem = elem};
+    pthread_cond_init(&w.cond, NULL);
+    if (c != NULL) {
+        tiny_go_enqueue(&c->recvq, &s);
+    }
+    tiny_go_park(&w);
+    pthread_mutex_unlock(&tiny_go_sched_mu);
+    pthread_cond_destroy(&w.cond);
+    return w.ok;
+}
+
+// 关闭 channel, 等待的接收方得到零值, 等待的发送方 panic
+int tiny_go_builtin_chan_close(tiny_go_chan *c, char *pos, int npos){
+    if (c == NULL) {
+        tiny_go_runtime_panic(pos, npos, "close of nil channel");
+    }
+    pthread_mutex_lock(&tiny_go_sched_mu);
+    if (c->closed) {
+        pthread_mutex_unlock(&tiny_go_sched_mu);
+        tiny_go_runtime_panic(pos, npos, "close of closed channel");
+    }
+    c->closed = 1;
+    tiny_go_sudog *s;
+    while ((s = tiny_go_dequeue(&c->recvq)) != NULL) {
+        memset(s->elem, 0, c->elem_size);
+        tiny_go_wake(s, 0);
+    }
+    while ((s = tiny_go_dequeue(&c->sendq)) != NULL) {
+        tiny_go_wake(s, 0);
+    }
+    pthread_mutex_unlock(&tiny_go_sched_mu);
+    return 0;
+}
+
+int tiny_go_builtin_chan_len(tiny_go_chan *c){
+    if (c == NULL) {
+        return 0;
+    }
+    pthread_mutex_lock(&tiny_go_sched_mu);
+    int n = c->count;
+    pthread_mutex_unlock(&tiny_go_sched_mu);
+    return n;
+}
+
+int tiny_go_builtin_chan_cap(tiny_go_chan *c){
+    return c == NULL ? 0 : c->cap;
+}
+
+// select 的分支的方向, 和 select.go 中的 select* 一致
+enum {
+    TINY_GO_SELECT_RECV,
+    TINY_GO_SELECT_SEND,
+};
+
+// select 的分支, 和 LLVM 中的 { i8*, i8*, i32 } 一致
+typedef struct {
+    tiny_go_chan *c;
+    char *elem;
+    int dir;
+} tiny_go_select_case;
+
+// 从随机的分支开始找到第一个可以执行的分支, 返回它的下标, 接收分支的 *ok 和 chan_recv 的返回值相同.
+// 都不能执行时有 default 分支返回 -1, 否则在所有 channel 上等待
+int tiny_go_builtin_select(tiny_go_select_case *cases, int n, int has_default, int *ok, char *pos, int npos){
+    pthread_mutex_lock(&tiny_go_sched_mu);
+    int start = n > 0 ? rand() % n : 0;
+    for (int i = 0; i < n; i++) {
+        int k = (start + i) % n;
+        tiny_go_select_case *sc = &cases[k];
+        if (sc->c == NULL) {
+            continue;
+        }
+        int done = sc->dir == TINY_GO_SELECT_SEND ? tiny_go_chan_try_send(sc->c, sc->elem, pos, npos)
+                                                  : tiny_go_chan_try_recv(sc->c, sc->elem, ok);
+        if (done) {
+            pthread_mutex_unlock(&tiny_go_sched_mu);
+            return k;
+        }
+    }
+    if (has_default) {
+        pthread_mutex_unlock(&tiny_go_sched_mu);
+        return -1;
+    }
+
+    tiny_go_waiter w = {.done = 0};
+    tiny_go_sudog *sudogs = tiny_go_builtin_alloc(n * sizeof(tiny_go_sudog));
+    pthread_cond_init(&w.cond, NULL);
+    for (int k = 0; k < n; k++) {
+        tiny_go_select_case *sc = &cases[k];
+        if (sc->c == NULL) {
+            continue;
+        }
+        sudogs[k].w = &w;
+        sudogs[k].index = k;
+        sudogs[k].elem = sc->elem;
+        tiny_go_enqueue(sc->dir == TINY_GO_SELECT_SEND ? &sc->c->sendq : &sc->c->recvq, &sudogs[k]);
+    }
+    tiny_go_park(&w);
+    // 其他分支的项还在等待队列中, 返回前移除
+    for (int k = 0; k < n; k++) {
+        tiny_go_select_case *sc = &cases[k];
+        if (sc->c != NULL) {
+            tiny_go_waitq_remove(sc->dir == TINY_GO_SELECT_SEND ? &sc->c->sendq : &sc->c->recvq, &sudogs[k]);
+        }
+    }
+    pthread_mutex_unlock(&tiny_go_sched_mu);
+    pthread_cond_destroy(&w.cond);
+    free(sudogs);
+
+    if (cases[w.index].dir == TINY_GO_SELECT_SEND && !w.ok) {
+        tiny_go_runtime_panic(pos, npos, "send on closed channel");
+    }
+    *ok = w.ok;
+    return w.index;
+}
@@ -2,13 +2,14 @@ package builtin
 
 import _ "embed"
 
-//go:embed _builtin.ll
-var llBuiltin string
+//go:embed _builtin.c
+var cBuiltin string
 
 // llBuiltin_wasm go:embed _builtin_wasm.ll
 var llBuiltin_wasm string
 
-func GetBuiltinLL(goos, goarch string) string {
+// GetBuiltinC 获取运行时的 C 源码, wasm 平台的运行时由 run_wasm.js 提供
+func GetBuiltinC(goos, goarch string) string {
 	switch goos {
 	case "wasm":
 		return llBuiltin_wasm
@@ -16,12 +17,52 @@ func GetBuiltinLL(goos, goarch string) string {
 	case "linux":
 	case "windows":
 	}
-	return llBuiltin
+	return cBuiltin
 }
 
 const Header = `
+%string = type { i8*, i32 }
+%tiny_go_type = type { i8*, i32, i32 (i8*, i8*)*, i32 }
+%tiny_go_frame = type { i8*, i8*, i32, i8*, i32, i8* }
+
 declare i32 @tiny_go_builtin_exit(i32)
-declare i32 @tiny_go_builtin_println(i32)
+declare i32 @tiny_go_builtin_print(i8*, i32)
+declare i32 @tiny_go_builtin_println(i8*, i32)
+declare i32 @tiny_go_builtin_printf(i8*, i32, i8*, i32)
+declare i8* @tiny_go_builtin_string_concat(i8*, i32, i8*, i32)
+declare i32 @tiny_go_builtin_string_compare(i8*, i32, i8*, i32)
+declare void @tiny_go_builtin_panic_index(i8*, i32, i32, i32)
+declare void @tiny_go_builtin_panic_slice(i8*, i32, i32, i32, i32, i32)
+declare void @tiny_go_builtin_panic_nil(i8*, i32)
+declare void @tiny_go_builtin_panic_divide(i8*, i32)
+declare void @tiny_go_builtin_panic_shift(i8*, i32)
+declare i32 @tiny_go_builtin_panic(i8*, i8*, i8*, i32)
+declare void @tiny_go_builtin_recover(%tiny_go_frame*, i8**)
+declare void @tiny_go_builtin_frame_push(%tiny_go_frame*, i8*, i32, i8*)
+declare void @tiny_go_builtin_frame_pop(%tiny_go_frame*)
+declare i32 @_setjmp(i8*) returns_twice
+declare i8* @tiny_go_builtin_alloc(i32)
+declare void @tiny_go_builtin_make_slice(i8*, i32, i32, i32, i8*, i32)
+declare void @tiny_go_builtin_slice_grow(i8*, i32, i32)
+declare void @llvm.memmove.p0i8.p0i8.i32(i8*, i8*, i32, i1)
+declare i8* @tiny_go_builtin_iface_type(i8*)
+declare i32 @tiny_go_builtin_iface_equal(i8*, i8*, i8*, i8*, i8*, i32)
+declare void @tiny_go_builtin_panic_assert(i8*, i32, i8*, i32, i8*, i8*, i32, i32)
+declare i8* @tiny_go_builtin_map_make(i32, i32, i32, i32)
+declare i8* @tiny_go_builtin_map_access(i8*, i8*)
+declare i8* @tiny_go_builtin_map_assign(i8*, i8*, i8*, i32)
+declare i32 @tiny_go_builtin_map_delete(i8*, i8*)
+declare i32 @tiny_go_builtin_map_len(i8*)
+declare i8* @tiny_go_builtin_map_next(i8*, i8*, i8**, i8**)
+declare i32 @tiny_go_builtin_decode_rune(i8*, i32, i32, i32*)
+declare i32 @tiny_go_builtin_go(i32 (i8*)*, i8*)
+declare i8* @tiny_go_builtin_chan_make(i32, i32, i8*, i32)
+declare void @tiny_go_builtin_chan_send(i8*, i8*, i8*, i32)
+declare i32 @tiny_go_builtin_chan_recv(i8*, i8*)
+declare i32 @tiny_go_builtin_chan_close(i8*, i8*, i32)
+declare i32 @tiny_go_builtin_chan_len(i8*)
+declare i32 @tiny_go_builtin_chan_cap(i8*)
+declare i32 @tiny_go_builtin_select(i8*, i32, i32, i32*, i8*, i32)
 
 `
 
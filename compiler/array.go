@@ -0,0 +1,120 @@
+package compiler
+
+import (
+	"fmt"
+	"io"
+	"tiny-go/ast"
+	"tiny-go/token"
+	"tiny-go/types"
+)
+
+// 数组在 LLVM 中表示为 [N x T], 变量保存在 alloca 分配的内存中.
+// 下标访问通过 getelementptr 计算元素地址, 访问前检查下标是否越界.
+
+func (p *Compiler) compileExprIndex(w io.Writer, expr *ast.IndexExpr) string {
+	typ := p.exprType(expr.X)
+	if types.IsString(typ) {
+		return p.compileStringIndex(w, expr)
+	}
+	if _, ok := types.Underlying(typ).(*types.Map); ok {
+		value, _ := p.compileMapIndex(w, expr, false)
+		return value
+	}
+
+	elemType := llType(p.exprType(expr))
+	var ptr string
+	if _, ok := types.Underlying(typ).(*types.Slice); ok {
+		ptr = p.compileSliceIndexAddr(w, expr)
+	} else {
+		ptr = p.compileIndexAddr(w, expr)
+	}
+	localName := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = load %s, %s* %s, align %d\n", localName, elemType, elemType, ptr, types.Alignof(p.exprType(expr)))
+	return localName
+}
+
+// addressable 判断表达式是否可以取地址
+func (p *Compiler) addressable(expr ast.Expr) bool {
+	return p.info.Types[expr].Addressable()
+}
+
+// compileAddr 获取可赋值表达式的地址
+func (p *Compiler) compileAddr(w io.Writer, expr ast.Expr) string {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		return p.objName(p.objectOf(expr))
+	case *ast.IndexExpr:
+		switch types.Underlying(p.exprType(expr.X)).(type) {
+		case *types.Array:
+			return p.compileIndexAddr(w, expr)
+		case *types.Slice:
+			return p.compileSliceIndexAddr(w, expr)
+		case *types.Map:
+			return p.compileMapIndexAddr(w, expr)
+		}
+	case *ast.SelectorExpr:
+		return p.compileSelectorAddr(w, expr)
+	case *ast.ParenExpr:
+		return p.compileAddr(w, expr.X)
+	case *ast.StarExpr:
+		return p.compileDeref(w, expr)
+	}
+	panic(fmt.Sprintf("unknown: %[1]T, %[1]v", expr))
+}
+
+// isMapIndex 判断表达式是否为 map 的下标访问 m[k]
+func (p *Compiler) isMapIndex(expr ast.Expr) bool {
+	switch expr := expr.(type) {
+	case *ast.IndexExpr:
+		_, ok := types.Underlying(p.exprType(expr.X)).(*types.Map)
+		return ok
+	case *ast.ParenExpr:
+		return p.isMapIndex(expr.X)
+	}
+	return false
+}
+
+// compileIndexAddr 计算数组元素 x[i] 的地址
+func (p *Compiler) compileIndexAddr(w io.Writer, expr *ast.IndexExpr) string {
+	array := types.Underlying(p.exprType(expr.X)).(*types.Array)
+	arrayType := llType(array)
+
+	// 不可取地址的数组 (如函数返回值) 先保存到临时变量
+	var base string
+	if p.addressable(expr.X) {
+		base = p.compileAddr(w, expr.X)
+	} else {
+		base = p.spill(w, p.compileExpr(w, expr.X), array)
+	}
+
+	index := p.compileIndex(w, expr.Index)
+	p.genBoundsCheck(w, expr.Index.Pos(), index, fmt.Sprint(array.Len))
+
+	ptr := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = getelementptr inbounds %s, %s* %s, i32 0, i32 %s\n", ptr, arrayType, arrayType, base, index)
+	return ptr
+}
+
+// compileIndex 编译下标表达式, 结果转换为 int
+func (p *Compiler) compileIndex(w io.Writer, index ast.Expr) string {
+	return p.convert(w, p.compileExpr(w, index), p.exprType(index), types.Typ[types.Int])
+}
+
+// genBoundsCheck 检查 0 <= index < length, 越界时调用 builtin 的 panic 函数
+func (p *Compiler) genBoundsCheck(w io.Writer, pos token.Pos, index, length string) {
+	outOfRange := p.genId()
+	panicLabel := p.genLabelId("index.panic")
+	okLabel := p.genLabelId("index.ok")
+
+	// 无符号比较同时处理了负数下标
+	_, _ = fmt.Fprintf(w, "\t%s = icmp uge i32 %s, %s\n", outOfRange, index, length)
+	_, _ = fmt.Fprintf(w, "\tbr i1 %s, label %%%s, label %%%s\n", outOfRange, panicLabel, okLabel)
+
+	_, _ = fmt.Fprintf(w, "\n%s:\n", panicLabel)
+	posStr := p.posString(pos)
+	_, _ = fmt.Fprintf(w, "\tcall void @tiny_go_builtin_panic_index(i8* %s, i32 %d, i32 %s, i32 %s)\n",
+		p.stringConstPtr(posStr), len(posStr), index, length)
+	_, _ = fmt.Fprintf(w, "\tunreachable\n")
+
+	_, _ = fmt.Fprintf(w, "\n%s:\n", okLabel)
+}
@@ -0,0 +1,189 @@
+package compiler
+
+import (
+	"fmt"
+	"go/constant"
+	"io"
+	"strconv"
+	"tiny-go/ast"
+	"tiny-go/token"
+	"tiny-go/types"
+)
+
+// 二元运算: 比较和算术运算的右操作数先转换为左操作数的类型;
+// 移位运算的右操作数可以是任意整数类型, 移位数不小于位数时结果和 Go 一致.
+
+func (p *Compiler) compileExprBinary(w io.Writer, expr *ast.BinaryExpr) string {
+	if expr.Op == token.AND || expr.Op == token.OR {
+		return p.compileLogical(w, expr)
+	}
+	typ, yTyp := p.exprType(expr.X), p.exprType(expr.Y)
+	if typ == types.Typ[types.UntypedNil] || yTyp == types.Typ[types.UntypedNil] {
+		return p.compileNilCompare(w, expr, typ, yTyp)
+	}
+	if types.IsInterface(typ) || types.IsInterface(yTyp) {
+		return p.compileIfaceEqual(w, expr, typ, yTyp)
+	}
+	switch types.Underlying(typ).(type) {
+	case *types.Array, *types.Struct:
+		return p.compileAggregateEqual(w, expr, typ)
+	}
+
+	// 无类型常量和有类型的操作数运算时转换为对方的类型
+	xTyp := typ
+	if expr.Op != token.SHL && expr.Op != token.SHR && types.IsUntyped(typ) && !types.IsUntyped(yTyp) {
+		typ = yTyp
+	}
+
+	x := p.convert(w, p.compileExpr(w, expr.X), xTyp, typ)
+	y := p.compileExpr(w, expr.Y)
+	return p.compileBinaryOp(w, expr, typ, yTyp, x, y)
+}
+
+// compileBinaryOp 对已经求值的操作数 x 和 y 进行运算, 类型已经由类型检查保证
+func (p *Compiler) compileBinaryOp(w io.Writer, expr *ast.BinaryExpr, typ, yTyp types.Type, x, y string) string {
+	if expr.Op == token.SHL || expr.Op == token.SHR {
+		return p.compileShift(w, expr.OpPos, expr.Op, typ, yTyp, x, y)
+	}
+	y = p.convert(w, y, yTyp, typ)
+
+	if types.IsString(typ) {
+		return p.compileStringOp(w, expr, x, y)
+	}
+
+	localName := p.genId()
+	if expr.Op == token.AND_NOT {
+		// x &^ y 即 x & ^y
+		notY := p.genId()
+		_, _ = fmt.Fprintf(w, "\t%s = xor %s %s, -1\n", notY, llType(typ), y)
+		_, _ = fmt.Fprintf(w, "\t%s = and %s %s, %s\n", localName, llType(typ), x, notY)
+		return localName
+	}
+	if (expr.Op == token.DIV || expr.Op == token.MOD) && types.IsInteger(typ) {
+		return p.compileIntDivide(w, expr, typ, x, y)
+	}
+	_, _ = fmt.Fprintf(w, "\t%s = %s %s %v, %v\n", localName, opType(expr.Op, typ), llType(typ), x, y)
+	return localName
+}
+
+// compileIntDivide 编译整数的 / 和 %, 除数为 0 时 panic. 有符号整数的最小值除以 -1 在 LLVM 中未定义,
+// 结果和 Go 一致: 商为被除数取反(溢出后仍为最小值), 余数为 0
+func (p *Compiler) compileIntDivide(w io.Writer, expr *ast.BinaryExpr, typ types.Type, x, y string) string {
+	t := llType(typ)
+	localName := p.genId()
+	if n, err := strconv.ParseInt(y, 10, 64); err == nil && n != 0 && n != -1 {
+		_, _ = fmt.Fprintf(w, "\t%s = %s %s %s, %s\n", localName, opType(expr.Op, typ), t, x, y)
+		return localName
+	}
+	p.genDivideCheck(w, expr.OpPos, y, typ)
+	if types.IsUnsigned(typ) {
+		_, _ = fmt.Fprintf(w, "\t%s = %s %s %s, %s\n", localName, opType(expr.Op, typ), t, x, y)
+		return localName
+	}
+
+	isNegOne := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = icmp eq %s %s, -1\n", isNegOne, t, y)
+	divisor := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = select i1 %s, %s 1, %s %s\n", divisor, isNegOne, t, t, y)
+	value := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = %s %s %s, %s\n", value, opType(expr.Op, typ), t, x, divisor)
+	special := "0"
+	if expr.Op == token.DIV {
+		special = p.genId()
+		_, _ = fmt.Fprintf(w, "\t%s = sub %s 0, %s\n", special, t, x)
+	}
+	_, _ = fmt.Fprintf(w, "\t%s = select i1 %s, %s %s, %s %s\n", localName, isNegOne, t, special, t, value)
+	return localName
+}
+
+// genDivideCheck 检查整数除法的除数, 为 0 时 panic
+func (p *Compiler) genDivideCheck(w io.Writer, pos token.Pos, y string, typ types.Type) {
+	isZero := p.genId()
+	panicLabel := p.genLabelId("div.panic")
+	okLabel := p.genLabelId("div.ok")
+	_, _ = fmt.Fprintf(w, "\t%s = icmp eq %s %s, 0\n", isZero, llType(typ), y)
+	_, _ = fmt.Fprintf(w, "\tbr i1 %s, label %%%s, label %%%s\n", isZero, panicLabel, okLabel)
+
+	_, _ = fmt.Fprintf(w, "\n%s:\n", panicLabel)
+	posStr := p.posString(pos)
+	_, _ = fmt.Fprintf(w, "\tcall void @tiny_go_builtin_panic_divide(i8* %s, i32 %d)\n", p.stringConstPtr(posStr), len(posStr))
+	_, _ = fmt.Fprintf(w, "\tunreachable\n")
+
+	_, _ = fmt.Fprintf(w, "\n%s:\n", okLabel)
+}
+
+// compileShift 编译移位运算, LLVM 中移位数不小于位数时结果未定义, 需要单独处理:
+// 左移和无符号右移的结果为 0, 有符号右移相当于移动 位数-1 位. 有符号的移位数为负数时 panic
+func (p *Compiler) compileShift(w io.Writer, pos token.Pos, op token.TokenType, typ, yTyp types.Type, x, y string) string {
+	t := llType(typ)
+	bits := types.Sizeof(typ) * 8
+	if n, err := strconv.ParseInt(y, 10, 64); !types.IsUnsigned(yTyp) && (err != nil || n < 0) {
+		p.genShiftCheck(w, pos, y, yTyp)
+	}
+
+	over := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = icmp uge %s %s, %d\n", over, llType(yTyp), y, bits)
+	count := p.convert(w, y, yTyp, typ)
+
+	localName := p.genId()
+	if op == token.SHR && !types.IsUnsigned(typ) {
+		clamped := p.genId()
+		_, _ = fmt.Fprintf(w, "\t%s = select i1 %s, %s %d, %s %s\n", clamped, over, t, bits-1, t, count)
+		_, _ = fmt.Fprintf(w, "\t%s = ashr %s %s, %s\n", localName, t, x, clamped)
+		return localName
+	}
+
+	shifted := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = %s %s %s, %s\n", shifted, opType(op, typ), t, x, count)
+	_, _ = fmt.Fprintf(w, "\t%s = select i1 %s, %s 0, %s %s\n", localName, over, t, t, shifted)
+	return localName
+}
+
+// genShiftCheck 检查有符号的移位数, 为负数时 panic
+func (p *Compiler) genShiftCheck(w io.Writer, pos token.Pos, y string, yTyp types.Type) {
+	isNeg := p.genId()
+	panicLabel := p.genLabelId("shift.panic")
+	okLabel := p.genLabelId("shift.ok")
+	_, _ = fmt.Fprintf(w, "\t%s = icmp slt %s %s, 0\n", isNeg, llType(yTyp), y)
+	_, _ = fmt.Fprintf(w, "\tbr i1 %s, label %%%s, label %%%s\n", isNeg, panicLabel, okLabel)
+
+	_, _ = fmt.Fprintf(w, "\n%s:\n", panicLabel)
+	posStr := p.posString(pos)
+	_, _ = fmt.Fprintf(w, "\tcall void @tiny_go_builtin_panic_shift(i8* %s, i32 %d)\n", p.stringConstPtr(posStr), len(posStr))
+	_, _ = fmt.Fprintf(w, "\tunreachable\n")
+
+	_, _ = fmt.Fprintf(w, "\n%s:\n", okLabel)
+}
+
+// compileStmtOpAssign 编译复合赋值 x op= y
+func (p *Compiler) compileStmtOpAssign(w io.Writer, stmt *ast.AssignStmt, op token.TokenType) {
+	y := stmt.Value[0]
+	p.compileOpAssign(w, stmt.Target[0], stmt.OpPos, op, p.exprType(y), func() string {
+		return p.compileExpr(w, y)
+	})
+}
+
+// compileStmtIncDec 编译 x++ 和 x--, 相当于 x += 1 和 x -= 1
+func (p *Compiler) compileStmtIncDec(w io.Writer, stmt *ast.IncDecStmt) {
+	op := token.ADD
+	if stmt.Tok == token.DEC {
+		op = token.SUB
+	}
+	one := types.Typ[types.UntypedInt]
+	p.compileOpAssign(w, stmt.X, stmt.TokPos, op, one, func() string {
+		return p.compileConst(w, constant.MakeInt64(1), one)
+	})
+}
+
+// compileOpAssign 编译 target op= y, target 只求值一次, y 在读取 target 之后由 compileY 求值
+func (p *Compiler) compileOpAssign(w io.Writer, target ast.Expr, pos token.Pos, op token.TokenType,
+	yTyp types.Type, compileY func() string) {
+	expr := &ast.BinaryExpr{X: target, OpPos: pos, Op: op}
+	typ := p.exprType(target)
+
+	ptr := p.compileAddr(w, target)
+	x := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = load %s, %s* %s, align %d\n", x, llType(typ), llType(typ), ptr, types.Alignof(typ))
+	value := p.compileBinaryOp(w, expr, typ, yTyp, x, compileY())
+	_, _ = fmt.Fprintf(w, "\tstore %s %s, %s* %s\n", llType(typ), value, llType(typ), ptr)
+}
@@ -0,0 +1,78 @@
+package compiler
+
+import (
+	"fmt"
+	"io"
+	"tiny-go/ast"
+	"tiny-go/token"
+)
+
+// bool 在 LLVM 中表示为 i1. && 和 || 按短路求值: 作为 if/for 的条件时直接生成跳转,
+// 作为值使用时右操作数在单独的基本块中求值, 结果由 phi 指令合并.
+
+// compileCond 编译条件表达式, 为真时跳转到 trueLabel, 否则跳转到 falseLabel
+func (p *Compiler) compileCond(w io.Writer, cond ast.Expr, trueLabel, falseLabel string) {
+	switch expr := cond.(type) {
+	case *ast.ParenExpr:
+		p.compileCond(w, expr.X, trueLabel, falseLabel)
+		return
+	case *ast.UnaryExpr:
+		if expr.Op == token.NOT {
+			p.compileCond(w, expr.X, falseLabel, trueLabel)
+			return
+		}
+	case *ast.BinaryExpr:
+		switch expr.Op {
+		case token.AND:
+			rhs := p.genLabelId("cond.and")
+			p.compileCond(w, expr.X, rhs, falseLabel)
+			_, _ = fmt.Fprintf(w, "\n%s:\n", rhs)
+			p.compileCond(w, expr.Y, trueLabel, falseLabel)
+			return
+		case token.OR:
+			rhs := p.genLabelId("cond.or")
+			p.compileCond(w, expr.X, trueLabel, rhs)
+			_, _ = fmt.Fprintf(w, "\n%s:\n", rhs)
+			p.compileCond(w, expr.Y, trueLabel, falseLabel)
+			return
+		}
+	}
+
+	value := p.compileExpr(w, cond)
+	_, _ = fmt.Fprintf(w, "\tbr i1 %s, label %%%s, label %%%s\n", value, trueLabel, falseLabel)
+}
+
+// compileLogical 编译作为值使用的 && 和 ||
+func (p *Compiler) compileLogical(w io.Writer, expr *ast.BinaryExpr) string {
+	name := "and"
+	if expr.Op == token.OR {
+		name = "or"
+	}
+	lhs := p.genLabelId(name + ".lhs")
+	rhs := p.genLabelId(name + ".rhs")
+	rhsEnd := p.genLabelId(name + ".rhs.end")
+	end := p.genLabelId(name + ".end")
+
+	// 左操作数求值后可能已经不在当前基本块中, 跳转到新的基本块以便 phi 指令引用
+	x := p.compileExpr(w, expr.X)
+	_, _ = fmt.Fprintf(w, "\tbr label %%%s\n", lhs)
+	_, _ = fmt.Fprintf(w, "\n%s:\n", lhs)
+	short := "false"
+	if expr.Op == token.AND {
+		_, _ = fmt.Fprintf(w, "\tbr i1 %s, label %%%s, label %%%s\n", x, rhs, end)
+	} else {
+		short = "true"
+		_, _ = fmt.Fprintf(w, "\tbr i1 %s, label %%%s, label %%%s\n", x, end, rhs)
+	}
+
+	_, _ = fmt.Fprintf(w, "\n%s:\n", rhs)
+	y := p.compileExpr(w, expr.Y)
+	_, _ = fmt.Fprintf(w, "\tbr label %%%s\n", rhsEnd)
+	_, _ = fmt.Fprintf(w, "\n%s:\n", rhsEnd)
+	_, _ = fmt.Fprintf(w, "\tbr label %%%s\n", end)
+
+	_, _ = fmt.Fprintf(w, "\n%s:\n", end)
+	localName := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = phi i1 [ %s, %%%s ], [ %s, %%%s ]\n", localName, short, lhs, y, rhsEnd)
+	return localName
+}
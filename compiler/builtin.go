@@ -0,0 +1,65 @@
+package compiler
+
+import (
+	"fmt"
+	"io"
+	"tiny-go/ast"
+	"tiny-go/types"
+)
+
+// compileBuiltinCall 编译 len 等内置函数的调用
+func (p *Compiler) compileBuiltinCall(w io.Writer, expr *ast.CallExpr) string {
+	switch name := expr.FuncName.Name; name {
+	case "len", "cap":
+		arg := expr.Args[0]
+		switch typ := types.Underlying(p.exprType(arg)).(type) {
+		case *types.Array:
+			return fmt.Sprint(typ.Len)
+		case *types.Slice:
+			field := 1
+			if name == "cap" {
+				field = 2
+			}
+			localName := p.genId()
+			_, _ = fmt.Fprintf(w, "\t%s = extractvalue %s %s, %d\n", localName, llType(typ), p.compileExpr(w, arg), field)
+			return localName
+		case *types.Map:
+			localName := p.genId()
+			_, _ = fmt.Fprintf(w, "\t%s = call i32 @tiny_go_builtin_map_len(i8* %s)\n", localName, p.compileExpr(w, arg))
+			return localName
+		case *types.Chan:
+			localName := p.genId()
+			_, _ = fmt.Fprintf(w, "\t%s = call i32 @tiny_go_builtin_chan_%s(i8* %s)\n", localName, name, p.compileExpr(w, arg))
+			return localName
+		default:
+			_, n := p.stringParts(w, p.compileExpr(w, arg))
+			return n
+		}
+	case "make":
+		return p.compileMake(w, expr)
+	case "append":
+		return p.compileAppend(w, expr)
+	case "new":
+		return p.compileNew(w, expr)
+	case "delete", "close", "panic":
+		call := p.prepareBuiltinCall(w, expr)
+		return p.emitCall(w, call.fnName, call.resultType, call.paramsType, call.args)
+	case "recover":
+		return p.compileRecover(w, expr)
+	}
+	panic("unreachable")
+}
+
+// prepareBuiltinCall 计算作为语句调用的内置函数的参数, 返回对运行时的调用.
+// 也用于 defer 和 go 语句, 其中只能使用没有结果的 delete, close 和 panic
+func (p *Compiler) prepareBuiltinCall(w io.Writer, expr *ast.CallExpr) *callInfo {
+	switch expr.FuncName.Name {
+	case "delete":
+		return p.prepareDelete(w, expr)
+	case "close":
+		return p.prepareClose(w, expr)
+	case "panic":
+		return p.preparePanic(w, expr)
+	}
+	panic("unreachable")
+}
@@ -0,0 +1,78 @@
+package compiler
+
+import (
+	"fmt"
+	"io"
+	"tiny-go/ast"
+	"tiny-go/types"
+)
+
+// channel 在 LLVM 中表示为 i8*, 即 builtin 运行时中 channel 的指针, nil channel 为 null.
+// 发送和接收的值保存在临时变量中, 把地址传给运行时, 由运行时在 goroutine 之间复制.
+// 对 nil channel 的发送和接收永远阻塞, 所有 goroutine 都阻塞时运行时报告死锁.
+
+// compileMakeChan 编译 make(chan T) 和 make(chan T, size)
+func (p *Compiler) compileMakeChan(w io.Writer, expr *ast.CallExpr, typ *types.Chan) string {
+	size := "0"
+	if len(expr.Args) == 2 {
+		size = p.compileIndex(w, expr.Args[1])
+	}
+	posStr := p.posString(expr.Pos())
+	localName := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = call i8* @tiny_go_builtin_chan_make(i32 %s, i32 %s, i8* %s, i32 %d)\n",
+		localName, llSizeOf(typ.Elem), size, p.stringConstPtr(posStr), len(posStr))
+	return localName
+}
+
+// chanType 获取 channel 操作的操作数的类型
+func (p *Compiler) chanType(expr ast.Expr) *types.Chan {
+	return types.Underlying(p.exprType(expr)).(*types.Chan)
+}
+
+// compileChanRecv 编译 <-ch. commaOk 为 true 时还返回值是否来自发送方, channel 关闭后为 false
+func (p *Compiler) compileChanRecv(w io.Writer, expr *ast.UnaryExpr, commaOk bool) (value, ok string) {
+	typ := p.chanType(expr.X)
+	c := p.compileExpr(w, expr.X)
+	ptr := p.genId()
+	p.genAlloca(w, ptr, llType(typ.Elem), types.Alignof(typ.Elem))
+	elem := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = bitcast %s* %s to i8*\n", elem, llType(typ.Elem), ptr)
+	received := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = call i32 @tiny_go_builtin_chan_recv(i8* %s, i8* %s)\n", received, c, elem)
+	value = p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = load %s, %s* %s, align %d\n", value, llType(typ.Elem), llType(typ.Elem), ptr, types.Alignof(typ.Elem))
+	if !commaOk {
+		return value, ""
+	}
+	ok = p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = icmp ne i32 %s, 0\n", ok, received)
+	return value, ok
+}
+
+// compileSendValue 计算 ch <- v 的 channel 和值, 返回 channel 和保存值的临时变量的地址
+func (p *Compiler) compileSendValue(w io.Writer, stmt *ast.SendStmt) (c, elem string) {
+	typ := p.chanType(stmt.Chan)
+	c = p.compileExpr(w, stmt.Chan)
+	value := p.convert(w, p.compileExpr(w, stmt.Value), p.exprType(stmt.Value), typ.Elem)
+	return c, p.spillRaw(w, value, typ.Elem)
+}
+
+// compileStmtSend 编译 ch <- v, 向已关闭的 channel 发送时在运行时 panic
+func (p *Compiler) compileStmtSend(w io.Writer, stmt *ast.SendStmt) {
+	c, elem := p.compileSendValue(w, stmt)
+	posStr := p.posString(stmt.Arrow)
+	_, _ = fmt.Fprintf(w, "\tcall void @tiny_go_builtin_chan_send(i8* %s, i8* %s, i8* %s, i32 %d)\n",
+		c, elem, p.stringConstPtr(posStr), len(posStr))
+}
+
+// prepareClose 计算 close(ch) 的 channel, 返回对运行时的调用, 也用于 defer 和 go 语句
+func (p *Compiler) prepareClose(w io.Writer, expr *ast.CallExpr) *callInfo {
+	arg := expr.Args[0]
+	posStr := p.posString(expr.Pos())
+	return &callInfo{
+		fnName:     "@tiny_go_builtin_chan_close",
+		resultType: "i32",
+		paramsType: []string{"i8*", "i8*", "i32"},
+		args:       []string{p.compileExpr(w, arg), p.stringConstPtr(posStr), fmt.Sprint(len(posStr))},
+	}
+}
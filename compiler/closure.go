@@ -0,0 +1,178 @@
+package compiler
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"tiny-go/ast"
+	"tiny-go/types"
+)
+
+// 函数值在 LLVM 中表示为 { i8*, i8* }, 即代码指针和环境指针, nil 函数值的代码指针为 null.
+// 代码的第一个参数为环境指针, 其余参数和函数类型一致.
+//
+// 闭包按引用捕获外层函数的局部变量: 这些变量已经由 findEscapes 分配在堆上, 环境是堆上的结构体,
+// 保存被捕获变量的指针. 闭包编译为单独的函数 <外层函数名>.funcN, 入口处从环境中取出变量的指针,
+// 保存到和外层函数相同的变量名中. 函数声明作为值使用时通过忽略环境的包装函数 <函数名>.closure 调用.
+
+// compileFuncLit 编译函数字面值, 返回函数值
+func (p *Compiler) compileFuncLit(w io.Writer, lit *ast.FuncLit) string {
+	sig := p.exprType(lit).(*types.Signature)
+	captures := p.captures(lit)
+	p.fn.funcLits++
+	name := fmt.Sprintf("%s.func%d", p.fn.name, p.fn.funcLits)
+
+	var buf bytes.Buffer
+	p.compileFuncBody(&buf, &funcState{name: name, sig: sig, escapes: p.findEscapes(lit.Body)},
+		lit.Type, lit.Body, lit.Type.Params.List, sig.Params, captures)
+	_, _ = p.funcLits.Write(buf.Bytes())
+
+	env := "null"
+	if len(captures) > 0 {
+		envType := captureEnvType(captures)
+		ptr := p.heapAlloc(w, envType)
+		for i, obj := range captures {
+			p.storeField(w, llType(envType), ptr, i, llType(obj.Type)+"*", p.objName(obj))
+		}
+		env = p.genId()
+		_, _ = fmt.Fprintf(w, "\t%s = bitcast %s* %s to i8*\n", env, llType(envType), ptr)
+	}
+	return p.makeFuncValue(w, fmt.Sprintf("bitcast (%s %s to i8*)", envFuncType(sig), name), env)
+}
+
+// captures 查找闭包引用的外层局部变量, 按第一次出现的顺序排列.
+// 闭包中声明的变量此时还没有分配, 所以已经有 LLVM 局部名字的变量都来自外层函数
+func (p *Compiler) captures(lit *ast.FuncLit) []*types.Object {
+	captures := []*types.Object{}
+	seen := make(map[*types.Object]bool)
+	for _, ident := range funcLitIdents(lit) {
+		obj := p.info.Uses[ident]
+		if obj == nil || obj.Kind != types.ObjVar || !strings.HasPrefix(p.names[obj], "%") || seen[obj] {
+			continue
+		}
+		seen[obj] = true
+		captures = append(captures, obj)
+	}
+	return captures
+}
+
+// funcLitIdents 获取闭包中引用的所有标识符, 包括调用中的函数名
+func funcLitIdents(lit *ast.FuncLit) (idents []*ast.Ident) {
+	ast.Inspect(lit.Body, func(node interface{}) bool {
+		switch node := node.(type) {
+		case *ast.Ident:
+			idents = append(idents, node)
+		case *ast.CallExpr:
+			if node.Pkg != nil {
+				idents = append(idents, node.Pkg)
+			}
+			if node.Fun == nil && node.Recv == nil && node.Pkg == nil {
+				idents = append(idents, node.FuncName)
+			}
+		}
+		return true
+	})
+	return idents
+}
+
+// captureEnvType 闭包环境的类型, 字段为被捕获变量的指针
+func captureEnvType(captures []*types.Object) *types.Struct {
+	env := &types.Struct{}
+	for _, obj := range captures {
+		env.Fields = append(env.Fields, &types.Field{Name: obj.Name, Type: &types.Pointer{Elem: obj.Type}})
+	}
+	return env
+}
+
+// loadCaptures 在闭包的入口处从环境中取出被捕获变量的指针
+func (p *Compiler) loadCaptures(w io.Writer, captures []*types.Object) {
+	if len(captures) == 0 {
+		return
+	}
+	envType := llType(captureEnvType(captures))
+	env := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = bitcast i8* %%env to %s*\n", env, envType)
+	for i, obj := range captures {
+		fieldPtr := p.genId()
+		_, _ = fmt.Fprintf(w, "\t%s = getelementptr inbounds %s, %s* %s, i32 0, i32 %d\n", fieldPtr, envType, envType, env, i)
+		_, _ = fmt.Fprintf(w, "\t%s = load %s*, %s** %s\n", p.objName(obj), llType(obj.Type), llType(obj.Type), fieldPtr)
+	}
+}
+
+// makeFuncValue 由代码指针和环境指针构造函数值
+func (p *Compiler) makeFuncValue(w io.Writer, code, env string) string {
+	withCode := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = insertvalue { i8*, i8* } undef, i8* %s, 0\n", withCode, code)
+	localName := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = insertvalue { i8*, i8* } %s, i8* %s, 1\n", localName, withCode, env)
+	return localName
+}
+
+// funcDeclValue 函数声明作为值使用时的函数值常量, 需要时生成包装函数
+func (p *Compiler) funcDeclValue(obj *types.Object) string {
+	sig := obj.Type.(*types.Signature)
+	wrapper := p.objName(obj) + ".closure"
+	if !p.funcWrappers[wrapper] {
+		p.funcWrappers[wrapper] = true
+
+		params := []string{"i8* %env"}
+		var paramsType, args []string
+		for i, param := range sig.Params {
+			args = append(args, fmt.Sprintf("%%arg%d", i))
+			paramsType = append(paramsType, llType(param))
+			params = append(params, fmt.Sprintf("%s %s", llType(param), args[i]))
+		}
+		w := &p.funcLits
+		_, _ = fmt.Fprintf(w, "\ndefine private %s %s(%s) {\n", resultType(sig), wrapper, strings.Join(params, ", "))
+		result := p.emitCall(w, p.objName(obj), resultType(sig), paramsType, args)
+		_, _ = fmt.Fprintf(w, "\tret %s %s\n", resultType(sig), result)
+		_, _ = fmt.Fprintf(w, "}\n")
+	}
+	return fmt.Sprintf("{ i8* bitcast (%s %s to i8*), i8* null }", envFuncType(sig), wrapper)
+}
+
+// funcValue 获取被调用的函数值, 如 f(x) 中函数类型的变量 f. 调用 s.f(x) 中函数类型的字段 f 时返回 s,
+// 字段由类型检查记录在 Selections 中. 调用的是函数声明, 方法或包中的函数时返回 nil
+func (p *Compiler) funcValue(expr *ast.CallExpr) ast.Expr {
+	if expr.Fun != nil {
+		return expr.Fun
+	}
+	if sel := p.info.Selections[expr]; sel != nil {
+		if sel.Kind == types.FieldVal {
+			return p.methodRecv(expr)
+		}
+		return nil
+	}
+	if obj := p.calleeObject(expr); obj != nil && obj.Kind == types.ObjVar {
+		return expr.FuncName
+	}
+	return nil
+}
+
+// funcValueType 获取被调用的函数值的类型, 调用函数类型的字段 s.f() 时为字段的类型
+func (p *Compiler) funcValueType(expr *ast.CallExpr, fn ast.Expr) *types.Signature {
+	typ := p.exprType(fn)
+	if sel := p.info.Selections[expr]; sel != nil {
+		typ = sel.Type
+	}
+	return types.Underlying(typ).(*types.Signature)
+}
+
+// compileFuncValue 计算被调用的函数值, 返回代码指针和环境指针
+func (p *Compiler) compileFuncValue(w io.Writer, expr *ast.CallExpr, fn ast.Expr, sig *types.Signature) (code, env string) {
+	var value string
+	if sel := p.info.Selections[expr]; sel != nil {
+		value = p.compileField(w, fn, expr.FuncName.NamePos, sel)
+	} else {
+		value = p.compileExpr(w, fn)
+	}
+	ptr := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = extractvalue { i8*, i8* } %s, 0\n", ptr, value)
+	env = p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = extractvalue { i8*, i8* } %s, 1\n", env, value)
+	p.genNilCheck(w, fn.Pos(), ptr, &types.Pointer{Elem: types.Typ[types.Int8]})
+	code = p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = bitcast i8* %s to %s\n", code, ptr, envFuncType(sig))
+	return code, env
+}
@@ -9,35 +9,75 @@ import (
 	"tiny-go/ast"
 	"tiny-go/builtin"
 	"tiny-go/token"
+	"tiny-go/types"
 )
 
 type Compiler struct {
 	file   *ast.File
-	scope  *Scope
+	info   *types.Info // 类型检查的结果
 	nextId int
+
+	fn    *funcState               // 当前正在编译的函数
+	names map[*types.Object]string // 变量和函数在 LLVM 中的名字
+
+	strings    []string          // 字符串常量, 下标为常量编号
+	stringsIdx map[string]string // 字符串常量对应的全局变量名
+
+	types    []types.Type       // 转换为接口的动态类型, 下标为类型描述符编号
+	typesIdx map[string]int     // 类型对应的类型描述符编号
+	itabs    []*itabInfo        // 已经用到的 itab
+	itabsIdx map[string]string  // 动态类型和接口对应的 itab 的全局变量名
+	lookups  []*types.Interface // 需要在运行时查找 itab 的接口, 下标为查找函数编号
+
+	funcLits     bytes.Buffer    // 闭包和函数值的包装函数, 在模块末尾输出
+	funcWrappers map[string]bool // 已经生成包装函数的函数
 }
 
-func NewCompiler() *Compiler {
-	return &Compiler{
-		scope: NewScope(Universe),
-	}
+// funcState 函数编译过程中的状态
+type funcState struct {
+	name   string        // LLVM 中的函数名
+	decl   *ast.FuncDecl // 函数声明, 闭包为 nil
+	sig    *types.Signature
+	defers []*deferCall // 已编译的 defer 语句, 下标为 defer 编号
+
+	escapes map[*types.Object]bool // 被取地址的局部变量, 分配在堆上
+	results []string               // 命名返回值对应的局部变量, 返回值没有命名时为 nil
+	allocas bytes.Buffer           // 在入口处分配的局部变量和临时变量
+
+	branches []*branchTarget // 外层的循环和 switch, 最内层的在最后
+	funcLits int             // 函数中已编译的闭包个数, 用于生成闭包的函数名
+	goStmts  int             // 函数中已编译的 go 语句个数, 用于生成 goroutine 入口函数的函数名
 }
 
-func (p *Compiler) enterScope() {
-	p.scope = NewScope(p.scope)
+// branchTarget break/continue 的跳转目标
+type branchTarget struct {
+	label      string // 循环或 switch 的标号, 没有标号时为空
+	breakTo    string
+	continueTo string // switch 没有 continue 的目标, 为空
 }
 
-func (p *Compiler) leaveScope() {
-	p.scope = p.scope.Outer
+func NewCompiler() *Compiler {
+	return &Compiler{
+		names:      make(map[*types.Object]string),
+		stringsIdx: make(map[string]string),
+		typesIdx:   make(map[string]int),
+		itabsIdx:   make(map[string]string),
+
+		funcWrappers: make(map[string]bool),
+	}
 }
 
-func (p *Compiler) restoreScope(scope *Scope) {
-	p.scope = scope
+// objName 获取变量或函数在 LLVM 中的名字, Universe 中的函数由 builtin 运行时实现
+func (p *Compiler) objName(obj *types.Object) string {
+	if name, ok := p.names[obj]; ok {
+		return name
+	}
+	return "@tiny_go_builtin_" + obj.Name
 }
 
 func (p *Compiler) genHeader(w io.Writer, file *ast.File) {
 	_, _ = fmt.Fprintf(w, ";package %s\n", file.Pkg.Name)
-	_, _ = fmt.Fprintf(w, builtin.Header)
+	_, _ = io.WriteString(w, builtin.Header)
 }
 
 func (p *Compiler) genMain(w io.Writer, file *ast.File) {
@@ -46,30 +86,45 @@ func (p *Compiler) genMain(w io.Writer, file *ast.File) {
 	}
 	for _, fn := range file.Funcs {
 		if fn.Name == "main" {
-			_, _ = fmt.Fprintf(w, builtin.MainMain)
+			_, _ = io.WriteString(w, builtin.MainMain)
 			return
 		}
 	}
 }
 
+// genStrings 生成字符串常量对应的全局变量
+func (p *Compiler) genStrings(w io.Writer) {
+	if len(p.strings) > 0 {
+		_, _ = fmt.Fprintln(w)
+	}
+	for i, s := range p.strings {
+		_, _ = fmt.Fprintf(w, "@.str.%d = private unnamed_addr constant [%d x i8] c\"%s\", align 1\n",
+			i, len(s), llString(s))
+	}
+}
+
 func (p *Compiler) genInit(w io.Writer, file *ast.File) {
-	_, _ = fmt.Fprintf(w, "define i32 @tiny_go_%s_init() {\n", file.Pkg.Name)
+	name := fmt.Sprintf("@tiny_go_%s_init", file.Pkg.Name)
+	_, _ = fmt.Fprintf(w, "define i32 %s() {\n", name)
 
-	for _, g := range file.Globals {
-		var localName = "0"
-		if g.Value != nil {
-			localName = p.compileExpr(w, g.Value)
-		}
+	// 全局变量的初始值中可以有闭包
+	defer func() { p.fn = nil }()
+	p.fn = &funcState{name: name, sig: &types.Signature{}}
 
-		var varName string
-		if _, obj := p.scope.Lookup(g.Name.Name); obj != nil {
-			varName = obj.MangledName
-		} else {
-			panic(fmt.Sprintf("var %v undefined", g))
+	var body bytes.Buffer
+	for _, g := range file.Globals {
+		if g.Value == nil {
+			continue
 		}
-
-		_, _ = fmt.Fprintf(w, "\tstore i32 %s, i32* %s\n", localName, varName)
+		obj := p.info.Defs[g.Name]
+		localName := p.compileExpr(&body, g.Value)
+		localName = p.convert(&body, localName, p.exprType(g.Value), obj.Type)
+		_, _ = fmt.Fprintf(&body, "\tstore %s %s, %s* %s\n", llType(obj.Type), localName, llType(obj.Type), p.objName(obj))
 	}
+	_, _ = w.Write(p.fn.allocas.Bytes())
+	p.genFramePush(w, false)
+	_, _ = w.Write(body.Bytes())
+	p.genFramePop(w)
 	_, _ = fmt.Fprintln(w, "\tret i32 0")
 	_, _ = fmt.Fprintln(w, "}")
 }
@@ -81,91 +136,116 @@ func (p *Compiler) genLabelId(name string) string {
 }
 
 func (p *Compiler) compileFile(w io.Writer, file *ast.File) {
-	defer p.restoreScope(p.scope)
-	p.enterScope()
-
-	// import
-	for _, x := range file.Imports {
-		var mangledName = fmt.Sprintf("@tiny_go_%s", x.Path)
-		if x.Name != nil {
-			p.scope.Insert(&Object{
-				Name:        x.Name.Name,
-				MangledName: mangledName,
-				Node:        x,
-			})
-		} else {
-			p.scope.Insert(&Object{
-				Name:        x.Path,
-				MangledName: mangledName,
-				Node:        x,
-			})
-		}
-	}
+	p.genTypeDefs(w, file)
 
 	// global vars
 	for _, g := range file.Globals {
+		obj := p.info.Defs[g.Name]
 		var mangledName = fmt.Sprintf("@tiny_go_%s_%s", file.Pkg.Name, g.Name.Name)
-		p.scope.Insert(&Object{
-			Name:        g.Name.Name,
-			MangledName: mangledName,
-			Type:        g.Type.Type,
-			Node:        g,
-		})
-		_, _ = fmt.Fprintf(w, "%s = global i32 0\n", mangledName)
+		p.names[obj] = mangledName
+		_, _ = fmt.Fprintf(w, "%s = global %s %s\n", mangledName, llType(obj.Type), zeroValue(obj.Type))
 	}
 
-	// global funcs
+	// global funcs, 方法的函数名为 @tiny_go_<pkg>_<Type>.<Method>
 	for _, fn := range file.Funcs {
-		var mangledName = fmt.Sprintf("@tiny_go_%s_%s", file.Pkg.Name, fn.Name)
-
-		// func type
-		var typ string
-		if fn.Type.Result == nil {
-			typ = "i32"
-		} else {
-			typ = fn.Type.Result.Type
+		obj := p.info.Funcs[fn]
+		if sig := obj.Type.(*types.Signature); sig.Recv != nil {
+			p.names[obj] = fmt.Sprintf("@tiny_go_%s_%s.%s", file.Pkg.Name, recvBase(sig.Recv).Name, fn.Name)
+			continue
 		}
-		p.scope.Insert(&Object{
-			Name:        fn.Name,
-			MangledName: mangledName,
-			Type:        typ,
-			Node:        fn,
-		})
+		p.names[obj] = fmt.Sprintf("@tiny_go_%s_%s", file.Pkg.Name, fn.Name)
 	}
 	p.genInit(w, file)
 	for _, fn := range file.Funcs {
-		p.compileFunc(w, file, fn)
+		p.compileFunc(w, fn)
+	}
+}
+
+func (p *Compiler) compileFunc(w io.Writer, fn *ast.FuncDecl) {
+	obj := p.info.Funcs[fn]
+	sig := obj.Type.(*types.Signature)
+	name := p.objName(obj)
+
+	// 方法的接收者作为第一个参数
+	params, paramTypes := fn.Type.Params.List, sig.Params
+	if fn.Recv != nil {
+		params = append(fn.Recv.List[:1:1], params...)
+		paramTypes = append([]types.Type{sig.Recv}, paramTypes...)
+	}
+
+	if fn.Body == nil {
+		var argTypeList []string
+		for _, typ := range paramTypes {
+			argTypeList = append(argTypeList, llType(typ))
+		}
+		_, _ = fmt.Fprintf(w, "declare %s %s(%s)\n", resultType(sig), name, strings.Join(argTypeList, ", "))
+		return
 	}
+
+	p.compileFuncBody(w, &funcState{name: name, decl: fn, sig: sig, escapes: p.findEscapes(fn.Body)},
+		fn.Type, fn.Body, params, paramTypes, nil)
 }
 
-func (p *Compiler) compileFunc(w io.Writer, file *ast.File, fn *ast.FuncDecl) {
-	defer p.restoreScope(p.scope)
-	p.enterScope()
+// compileFuncBody 生成函数的定义. captures 为闭包捕获的变量, 不为 nil 时函数的第一个参数为环境指针
+func (p *Compiler) compileFuncBody(w io.Writer, fn *funcState, ftype *ast.FuncType, fnBody *ast.BlockStmt,
+	params []*ast.Field, paramTypes []types.Type, captures []*types.Object) {
+	defer func(outer *funcState) { p.fn = outer }(p.fn)
+	p.fn = fn
+	sig := fn.sig
 
 	// args
 	var argNameList []string
 	var argTypeList []string
-	for _, arg := range fn.Type.Params.List {
-		var mangledName = fmt.Sprintf("%%local_%s.pos.%d", arg.Name.Name, arg.Name.NamePos)
-		var argType = arg.Type.Type
+	for i, arg := range params {
+		pos := arg.Type.Pos()
+		if arg.Name != nil {
+			pos = arg.Name.NamePos
+		}
+		var mangledName = fmt.Sprintf("%%local_%s.pos.%d", paramName(arg), pos)
 		argNameList = append(argNameList, mangledName)
-		argTypeList = append(argTypeList, argType)
+		argTypeList = append(argTypeList, llType(paramTypes[i]))
 	}
 
 	// result type
-	var typ string
-	if fn.Type.Result == nil {
-		typ = "i32"
-	} else {
-		typ = fn.Type.Result.Type
+	var typ = resultType(sig)
+
+	// fn body. 命名返回值在 setjmp 之前声明, panic 时从 defer.run 返回也能访问
+	var results, body bytes.Buffer
+	if captures != nil {
+		p.loadCaptures(&body, captures)
 	}
 
-	if fn.Body == nil {
-		_, _ = fmt.Fprintf(w, "declare %s @tiny_go_%s_%s()\n", typ, file.Pkg.Name, fn.Name)
-		return
+	// args, 没有名字的参数没有对应的对象
+	for i, arg := range params {
+		var argRegName = fmt.Sprintf("%s.arg%d", argNameList[i], i)
+		var mangledName = argNameList[i]
+		var obj *types.Object
+		if arg.Name != nil {
+			obj = p.info.Defs[arg.Name]
+		}
+
+		p.allocLocal(&body, obj, mangledName, paramTypes[i])
+		_, _ = fmt.Fprintf(&body, "\tstore %s %s, %s* %s\n", argTypeList[i], argRegName, argTypeList[i], mangledName)
 	}
-	_, _ = fmt.Fprintf(w, "define %s @tiny_go_%s_%s(", typ, file.Pkg.Name, fn.Name)
+
+	p.declareResults(&results, ftype, sig)
+
+	// body
+	for _, x := range fnBody.List {
+		p.compileStmt(&body, x)
+	}
+
+	linkage := ""
+	if captures != nil {
+		// 闭包只能通过函数值调用
+		linkage = "private "
+	}
+	_, _ = fmt.Fprintf(w, "define %s%s %s(", linkage, typ, fn.name)
 	var first = true
+	if captures != nil {
+		first = false
+		_, _ = fmt.Fprintf(w, "i8* %%env")
+	}
 	for i, argRegName := range argNameList {
 		if first {
 			first = false
@@ -176,35 +256,37 @@ func (p *Compiler) compileFunc(w io.Writer, file *ast.File, fn *ast.FuncDecl) {
 	}
 	_, _ = fmt.Fprintf(w, ") {\n")
 
-	// fn body
-	func() {
-		// args+body scope
-		defer p.restoreScope(p.scope)
-		p.enterScope()
-
-		// args
-		for i, arg := range fn.Type.Params.List {
-			var argRegName = fmt.Sprintf("%s.arg%d", argNameList[i], i)
-			var mangledName = argNameList[i]
-			p.scope.Insert(&Object{
-				Name:        arg.Name.Name,
-				MangledName: mangledName,
-				Type:        arg.Type.Type,
-				Node:        fn,
-			})
-
-			_, _ = fmt.Fprintf(w, "\t%s = alloca %s, align 4\n", mangledName, arg.Type.Type)
-			_, _ = fmt.Fprintf(w, "\tstore %s %s, %s* %s\n", arg.Type.Type, argRegName, arg.Type.Type, mangledName)
+	// 返回值和 defer 链表需要在入口处分配, 保证所有 return 路径都能访问.
+	// panic 被 recover 后没有执行 return 语句, 返回值为零值
+	hasDefer := len(p.fn.defers) > 0
+	if sig.Result != nil {
+		_, _ = fmt.Fprintf(w, "\t%%ret.value = alloca %s, align %d\n", typ, types.Alignof(sig.Result))
+		if hasDefer {
+			_, _ = fmt.Fprintf(w, "\tstore %s %s, %s* %%ret.value\n", typ, zeroValue(sig.Result), typ)
 		}
-
-		// body
-		for _, x := range fn.Body.List {
-			p.compileStmt(w, x)
-		}
-	}()
-
-	if fn.Type.Result == nil {
+	}
+	if hasDefer {
+		_, _ = fmt.Fprintf(w, "\t%%defer.head = alloca i8*, align 8\n")
+		_, _ = fmt.Fprintf(w, "\tstore i8* null, i8** %%defer.head\n")
+	}
+	_, _ = w.Write(p.fn.allocas.Bytes())
+	_, _ = w.Write(results.Bytes())
+	p.genFramePush(w, hasDefer)
+	_, _ = w.Write(body.Bytes())
+	_, _ = fmt.Fprintf(w, "\tbr label %%return\n")
+
+	// return: 所有 return 语句都跳转到这里, 先执行 defer 再返回
+	_, _ = fmt.Fprintf(w, "\nreturn:\n")
+	p.genDeferRun(w)
+	p.genFramePop(w)
+	if sig.Result == nil {
 		_, _ = fmt.Fprintf(w, "\tret %s 0\n", typ)
+	} else if p.fn.results != nil {
+		_, _ = fmt.Fprintf(w, "\tret %s %s\n", typ, p.genLoadResults(w))
+	} else {
+		retValue := p.genId()
+		_, _ = fmt.Fprintf(w, "\t%s = load %s, %s* %%ret.value, align %d\n", retValue, typ, typ, types.Alignof(sig.Result))
+		_, _ = fmt.Fprintf(w, "\tret %s %s\n", typ, retValue)
 	}
 	_, _ = fmt.Fprintln(w, "}")
 }
@@ -212,41 +294,48 @@ func (p *Compiler) compileFunc(w io.Writer, file *ast.File, fn *ast.FuncDecl) {
 func (p *Compiler) compileStmt(w io.Writer, stmt ast.Stmt) {
 	switch stmt := stmt.(type) {
 	case *ast.VarSpec:
-		var localName = "0"
+		obj := p.info.Defs[stmt.Name]
+		typ := obj.Type
+		var localName = zeroValue(typ)
 		if stmt.Value != nil {
 			localName = p.compileExpr(w, stmt.Value)
+			localName = p.convert(w, localName, p.exprType(stmt.Value), typ)
 		}
 
 		var mangledName = fmt.Sprintf("%%local_%s.pos.%d", stmt.Name.Name, stmt.VarPos)
-		var width int
-		p.scope.Insert(&Object{
-			Name:        stmt.Name.Name,
-			MangledName: mangledName,
-			Type:        stmt.Type.Type,
-			Node:        stmt,
-		})
-		switch stmt.Type.Type {
-		case "i8":
-			width = 1
-		default:
-			width = 4
-		}
-
-		_, _ = fmt.Fprintf(w, "\t%s = alloca %s, align %d\n", mangledName, stmt.Type.Type, width)
-		_, _ = fmt.Fprintf(w, "\tstore %s %s, %s* %s\n", stmt.Type.Type, localName, stmt.Type.Type, mangledName)
+		p.allocLocal(w, obj, mangledName, typ)
+		_, _ = fmt.Fprintf(w, "\tstore %s %s, %s* %s\n", llType(typ), localName, llType(typ), mangledName)
+	case *ast.ConstDecl:
+		// 常量的值已经由类型检查计算, 用到时直接生成
 	case *ast.AssignStmt:
 		p.compileStmtAssign(w, stmt)
+	case *ast.IncDecStmt:
+		p.compileStmtIncDec(w, stmt)
 	case *ast.ReturnStmt:
 		p.compileStmtReturn(w, stmt)
+	case *ast.DeferStmt:
+		p.compileStmtDefer(w, stmt)
+	case *ast.GoStmt:
+		p.compileStmtGo(w, stmt)
+	case *ast.SendStmt:
+		p.compileStmtSend(w, stmt)
 	case *ast.IfStmt:
 		p.compileStmtIf(w, stmt)
 	case *ast.ForStmt:
-		p.compileStmtFor(w, stmt)
+		p.compileStmtFor(w, stmt, "")
+	case *ast.RangeStmt:
+		p.compileStmtRange(w, stmt, "")
+	case *ast.SwitchStmt:
+		p.compileStmtSwitch(w, stmt, "")
+	case *ast.TypeSwitchStmt:
+		p.compileStmtTypeSwitch(w, stmt, "")
+	case *ast.SelectStmt:
+		p.compileStmtSelect(w, stmt, "")
 	case *ast.BranchStmt:
 		p.compileStmtBranch(w, stmt)
+	case *ast.LabeledStmt:
+		p.compileStmtLabeled(w, stmt)
 	case *ast.BlockStmt:
-		defer p.restoreScope(p.scope)
-		p.enterScope()
 		for _, x := range stmt.List {
 			p.compileStmt(w, x)
 		}
@@ -257,186 +346,74 @@ func (p *Compiler) compileStmt(w io.Writer, stmt ast.Stmt) {
 	}
 }
 
-func (p *Compiler) compileStmtReturn(w io.Writer, stmt *ast.ReturnStmt) {
-	if stmt.Result != nil {
-		typ, flag := getType(stmt.Result)
-		if !flag {
-			if _, obj := p.scope.Lookup(typ); obj != nil {
-				typ = obj.Type
-			} else {
-				panic(fmt.Sprintf("var %s undefined", typ))
-			}
-		}
-		_, _ = fmt.Fprintf(w, "\tret %s %v\n", typ, p.compileExpr(w, stmt.Result))
-	} else {
-		_, _ = fmt.Fprintf(w, "\tret i32 0\n")
-	}
-}
-
 func (p *Compiler) compileStmtBranch(w io.Writer, stmt *ast.BranchStmt) {
-	var branch []string
-	if _, obj := p.scope.Lookup("for"); obj != nil {
-		branch = strings.Fields(obj.MangledName)
-	} else {
-		panic(fmt.Sprintf("for undefined"))
+	if stmt.TokType == token.GOTO {
+		_, _ = fmt.Fprintf(w, "\tbr label %%%s\n", labelName(stmt.Label.Name))
+		return
 	}
-	var i int
+	target := p.lookupBranch(stmt)
 	switch stmt.TokType {
 	case token.BREAK:
-		i = 1
+		_, _ = fmt.Fprintf(w, "\tbr label %%%s\n", target.breakTo)
 	case token.CONTINUE:
-		i = 0
+		_, _ = fmt.Fprintf(w, "\tbr label %%%s\n", target.continueTo)
 	default:
 		panic("unreachable")
 	}
-	_, _ = fmt.Fprintf(w, "\tbr label %%%s\n", branch[i])
 }
 
-func (p *Compiler) compileStmtAssign(w io.Writer, stmt *ast.AssignStmt) {
-	var varNameList = make([]string, len(stmt.Value))
-	for i := range stmt.Target {
-		varNameList[i] = p.compileExpr(w, stmt.Value[i])
-	}
-
-	if stmt.Op == token.DEFINE {
-		for i, target := range stmt.Target {
-			if _, obj := p.scope.Lookup(target.Name); obj == nil {
-				var mangledName = fmt.Sprintf("%%local_%s.pos.%d", target.Name, target.NamePos)
-				var width int
-				typ, flag := getType(stmt.Value[i])
-				if !flag {
-					if _, obj := p.scope.Lookup(typ); obj != nil {
-						typ = obj.Type
-					} else {
-						panic(fmt.Sprintf("var %s undefined", typ))
-					}
-				}
-				p.scope.Insert(&Object{
-					Name:        target.Name,
-					MangledName: mangledName,
-					Type:        typ,
-					Node:        target,
-				})
-				switch typ {
-				case "i32":
-					width = 4
-				case "i8":
-					width = 1
-				default:
-					width = 4
-				}
-
-				_, _ = fmt.Fprintf(w, "\t%s = alloca %s, align %d\n", mangledName, typ, width)
-			}
-		}
-	}
-
-	for i := range stmt.Target {
-		var varName string
-		var typ string
-		if _, obj := p.scope.Lookup(stmt.Target[i].Name); obj != nil {
-			varName = obj.MangledName
-			typ = obj.Type
-		} else {
-			panic(fmt.Sprintf("var %s undefined", stmt.Target[0].Name))
+// lookupBranch 查找 break/continue 跳转的循环或 switch, 标号已经由类型检查保证有效
+func (p *Compiler) lookupBranch(stmt *ast.BranchStmt) *branchTarget {
+	for i := len(p.fn.branches) - 1; i >= 0; i-- {
+		target := p.fn.branches[i]
+		if stmt.TokType == token.CONTINUE && target.continueTo == "" {
+			continue // continue 跳过外层的 switch
 		}
-		_, _ = fmt.Fprintf(w, "\tstore %s %s, %s* %s\n", typ, varNameList[i], typ, varName)
-	}
-}
-
-func (p *Compiler) compileStmtCond(stmt ast.Expr) []string {
-	var condList []string
-	switch stmt.(type) {
-	case *ast.BinaryExpr:
-		switch stmt.(*ast.BinaryExpr).Op {
-		case token.AND, token.OR:
-			condList = append(condList, p.compileStmtCond(stmt.(*ast.BinaryExpr).X)...)
-			condList = append(condList, p.compileStmtCond(stmt.(*ast.BinaryExpr).Y)...)
-		default:
-			condList = append(condList, "if.cond.line"+string(rune(stmt.(*ast.BinaryExpr).OpPos)))
+		if stmt.Label == nil || stmt.Label.Name == target.label {
+			return target
 		}
-	case *ast.UnaryExpr:
-		p.convertNot(stmt.(*ast.UnaryExpr).X)
-		condList = append(condList, p.compileStmtCond(stmt.(*ast.UnaryExpr).X)...)
 	}
-	return condList
+	panic("unreachable")
 }
 
-func (p *Compiler) convertNot(stmt ast.Expr) {
-	switch stmt.(type) {
-	case *ast.UnaryExpr:
-		p.convertNot(stmt.(*ast.UnaryExpr).X)
-	case *ast.BinaryExpr:
-		switch stmt.(*ast.BinaryExpr).Op {
-		case token.AND:
-			stmt.(*ast.BinaryExpr).Op = token.OR
-			p.convertNot(stmt.(*ast.BinaryExpr).X)
-			p.convertNot(stmt.(*ast.BinaryExpr).Y)
-		case token.OR:
-			stmt.(*ast.BinaryExpr).Op = token.AND
-			p.convertNot(stmt.(*ast.BinaryExpr).X)
-			p.convertNot(stmt.(*ast.BinaryExpr).Y)
-		case token.EQL:
-			stmt.(*ast.BinaryExpr).Op = token.NEQ
-		case token.NEQ:
-			stmt.(*ast.BinaryExpr).Op = token.EQL
-		case token.GTR:
-			stmt.(*ast.BinaryExpr).Op = token.LEQ
-		case token.GEQ:
-			stmt.(*ast.BinaryExpr).Op = token.LSS
-		case token.LSS:
-			stmt.(*ast.BinaryExpr).Op = token.GEQ
-		case token.LEQ:
-			stmt.(*ast.BinaryExpr).Op = token.GTR
-		}
+func (p *Compiler) compileStmtAssign(w io.Writer, stmt *ast.AssignStmt) {
+	if op, ok := stmt.Op.AssignOp(); ok {
+		p.compileStmtOpAssign(w, stmt, op)
+		return
 	}
+	values, typeList := p.compileAssignValues(w, stmt)
+	p.assignValues(w, stmt, values, typeList)
 }
 
-func (p *Compiler) compileStmtCondTree(stmt ast.Expr) []ast.Expr {
-	var treeList []ast.Expr
-	switch stmt.(type) {
-	case *ast.BinaryExpr:
-		switch stmt.(*ast.BinaryExpr).Op {
-		case token.AND, token.OR:
-			treeList = append(treeList, p.compileStmtCondTree(stmt.(*ast.BinaryExpr).X)...)
-			treeList = append(treeList, p.compileStmtCondTree(stmt.(*ast.BinaryExpr).Y)...)
-		default:
-			treeList = append(treeList, stmt)
+// assignValues 把已经求值的 values 赋值给 stmt 左边的变量, := 时先声明新的变量
+func (p *Compiler) assignValues(w io.Writer, stmt *ast.AssignStmt, varNameList []string, typeList []types.Type) {
+	if stmt.Op == token.DEFINE {
+		// 已经声明过的变量没有记录在 Defs 中, 直接赋值
+		for _, target := range stmt.Target {
+			target := target.(*ast.Ident)
+			obj := p.info.Defs[target]
+			if obj == nil {
+				continue
+			}
+			var mangledName = fmt.Sprintf("%%local_%s.pos.%d", target.Name, target.NamePos)
+			p.allocLocal(w, obj, mangledName, obj.Type)
 		}
-	case *ast.UnaryExpr:
-		treeList = append(treeList, p.compileStmtCondTree(stmt.(*ast.UnaryExpr).X)...)
 	}
-	return treeList
-}
 
-func (p *Compiler) findFLag(stmt ast.Expr, fOp token.TokenType) []bool {
-	var flags []bool
-	switch stmt.(type) {
-	case *ast.BinaryExpr:
-		switch stmt.(*ast.BinaryExpr).Op {
-		case token.AND, token.OR:
-			flags = append(flags, p.findFLag(stmt.(*ast.BinaryExpr).X, stmt.(*ast.BinaryExpr).Op)...)
-			flags = append(flags, p.findFLag(stmt.(*ast.BinaryExpr).Y, token.IDENT)...)
-		default:
-			switch fOp {
-			case token.AND:
-				flags = append(flags, true)
-			case token.OR:
-				flags = append(flags, false)
-			case token.IDENT:
-				flags = append(flags, false)
-			}
+	for i, target := range stmt.Target {
+		// 赋值给 _ 的值只求值不保存
+		if isBlank(target) {
+			continue
 		}
-	case *ast.UnaryExpr:
-		flags = append(flags, p.findFLag(stmt.(*ast.UnaryExpr).X, stmt.(*ast.UnaryExpr).Op)...)
+		ptr := p.compileAddr(w, target)
+		targetType := p.exprType(target)
+		typ := llType(targetType)
+		value := p.convert(w, varNameList[i], typeList[i], targetType)
+		_, _ = fmt.Fprintf(w, "\tstore %s %s, %s* %s\n", typ, value, typ, ptr)
 	}
-	return flags
 }
 
 func (p *Compiler) compileStmtIf(w io.Writer, stmt *ast.IfStmt) {
-	defer p.restoreScope(p.scope)
-	p.enterScope()
-
 	ifPos := fmt.Sprintf("%d", p.posLine(stmt.If))
 	ifInit := p.genLabelId("if.init.line" + ifPos)
 	ifCond := p.genLabelId("if.cond.line" + ifPos)
@@ -449,90 +426,39 @@ func (p *Compiler) compileStmtIf(w io.Writer, stmt *ast.IfStmt) {
 
 	// if.init
 	_, _ = fmt.Fprintf(w, "\n%s:\n", ifInit)
-	func() {
-		defer p.restoreScope(p.scope)
-		p.enterScope()
-
-		if stmt.Init != nil {
-			p.compileStmt(w, stmt.Init)
-		}
-		_, _ = fmt.Fprintf(w, "\tbr label %%%s\n", ifCond)
-
-		//if.cond
-		{
-			_, _ = fmt.Fprintf(w, "\n%s:\n", ifCond)
-			condList := p.compileStmtCond(stmt.Cond)
-			valueList := p.compileStmtCondTree(stmt.Cond)
-			flags := p.findFLag(stmt.Cond, token.IDENT)
-			var ifFalse string
-			var ifTrue string
-			var jumpTo string
-			for i := 0; i < len(condList)-1; i++ {
-				condValue := p.compileExpr(w, valueList[i])
-				if stmt.Else != nil {
-					jumpTo = ifElse
-				} else {
-					jumpTo = ifEnd
-				}
-				if flags[i] {
-					ifTrue = condList[i+1]
-					ifFalse = jumpTo
-				} else {
-					ifTrue = ifBody
-					ifFalse = condList[i+1]
-				}
-				_, _ = fmt.Fprintf(w, "\tbr i1 %s, label %%%s, label %%%s\n", condValue, ifTrue, ifFalse)
-				_, _ = fmt.Fprintf(w, "\n%s:\n", condList[i+1])
-			}
-			condValue := p.compileExpr(w, valueList[len(condList)-1])
-			if stmt.Else != nil {
-				_, _ = fmt.Fprintf(w, "\tbr i1 %s, label %%%s, label %%%s\n", condValue, ifBody, ifElse)
-			} else {
-				_, _ = fmt.Fprintf(w, "\tbr i1 %s, label %%%s, label %%%s\n", condValue, ifBody, ifEnd)
-			}
-			//condValue := p.compileExpr(w, stmt.Cond)
-			//if stmt.Else != nil {
-			//	_, _ = fmt.Fprintf(w, "\tbr i1 %s, label %%%s, label %%%s\n", condValue, ifBody, ifElse)
-			//} else {
-			//	_, _ = fmt.Fprintf(w, "\tbr i1 %s, label %%%s, label %%%s\n", condValue, ifBody, ifEnd)
-			//}
-		}
+	if stmt.Init != nil {
+		p.compileStmt(w, stmt.Init)
+	}
+	_, _ = fmt.Fprintf(w, "\tbr label %%%s\n", ifCond)
 
-		// if.body
-		func() {
-			defer p.restoreScope(p.scope)
-			p.enterScope()
-
-			_, _ = fmt.Fprintf(w, "\n%s:\n", ifBody)
-			p.compileStmt(w, stmt.Body)
-			if stmt.Else != nil {
-				_, _ = fmt.Fprintf(w, "\tbr label %%%s\n", ifElse)
-			} else {
-				_, _ = fmt.Fprintf(w, "\tbr label %%%s\n", ifEnd)
-			}
-		}()
+	//if.cond
+	_, _ = fmt.Fprintf(w, "\n%s:\n", ifCond)
+	if stmt.Else != nil {
+		p.compileCond(w, stmt.Cond, ifBody, ifElse)
+	} else {
+		p.compileCond(w, stmt.Cond, ifBody, ifEnd)
+	}
 
-		// if.else
-		func() {
-			defer p.restoreScope(p.scope)
-			p.enterScope()
+	// if.body
+	_, _ = fmt.Fprintf(w, "\n%s:\n", ifBody)
+	p.compileStmt(w, stmt.Body)
+	_, _ = fmt.Fprintf(w, "\tbr label %%%s\n", ifEnd)
 
-			_, _ = fmt.Fprintf(w, "\n%s:\n", ifElse)
-			if stmt.Else != nil {
-				p.compileStmt(w, stmt.Else)
-			}
-			_, _ = fmt.Fprintf(w, "\tbr label %%%s\n", ifEnd)
-		}()
-	}()
+	// if.else
+	_, _ = fmt.Fprintf(w, "\n%s:\n", ifElse)
+	if stmt.Else != nil {
+		p.compileStmt(w, stmt.Else)
+	}
+	_, _ = fmt.Fprintf(w, "\tbr label %%%s\n", ifEnd)
 
 	// end
 	_, _ = fmt.Fprintf(w, "\n%s:\n", ifEnd)
 }
 
-func (p *Compiler) compileStmtFor(w io.Writer, stmt *ast.ForStmt) {
-	defer p.restoreScope(p.scope)
-	p.enterScope()
-
+// compileStmtFor 编译 for 语句, label 为 for 语句的标号.
+// 和 for range 一样, init 中 := 声明的变量每次迭代都是新的变量: 被闭包捕获或取地址的变量在每次迭代的
+// 开始分配新的堆内存并复制当前的值, 进入 for.post 时复制回 init 中分配的变量, 条件和 post 语句使用后者.
+func (p *Compiler) compileStmtFor(w io.Writer, stmt *ast.ForStmt, label string) {
 	forPos := fmt.Sprintf("%d", p.posLine(stmt.For))
 	forInit := p.genLabelId("for.init.line" + forPos)
 	forCond := p.genLabelId("for.cond.line" + forPos)
@@ -540,161 +466,129 @@ func (p *Compiler) compileStmtFor(w io.Writer, stmt *ast.ForStmt) {
 	forBody := p.genLabelId("for.body.line" + forPos)
 	forEnd := p.genLabelId("for.end.line" + forPos)
 
-	p.scope.Insert(&Object{
-		Name:        "for",
-		MangledName: forPost + " " + forEnd,
-		Type:        "for",
+	p.fn.branches = append(p.fn.branches, &branchTarget{
+		label:      label,
+		breakTo:    forEnd,
+		continueTo: forPost,
 	})
+	defer func() { p.fn.branches = p.fn.branches[:len(p.fn.branches)-1] }()
 
 	// br for.init
 	_, _ = fmt.Fprintf(w, "\tbr label %%%s\n", forInit)
 
 	// for.init
-	func() {
-		defer p.restoreScope(p.scope)
-		p.enterScope()
+	_, _ = fmt.Fprintf(w, "\n%s:\n", forInit)
+	if stmt.Init != nil {
+		p.compileStmt(w, stmt.Init)
+	}
+	_, _ = fmt.Fprintf(w, "\tbr label %%%s\n", forCond)
 
-		_, _ = fmt.Fprintf(w, "\n%s:\n", forInit)
-		if stmt.Init != nil {
-			p.compileStmt(w, stmt.Init)
-		}
-		_, _ = fmt.Fprintf(w, "\tbr label %%%s\n", forCond)
-
-		// for.cond
-		_, _ = fmt.Fprintf(w, "\n%s:\n", forCond)
-		if stmt.Cond != nil {
-			condValue := p.compileExpr(w, stmt.Cond)
-			_, _ = fmt.Fprintf(w, "\tbr i1 %s, label %%%s, label %%%s\n", condValue, forBody, forEnd)
-		} else {
-			_, _ = fmt.Fprintf(w, "\tbr label %%%s\n", forBody)
-		}
+	// for.cond
+	_, _ = fmt.Fprintf(w, "\n%s:\n", forCond)
+	if stmt.Cond != nil {
+		p.compileCond(w, stmt.Cond, forBody, forEnd)
+	} else {
+		_, _ = fmt.Fprintf(w, "\tbr label %%%s\n", forBody)
+	}
 
-		// for.body
-		func() {
-			defer p.restoreScope(p.scope)
-			p.enterScope()
-
-			_, _ = fmt.Fprintf(w, "\n%s:\n", forBody)
-			p.compileStmt(w, stmt.Body)
-			_, _ = fmt.Fprintf(w, "\tbr label %%%s\n", forPost)
-		}()
-
-		// for.post
-		{
-			_, _ = fmt.Fprintf(w, "\n%s:\n", forPost)
-			if stmt.Post != nil {
-				p.compileStmt(w, stmt.Post)
-			}
-			_, _ = fmt.Fprintf(w, "\tbr label %%%s\n", forCond)
-		}
-	}()
+	// for.body
+	_, _ = fmt.Fprintf(w, "\n%s:\n", forBody)
+	loopVars := p.loopVars(stmt)
+	outer := make([]string, len(loopVars))
+	for i, obj := range loopVars {
+		outer[i] = p.names[obj]
+		inner := p.heapAlloc(w, obj.Type)
+		p.copyVar(w, obj.Type, outer[i], inner)
+		p.names[obj] = inner
+	}
+	p.compileStmt(w, stmt.Body)
+	_, _ = fmt.Fprintf(w, "\tbr label %%%s\n", forPost)
+
+	// for.post
+	_, _ = fmt.Fprintf(w, "\n%s:\n", forPost)
+	for i, obj := range loopVars {
+		p.copyVar(w, obj.Type, p.names[obj], outer[i])
+		p.names[obj] = outer[i]
+	}
+	if stmt.Post != nil {
+		p.compileStmt(w, stmt.Post)
+	}
+	_, _ = fmt.Fprintf(w, "\tbr label %%%s\n", forCond)
 
 	//end
 	_, _ = fmt.Fprintf(w, "\n%s:\n", forEnd)
 }
 
+// loopVars 获取 for 语句的 init 中 := 声明的被闭包捕获或取地址的变量
+func (p *Compiler) loopVars(stmt *ast.ForStmt) []*types.Object {
+	init, ok := stmt.Init.(*ast.AssignStmt)
+	if !ok || init.Op != token.DEFINE {
+		return nil
+	}
+	var vars []*types.Object
+	for _, x := range init.Target {
+		if obj := p.info.Defs[x.(*ast.Ident)]; obj != nil && p.fn.escapes[obj] {
+			vars = append(vars, obj)
+		}
+	}
+	return vars
+}
+
+// copyVar 把 from 指向的 typ 类型的变量的值复制到 to
+func (p *Compiler) copyVar(w io.Writer, typ types.Type, from, to string) {
+	value := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = load %s, %s* %s, align %d\n", value, llType(typ), llType(typ), from, types.Alignof(typ))
+	_, _ = fmt.Fprintf(w, "\tstore %s %s, %s* %s, align %d\n", llType(typ), value, llType(typ), to, types.Alignof(typ))
+}
+
 func (p *Compiler) compileExpr(w io.Writer, expr ast.Expr) (localName string) {
+	// 常量表达式在编译期求值
+	if value, typ, ok := p.constExpr(expr); ok {
+		return p.compileConst(w, value, typ)
+	}
+
 	switch expr := expr.(type) {
 	case *ast.Ident:
-		var varName string
-		var typ string
-		var width int
-		if _, obj := p.scope.Lookup(expr.Name); obj != nil {
-			varName = obj.MangledName
-			typ = obj.Type
-			switch typ {
-			case "i32":
-				width = 4
-			case "i8":
-				width = 1
-			default:
-				width = 4
-			}
-		} else {
-			panic(fmt.Sprintf("var %s undefined", expr.Name))
+		obj := p.info.Uses[expr]
+		if obj.Kind == types.ObjNil {
+			// nil 的值由 convert 转换为目标类型的零值
+			return zeroValue(obj.Type)
+		}
+		if obj.Kind == types.ObjFunc {
+			return p.funcDeclValue(obj)
 		}
 
+		typ := llType(obj.Type)
 		localName = p.genId()
-		_, _ = fmt.Fprintf(w, "\t%s = load %s, %s* %s, align %d\n", localName, typ, typ, varName, width)
-		return localName
-
-	case *ast.Int:
-		localName = p.genId()
-		_, _ = fmt.Fprintf(w, "\t%s = %s i32 %v, %v\n", localName,
-			"add", `0`, expr.Value)
-		return localName
-
-	case *ast.Float:
-		localName = p.genId()
-		_, _ = fmt.Fprintf(w, "\t%s = %s float %v, 0x%v\n", localName,
-			"fadd", `0.000000e+00`, math.Float64bits(expr.Value))
+		_, _ = fmt.Fprintf(w, "\t%s = load %s, %s* %s, align %d\n", localName, typ, typ, p.objName(obj), types.Alignof(obj.Type))
 		return localName
 
-	case *ast.Char:
-		localName = p.genId()
-		_, _ = fmt.Fprintf(w, "\t%s = %s i8 %v, %v\n", localName, "add", `0`, expr.Value)
-		return localName
 	case *ast.BinaryExpr:
-		typ, flag := getType(expr)
-		if !flag {
-			if _, obj := p.scope.Lookup(typ); obj != nil {
-				typ = obj.Type
-			} else {
-				panic(fmt.Sprintf("var %s undefined", typ))
-			}
-		}
-		if typ == "i8" {
-			typ = "i32"
-		}
-		localName = p.genId()
-		op := opType(expr.Op, typ)
-		x := p.compileExpr(w, expr.X)
-		y := p.compileExpr(w, expr.Y)
-		typX := ""
-		typY := ""
-		switch expr.X.(type) {
-		case *ast.Ident:
-			if _, obj := p.scope.Lookup(expr.X.(*ast.Ident).Name); obj != nil {
-				typX = obj.Type
-				x = p.convert(w, x, typX, "i32")
-			} else {
-				panic(fmt.Sprintf("var %s undefined", typ))
-			}
-		case *ast.Char:
-			x = p.convert(w, x, "i8", "i32")
+		return p.compileExprBinary(w, expr)
+
+	case *ast.UnaryExpr:
+		if expr.Op == token.BIT_AND {
+			return p.compileAddrOf(w, expr)
 		}
-		switch expr.Y.(type) {
-		case *ast.Ident:
-			if _, obj := p.scope.Lookup(expr.Y.(*ast.Ident).Name); obj != nil {
-				typY = obj.Type
-				y = p.convert(w, y, typY, "i32")
-			} else {
-				panic(fmt.Sprintf("var %s undefined", typ))
-			}
-		case *ast.Char:
-			y = p.convert(w, y, "i8", "i32")
+		if expr.Op == token.ARROW {
+			value, _ := p.compileChanRecv(w, expr, false)
+			return value
 		}
-		_, _ = fmt.Fprintf(w, "\t%s = %s %s %v, %v\n", localName, op, typ, x, y)
-		if typX != "" {
-			localName = p.convert(w, localName, "i32", typX)
+		typ := p.exprType(expr)
+		if expr.Op == token.NOT {
+			localName = p.genId()
+			_, _ = fmt.Fprintf(w, "\t%s = xor i1 %s, true\n", localName, p.compileExpr(w, expr.X))
+			return localName
 		}
-
-		return localName
-
-	case *ast.UnaryExpr:
-		typ, _ := getType(expr)
-		op := opType(expr.Op, typ)
-		var zero string
-		switch typ {
-		case "i32":
-			zero = `0`
-		case "float":
-			zero = `0.000000e+00`
+		if expr.Op == token.XOR {
+			localName = p.genId()
+			_, _ = fmt.Fprintf(w, "\t%s = xor %s %s, -1\n", localName, llType(typ), p.compileExpr(w, expr.X))
+			return localName
 		}
 		if expr.Op == token.SUB {
 			localName = p.genId()
 			_, _ = fmt.Fprintf(w, "\t%s = %s %s %v, %v\n",
-				localName, op, typ, zero, p.compileExpr(w, expr.X))
+				localName, opType(expr.Op, typ), llType(typ), zeroValue(typ), p.compileExpr(w, expr.X))
 			return localName
 		}
 		return p.compileExpr(w, expr.X)
@@ -702,108 +596,150 @@ func (p *Compiler) compileExpr(w io.Writer, expr ast.Expr) (localName string) {
 	case *ast.ParenExpr:
 		return p.compileExpr(w, expr.X)
 
-	case *ast.CallExpr:
-		var fnName string
-		var fnType string
-		var paramsType []string
-		if expr.Pkg != nil {
-			if _, obj := p.scope.Lookup(expr.Pkg.Name); obj != nil {
-				fnName = obj.MangledName + "_" + expr.FuncName.Name
-				fnType = obj.Type
-				if obj.Node != nil {
-					//node := obj.Node.(*ast.FuncDecl).Type.Params.List
-					//for _, typ := range node {
-					//	paramsType = append(paramsType, typ.Type.Type)
-					//}
-					paramsType = append(paramsType, "i32")
-				}
-			} else {
-				panic(fmt.Sprintf("func %s.%s undefined", expr.Pkg.Name, expr.FuncName.Name))
-			}
-		} else if _, obj := p.scope.Lookup(expr.FuncName.Name); obj != nil {
-			fnName = obj.MangledName
-			fnType = obj.Type
-			if obj.Node != nil {
-				node := obj.Node.(*ast.FuncDecl).Type.Params.List
-				for _, typ := range node {
-					paramsType = append(paramsType, typ.Type.Type)
-				}
-			}
-		} else {
-			panic(fmt.Sprintf("func %s undefined", expr.FuncName.Name))
-		}
+	case *ast.StarExpr:
+		typ := p.exprType(expr)
+		ptr := p.compileDeref(w, expr)
+		localName = p.genId()
+		_, _ = fmt.Fprintf(w, "\t%s = load %s, %s* %s, align %d\n", localName, llType(typ), llType(typ), ptr, types.Alignof(typ))
+		return localName
 
-		// test func
-		if fnType == "" {
-			fnType = "i32"
-		}
-		if len(paramsType) == 0 {
-			paramsType = append(paramsType, "i32")
-		}
+	case *ast.IndexExpr:
+		return p.compileExprIndex(w, expr)
 
-		localName = p.genId()
-		var localNames []string
-		for _, arg := range expr.Args {
-			localNames = append(localNames, p.compileExpr(w, arg))
-		}
-		_, _ = fmt.Fprintf(w, "\t%s = call %s(", localName, fnType)
-		first := true
-		for _, paramsType := range paramsType {
-			if first {
-				_, _ = fmt.Fprintf(w, "%s", paramsType)
-				first = false
-				continue
-			}
-			_, _ = fmt.Fprintf(w, ", %s", paramsType)
+	case *ast.SliceExpr:
+		return p.compileSliceExpr(w, expr)
+
+	case *ast.SelectorExpr:
+		return p.compileExprSelector(w, expr)
+
+	case *ast.CompositeLit:
+		return p.compileCompositeLit(w, expr)
+
+	case *ast.TypeAssertExpr:
+		value, _ := p.compileTypeAssert(w, expr, false)
+		return value
+
+	case *ast.FuncLit:
+		return p.compileFuncLit(w, expr)
+
+	case *ast.CallExpr:
+		if obj := p.calleeObject(expr); obj != nil && obj.Kind == types.ObjBuiltin {
+			return p.compileBuiltinCall(w, expr)
 		}
-		_, _ = fmt.Fprintf(w, ") %s(", fnName)
-		first = true
-		for i, paramType := range paramsType {
-			if first {
-				_, _ = fmt.Fprintf(w, "%s noundef %s", paramType, localNames[0])
-				first = false
-				continue
-			}
-			_, _ = fmt.Fprintf(w, ",%s noundef %s", paramType, localNames[i])
+		if p.isConversion(expr) {
+			return p.compileConversion(w, expr)
 		}
-		_, _ = fmt.Fprintf(w, ")\n")
-		//_, _ = fmt.Fprintf(w, "\t%s = call %s(i32) %s(i32 %v)\n",
-		//	localName, fnType, fnName, p.compileExpr(w, expr.Args[0]))
-		return localName
+		call := p.prepareCall(w, expr)
+		return p.emitCall(w, call.fnName, call.resultType, call.paramsType, call.args)
 
 	default:
 		panic(fmt.Sprintf("unknown: %[1]T, %[1]v", expr))
 	}
 }
 
+// callInfo 一次函数调用, 参数已经求值并转换为参数类型
+type callInfo struct {
+	fnName     string
+	resultType string
+	paramsType []string
+	args       []string
+}
+
+// lookupFunc 查找被调用的函数或方法, 返回函数名和函数类型
+// 调用函数值时函数名为空, 由 compileFuncValue 在运行时获取
+func (p *Compiler) lookupFunc(expr *ast.CallExpr) (fnName string, sig *types.Signature) {
+	if fn := p.funcValue(expr); fn != nil {
+		return "", p.funcValueType(expr, fn)
+	}
+	if sel := p.info.Selections[expr]; sel != nil {
+		return p.objName(sel.Obj), sel.Type.(*types.Signature)
+	}
+	// builtin 包中的函数对应 Universe 中同名的函数
+	obj := p.info.Uses[expr.FuncName]
+	return p.objName(obj), obj.Type.(*types.Signature)
+}
+
+// prepareCall 查找被调用的函数, 并计算调用参数
+func (p *Compiler) prepareCall(w io.Writer, expr *ast.CallExpr) *callInfo {
+	fnName, sig := p.lookupFunc(expr)
+
+	call := &callInfo{
+		fnName:     fnName,
+		resultType: resultType(sig),
+	}
+	if fn := p.funcValue(expr); fn != nil {
+		// 函数值的代码第一个参数为环境指针
+		code, env := p.compileFuncValue(w, expr, fn, sig)
+		call.fnName = code
+		call.paramsType = append(call.paramsType, "i8*")
+		call.args = append(call.args, env)
+	} else if sig.Recv != nil && types.IsInterface(sig.Recv) {
+		// 接口的方法通过 itab 动态调用, 接收者为数据指针
+		fn, data := p.compileIfaceMethod(w, p.methodRecv(expr), expr.FuncName, sig)
+		call.fnName = fn
+		call.paramsType = append(call.paramsType, "i8*")
+		call.args = append(call.args, data)
+	} else if sig.Recv != nil {
+		call.paramsType = append(call.paramsType, llType(sig.Recv))
+		call.args = append(call.args, p.compileRecv(w, p.methodRecv(expr), sig.Recv))
+	}
+	for i, arg := range p.compileArgs(w, expr, sig) {
+		call.paramsType = append(call.paramsType, llType(sig.Params[i]))
+		call.args = append(call.args, arg)
+	}
+	if printFuncs[fnName] {
+		p.lowerPrintArgs(w, call, sig)
+	}
+	p.genCallPos(w, expr.Pos())
+	return call
+}
+
+// emitCall 生成函数调用指令
+func (p *Compiler) emitCall(w io.Writer, fnName, fnType string, paramsType, args []string) (localName string) {
+	localName = p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = call %s(%s) %s(", localName, fnType, strings.Join(paramsType, ", "), fnName)
+	for i, paramType := range paramsType {
+		if i > 0 {
+			_, _ = fmt.Fprintf(w, ", ")
+		}
+		_, _ = fmt.Fprintf(w, "%s noundef %s", paramType, args[i])
+	}
+	_, _ = fmt.Fprintf(w, ")\n")
+	return localName
+}
+
 func (p *Compiler) genId() string {
 	id := fmt.Sprintf("%%t%d", p.nextId)
 	p.nextId++
 	return id
 }
 
-func (p *Compiler) convert(w io.Writer, localName, typ, NewTyp string) string {
-	if typ == NewTyp {
-		return ""
+// resultType 获取函数返回值的 LLVM 类型, 没有返回值的函数返回 i32 0
+func resultType(sig *types.Signature) string {
+	if sig.Result == nil {
+		return "i32"
 	}
-	emitName := p.genId()
-	var op string
-	switch NewTyp {
-	case "i32":
-		op = "sext"
-	case "i8":
-		op = "trunc"
-	}
-	_, _ = fmt.Fprintf(w, "\t%s = %s %s %s to %s\n", emitName, op, typ, localName, NewTyp)
-	return emitName
+	return llType(sig.Result)
+}
+
+// llFloat 浮点数常量, LLVM 要求 float 常量以 double 的十六进制形式表示
+func llFloat(v float64) string {
+	return fmt.Sprintf("0x%016X", math.Float64bits(float64(float32(v))))
 }
 
-func (p *Compiler) Compile(f *ast.File) string {
+// Compile 生成文件的 LLVM IR, info 为 types.Check 的结果, 文件必须已经通过类型检查
+func (p *Compiler) Compile(f *ast.File, info *types.Info) string {
 	var buf bytes.Buffer
 
+	p.file = f
+	p.info = info
 	p.genHeader(&buf, f)
 	p.compileFile(&buf, f)
 	p.genMain(&buf, f)
+	_, _ = buf.Write(p.funcLits.Bytes())
+	p.genStringItab(&buf)
+	p.genInterfaces(&buf)
+	p.genStrings(&buf)
 
 	return buf.String()
 }
@@ -0,0 +1,46 @@
+package compiler
+
+import (
+	"fmt"
+	"go/constant"
+	"io"
+	"math"
+	"tiny-go/ast"
+	"tiny-go/types"
+)
+
+// 常量表达式的值由类型检查用 go/constant 计算, 常量不分配存储空间.
+// 无类型常量的值是精确的, 能否用赋值和运算的类型表示已经由类型检查保证;
+// 无类型浮点常量在 LLVM 中用 double 的十六进制形式表示, 由 convert 转换为目标类型.
+
+// constExpr 获取常量表达式的值和类型, 表达式不是常量时 ok 为 false
+func (p *Compiler) constExpr(expr ast.Expr) (value constant.Value, typ types.Type, ok bool) {
+	tv := p.info.Types[expr]
+	return tv.Value, tv.Type, tv.Value != nil
+}
+
+// compileConst 生成常量在 LLVM 中的值
+func (p *Compiler) compileConst(w io.Writer, value constant.Value, typ types.Type) string {
+	switch {
+	case types.IsBoolean(typ):
+		return fmt.Sprint(constant.BoolVal(value))
+	case types.IsString(typ):
+		return p.compileStringLit(w, &ast.StringLit{Value: constant.StringVal(value)})
+	case types.IsFloat(typ):
+		f, _ := constant.Float64Val(constant.ToFloat(value))
+		if types.IsBasic(typ, types.Float) {
+			return llFloat(f)
+		}
+		return fmt.Sprintf("0x%016X", math.Float64bits(f))
+	}
+	v := constant.ToInt(value)
+	if i, ok := constant.Int64Val(v); ok {
+		if types.IsUntyped(typ) {
+			// 无类型整数常量保留精确的值, 由 convert 按目标类型截断
+			return fmt.Sprint(i)
+		}
+		return convertConst(i, typ)
+	}
+	u, _ := constant.Uint64Val(v)
+	return fmt.Sprint(int64(u))
+}
@@ -0,0 +1,114 @@
+package compiler
+
+import (
+	"fmt"
+	"go/constant"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"tiny-go/ast"
+	"tiny-go/types"
+)
+
+// 数值类型之间的转换: 整数按源类型是否有符号选择 sext 或 zext, 变窄时 trunc;
+// 整数和浮点数之间使用 sitofp/uitofp 和 fptosi/fptoui; 浮点数之间使用 fpext 和 fptrunc.
+
+// convert 把 typ 类型的值转换为 newTyp 类型, 调用前已经检查过可以转换
+func (p *Compiler) convert(w io.Writer, localName string, typ, newTyp types.Type) string {
+	if types.Identical(typ, newTyp) {
+		return localName
+	}
+	if typ == types.Typ[types.UntypedNil] {
+		return zeroValue(newTyp)
+	}
+	if types.IsInterface(newTyp) {
+		return p.toIface(w, localName, typ, newTyp)
+	}
+	if types.IsBasic(typ, types.UntypedFloat) {
+		// 无类型浮点常量的值为 double 的十六进制形式, 见 compileConst
+		if bits, err := strconv.ParseUint(strings.TrimPrefix(localName, "0x"), 16, 64); err == nil {
+			return p.compileConst(w, constant.MakeFloat64(math.Float64frombits(bits)), newTyp)
+		}
+	}
+	if llType(typ) == llType(newTyp) {
+		return localName
+	}
+	if types.Identical(types.Underlying(typ), types.Underlying(newTyp)) {
+		// 底层类型相同的结构体在 LLVM 中是不同的命名类型, 通过内存重新解释
+		ptr := p.spill(w, localName, typ)
+		newPtr, emitName := p.genId(), p.genId()
+		_, _ = fmt.Fprintf(w, "\t%s = bitcast %s* %s to %s*\n", newPtr, llType(typ), ptr, llType(newTyp))
+		_, _ = fmt.Fprintf(w, "\t%s = load %s, %s* %s, align %d\n", emitName, llType(newTyp), llType(newTyp), newPtr, types.Alignof(newTyp))
+		return emitName
+	}
+	if types.IsInteger(typ) {
+		if v, err := strconv.ParseInt(localName, 10, 64); err == nil {
+			return convertConst(v, newTyp)
+		}
+	}
+
+	var op string
+	switch {
+	case types.IsInteger(typ) && types.IsInteger(newTyp):
+		switch {
+		case types.Sizeof(typ) > types.Sizeof(newTyp):
+			op = "trunc"
+		case types.IsUnsigned(typ):
+			op = "zext"
+		default:
+			op = "sext"
+		}
+	case types.IsInteger(typ) && types.IsFloat(newTyp):
+		op = "sitofp"
+		if types.IsUnsigned(typ) {
+			op = "uitofp"
+		}
+	case types.IsFloat(typ) && types.IsInteger(newTyp):
+		op = "fptosi"
+		if types.IsUnsigned(newTyp) {
+			op = "fptoui"
+		}
+	case types.IsFloat(typ) && types.IsFloat(newTyp):
+		op = "fpext"
+		if types.Sizeof(typ) > types.Sizeof(newTyp) {
+			op = "fptrunc"
+		}
+	default:
+		panic(fmt.Sprintf("cannot convert %v to %v", typ, newTyp))
+	}
+
+	emitName := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = %s %s %s to %s\n", emitName, op, llType(typ), localName, llType(newTyp))
+	return emitName
+}
+
+// convertConst 把整数常量转换为 typ 类型的常量
+func convertConst(v int64, typ types.Type) string {
+	switch {
+	case types.IsBasic(typ, types.Float):
+		return llFloat(float64(v))
+	case types.IsBasic(typ, types.Float64):
+		return fmt.Sprintf("0x%016X", math.Float64bits(float64(v)))
+	}
+	// 按目标类型的位数截断, LLVM 中的整数常量用有符号数表示
+	bits := uint(types.Sizeof(typ) * 8)
+	if bits < 64 {
+		v = v << (64 - bits) >> (64 - bits)
+	}
+	return fmt.Sprint(v)
+}
+
+// isConversion 判断调用是否为类型转换 T(x)
+func (p *Compiler) isConversion(expr *ast.CallExpr) bool {
+	if expr.Fun != nil {
+		return p.info.Types[expr.Fun].IsType()
+	}
+	obj := p.calleeObject(expr)
+	return obj != nil && obj.Kind == types.ObjType
+}
+
+func (p *Compiler) compileConversion(w io.Writer, expr *ast.CallExpr) string {
+	x := expr.Args[0]
+	return p.convert(w, p.compileExpr(w, x), p.exprType(x), p.exprType(expr))
+}
@@ -0,0 +1,136 @@
+package compiler
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"tiny-go/ast"
+	"tiny-go/types"
+)
+
+// deferCall 一条 defer 语句对应的延迟调用.
+//
+// 执行 defer 语句时在堆上分配一个帧 { next, id, args... }, 参数在此时求值并保存,
+// 帧挂在函数的 %defer.head 链表头部; 函数返回前按链表顺序(即 LIFO)逐个取出并调用.
+// panic 时 longjmp 恢复入口处的栈指针, 函数体中 alloca 的内存会被覆盖, 所以帧不能分配在栈上.
+type deferCall struct {
+	*callInfo
+	frameType string
+	dynamic   bool // 接口方法的函数指针在运行时获取, 保存在帧的最后
+}
+
+// deferHeader 所有 defer 帧共有的头部: 下一个帧和 defer 编号
+const deferHeader = "{ i8*, i32 }"
+
+func (p *Compiler) compileStmtDefer(w io.Writer, stmt *ast.DeferStmt) {
+	var call *callInfo
+	if obj := p.calleeObject(stmt.Call); obj != nil && obj.Kind == types.ObjBuiltin {
+		call = p.prepareBuiltinCall(w, stmt.Call)
+	} else {
+		call = p.prepareCall(w, stmt.Call)
+	}
+
+	fields := append([]string{"i8*", "i32"}, call.paramsType...)
+	dynamic := strings.HasPrefix(call.fnName, "%")
+	if dynamic {
+		fields = append(fields, call.fnType())
+	}
+	d := &deferCall{
+		callInfo:  call,
+		frameType: "{ " + strings.Join(fields, ", ") + " }",
+		dynamic:   dynamic,
+	}
+	id := len(p.fn.defers)
+	p.fn.defers = append(p.fn.defers, d)
+
+	raw := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = call i8* @tiny_go_builtin_alloc(i32 %s)\n", raw, llTypeSize(d.frameType))
+	frame := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = bitcast i8* %s to %s*\n", frame, raw, d.frameType)
+
+	head := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = load i8*, i8** %%defer.head, align 8\n", head)
+	p.storeField(w, d.frameType, frame, 0, "i8*", head)
+	p.storeField(w, d.frameType, frame, 1, "i32", fmt.Sprint(id))
+	for i, arg := range call.args {
+		p.storeField(w, d.frameType, frame, i+2, call.paramsType[i], arg)
+	}
+	if dynamic {
+		p.storeField(w, d.frameType, frame, len(fields)-1, call.fnType(), call.fnName)
+	}
+
+	framePtr := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = bitcast %s* %s to i8*\n", framePtr, d.frameType, frame)
+	_, _ = fmt.Fprintf(w, "\tstore i8* %s, i8** %%defer.head, align 8\n", framePtr)
+}
+
+// genDeferRun 在函数返回前依次弹出 defer 链表并执行延迟调用
+func (p *Compiler) genDeferRun(w io.Writer) {
+	if len(p.fn.defers) == 0 {
+		return
+	}
+
+	_, _ = fmt.Fprintf(w, "\tbr label %%defer.run\n")
+
+	// defer.run
+	_, _ = fmt.Fprintf(w, "\ndefer.run:\n")
+	head := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = load i8*, i8** %%defer.head, align 8\n", head)
+	isNil := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = icmp eq i8* %s, null\n", isNil, head)
+	_, _ = fmt.Fprintf(w, "\tbr i1 %s, label %%defer.done, label %%defer.pop\n", isNil)
+
+	// defer.pop
+	_, _ = fmt.Fprintf(w, "\ndefer.pop:\n")
+	header := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = bitcast i8* %s to %s*\n", header, head, deferHeader)
+	next := p.loadField(w, deferHeader, header, 0, "i8*")
+	_, _ = fmt.Fprintf(w, "\tstore i8* %s, i8** %%defer.head, align 8\n", next)
+	id := p.loadField(w, deferHeader, header, 1, "i32")
+	_, _ = fmt.Fprintf(w, "\tswitch i32 %s, label %%defer.done [", id)
+	for i := range p.fn.defers {
+		_, _ = fmt.Fprintf(w, " i32 %d, label %%defer.call.%d", i, i)
+	}
+	_, _ = fmt.Fprintf(w, " ]\n")
+
+	// defer.call.N
+	for i, d := range p.fn.defers {
+		_, _ = fmt.Fprintf(w, "\ndefer.call.%d:\n", i)
+		frame := p.genId()
+		_, _ = fmt.Fprintf(w, "\t%s = bitcast i8* %s to %s*\n", frame, head, d.frameType)
+		var args []string
+		for j, typ := range d.paramsType {
+			args = append(args, p.loadField(w, d.frameType, frame, j+2, typ))
+		}
+		fnName := d.fnName
+		if d.dynamic {
+			fnName = p.loadField(w, d.frameType, frame, len(d.paramsType)+2, d.fnType())
+		}
+		p.emitCall(w, fnName, d.resultType, d.paramsType, args)
+		_, _ = fmt.Fprintf(w, "\tbr label %%defer.run\n")
+	}
+
+	// defer.done
+	_, _ = fmt.Fprintf(w, "\ndefer.done:\n")
+}
+
+// fnType 被调用函数的指针类型
+func (c *callInfo) fnType() string {
+	return fmt.Sprintf("%s (%s)*", c.resultType, strings.Join(c.paramsType, ", "))
+}
+
+// storeField 保存值到结构体指针 ptr 的第 i 个字段
+func (p *Compiler) storeField(w io.Writer, structType, ptr string, i int, typ, value string) {
+	fieldPtr := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = getelementptr inbounds %s, %s* %s, i32 0, i32 %d\n", fieldPtr, structType, structType, ptr, i)
+	_, _ = fmt.Fprintf(w, "\tstore %s %s, %s* %s\n", typ, value, typ, fieldPtr)
+}
+
+// loadField 读取结构体指针 ptr 的第 i 个字段
+func (p *Compiler) loadField(w io.Writer, structType, ptr string, i int, typ string) string {
+	fieldPtr := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = getelementptr inbounds %s, %s* %s, i32 0, i32 %d\n", fieldPtr, structType, structType, ptr, i)
+	value := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = load %s, %s* %s\n", value, typ, typ, fieldPtr)
+	return value
+}
@@ -0,0 +1,67 @@
+package compiler
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"tiny-go/ast"
+	"tiny-go/types"
+)
+
+// go 语句: 和 defer 一样, 函数和参数在当前 goroutine 中求值, 保存在堆上的帧 { args..., fn } 中,
+// 函数指针只在调用函数值和接口方法时保存. 新的 goroutine 从入口函数 <函数名>.goN 开始执行,
+// 入口函数从帧中取出参数后调用函数, 结果被丢弃.
+
+func (p *Compiler) compileStmtGo(w io.Writer, stmt *ast.GoStmt) {
+	var call *callInfo
+	if obj := p.calleeObject(stmt.Call); obj != nil && obj.Kind == types.ObjBuiltin {
+		call = p.prepareBuiltinCall(w, stmt.Call)
+	} else {
+		call = p.prepareCall(w, stmt.Call)
+	}
+
+	fields := append([]string{}, call.paramsType...)
+	dynamic := strings.HasPrefix(call.fnName, "%")
+	if dynamic {
+		fields = append(fields, call.fnType())
+	}
+	frameType := "{}"
+	if len(fields) > 0 {
+		frameType = "{ " + strings.Join(fields, ", ") + " }"
+	}
+
+	raw := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = call i8* @tiny_go_builtin_alloc(i32 %s)\n", raw, llTypeSize(frameType))
+	frame := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = bitcast i8* %s to %s*\n", frame, raw, frameType)
+	for i, arg := range call.args {
+		p.storeField(w, frameType, frame, i, call.paramsType[i], arg)
+	}
+	if dynamic {
+		p.storeField(w, frameType, frame, len(fields)-1, call.fnType(), call.fnName)
+	}
+
+	p.fn.goStmts++
+	entry := fmt.Sprintf("%s.go%d", p.fn.name, p.fn.goStmts)
+	p.genGoEntry(entry, call, frameType, dynamic)
+	_, _ = fmt.Fprintf(w, "\tcall i32 @tiny_go_builtin_go(i32 (i8*)* %s, i8* %s)\n", entry, raw)
+}
+
+// genGoEntry 生成 goroutine 的入口函数, 从帧中取出参数并调用函数
+func (p *Compiler) genGoEntry(entry string, call *callInfo, frameType string, dynamic bool) {
+	w := &p.funcLits
+	_, _ = fmt.Fprintf(w, "\ndefine private i32 %s(i8* %%frame) {\n", entry)
+	frame := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = bitcast i8* %%frame to %s*\n", frame, frameType)
+	var args []string
+	for i, typ := range call.paramsType {
+		args = append(args, p.loadField(w, frameType, frame, i, typ))
+	}
+	fnName := call.fnName
+	if dynamic {
+		fnName = p.loadField(w, frameType, frame, len(call.paramsType), call.fnType())
+	}
+	p.emitCall(w, fnName, call.resultType, call.paramsType, args)
+	_, _ = fmt.Fprintf(w, "\tret i32 0\n")
+	_, _ = fmt.Fprintf(w, "}\n")
+}
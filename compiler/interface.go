@@ -0,0 +1,365 @@
+package compiler
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"tiny-go/ast"
+	"tiny-go/token"
+	"tiny-go/types"
+)
+
+// 接口的值在 LLVM 中表示为 { i8*, i8* }, 即 itab 和数据指针, nil 接口的 itab 为 null.
+//
+// itab 是只读的全局数组 [n x i8*], 第 0 个元素为动态类型的类型描述符 %tiny_go_type,
+// 后面是按方法名排序的方法, 接口的方法调用通过 itab 中的函数指针完成.
+// 方法以包装函数 <方法名>.iface(i8* data, ...) 的形式保存, 值接收者的方法由包装函数解引用数据指针.
+//
+// 指针类型的值直接作为数据指针, 其他类型的值复制到堆上. 类型描述符在模块中唯一,
+// 类型断言通过比较描述符的地址完成; 接口之间的转换调用 @tiny_go_itab_lookup.N,
+// 在所有转换为过接口的动态类型中查找实现了目标接口的类型.
+
+// ifaceType LLVM 中类型描述符的类型, 和 builtin 中的 tiny_go_type 一致
+const ifaceType = "%tiny_go_type"
+
+// itabInfo 动态类型 typ 实现接口 iface 的 itab
+type itabInfo struct {
+	name  string
+	typ   types.Type
+	iface *types.Interface
+}
+
+// typeDesc 获取类型描述符的指针常量表达式, 每个类型只生成一个描述符
+func (p *Compiler) typeDesc(typ types.Type) string {
+	key := typ.String()
+	i, ok := p.typesIdx[key]
+	if !ok {
+		i = len(p.types)
+		p.types = append(p.types, typ)
+		p.typesIdx[key] = i
+	}
+	return fmt.Sprintf("bitcast (%s* @tiny_go_type.%d to i8*)", ifaceType, i)
+}
+
+// itab 获取动态类型 typ 实现接口 iface 的 itab 的指针常量表达式
+func (p *Compiler) itab(typ types.Type, iface *types.Interface) string {
+	p.typeDesc(typ)
+	key := typ.String() + "|" + iface.String()
+	name, ok := p.itabsIdx[key]
+	if !ok {
+		name = fmt.Sprintf("@tiny_go_itab.%d", len(p.itabs))
+		p.itabs = append(p.itabs, &itabInfo{name: name, typ: typ, iface: iface})
+		p.itabsIdx[key] = name
+	}
+	n := len(iface.Methods) + 1
+	return fmt.Sprintf("bitcast ([%d x i8*]* %s to i8*)", n, name)
+}
+
+// itabLookup 获取在运行时查找 iface 的 itab 的函数名
+func (p *Compiler) itabLookup(iface *types.Interface) string {
+	key := iface.String()
+	for i, x := range p.lookups {
+		if x.String() == key {
+			return fmt.Sprintf("@tiny_go_itab_lookup.%d", i)
+		}
+	}
+	p.lookups = append(p.lookups, iface)
+	return fmt.Sprintf("@tiny_go_itab_lookup.%d", len(p.lookups)-1)
+}
+
+// ifaceParts 获取接口值的 itab 和数据指针
+func (p *Compiler) ifaceParts(w io.Writer, value string) (itab, data string) {
+	itab, data = p.genId(), p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = extractvalue { i8*, i8* } %s, 0\n", itab, value)
+	_, _ = fmt.Fprintf(w, "\t%s = extractvalue { i8*, i8* } %s, 1\n", data, value)
+	return itab, data
+}
+
+// makeIface 由 itab 和数据指针构造接口值
+func (p *Compiler) makeIface(w io.Writer, itab, data string) string {
+	withItab := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = insertvalue { i8*, i8* } undef, i8* %s, 0\n", withItab, itab)
+	localName := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = insertvalue { i8*, i8* } %s, i8* %s, 1\n", localName, withItab, data)
+	return localName
+}
+
+// dynamicType 获取接口值的动态类型描述符, nil 接口为 null
+func (p *Compiler) dynamicType(w io.Writer, itab string) string {
+	localName := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = call i8* @tiny_go_builtin_iface_type(i8* %s)\n", localName, itab)
+	return localName
+}
+
+// toIface 把 typ 类型的值转换为接口类型 iface, 调用前已经检查过 typ 实现了接口
+func (p *Compiler) toIface(w io.Writer, value string, typ, ifaceTyp types.Type) string {
+	iface := types.Underlying(ifaceTyp).(*types.Interface)
+	if types.IsUntyped(typ) {
+		value, typ = p.convert(w, value, typ, types.Default(typ)), types.Default(typ)
+	}
+
+	// 接口之间的转换在运行时查找新的 itab
+	if types.IsInterface(typ) {
+		if types.Identical(types.Underlying(typ), iface) {
+			return value
+		}
+		itab, data := p.ifaceParts(w, value)
+		newItab := p.genId()
+		_, _ = fmt.Fprintf(w, "\t%s = call i8* %s(i8* %s)\n", newItab, p.itabLookup(iface), p.dynamicType(w, itab))
+		return p.makeIface(w, newItab, data)
+	}
+
+	data := p.genId()
+	if types.IsPointer(typ) {
+		_, _ = fmt.Fprintf(w, "\t%s = bitcast %s %s to i8*\n", data, llType(typ), value)
+	} else {
+		ptr := p.heapAlloc(w, typ)
+		_, _ = fmt.Fprintf(w, "\tstore %s %s, %s* %s\n", llType(typ), value, llType(typ), ptr)
+		_, _ = fmt.Fprintf(w, "\t%s = bitcast %s* %s to i8*\n", data, llType(typ), ptr)
+	}
+	return p.makeIface(w, p.itab(typ, iface), data)
+}
+
+// fromIface 从接口的数据指针中取出 typ 类型的值
+func (p *Compiler) fromIface(w io.Writer, data string, typ types.Type) string {
+	ptr := p.genId()
+	if types.IsPointer(typ) {
+		_, _ = fmt.Fprintf(w, "\t%s = bitcast i8* %s to %s\n", ptr, data, llType(typ))
+		return ptr
+	}
+	_, _ = fmt.Fprintf(w, "\t%s = bitcast i8* %s to %s*\n", ptr, data, llType(typ))
+	localName := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = load %s, %s* %s, align %d\n", localName, llType(typ), llType(typ), ptr, types.Alignof(typ))
+	return localName
+}
+
+// compileIfaceMethod 通过 itab 获取接口方法的函数指针, 返回函数指针和接收者的数据指针
+func (p *Compiler) compileIfaceMethod(w io.Writer, recv ast.Expr, sel *ast.Ident, sig *types.Signature) (fn, data string) {
+	iface := types.Underlying(sig.Recv).(*types.Interface)
+	_, index := iface.Method(sel.Name)
+	itab, data := p.ifaceParts(w, p.compileExpr(w, recv))
+	p.genNilCheck(w, sel.NamePos, itab, &types.Pointer{Elem: types.Typ[types.Int8]})
+
+	methods := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = bitcast i8* %s to i8**\n", methods, itab)
+	slot := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = getelementptr inbounds i8*, i8** %s, i32 %d\n", slot, methods, index+1)
+	ptr := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = load i8*, i8** %s\n", ptr, slot)
+	fn = p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = bitcast i8* %s to %s\n", fn, ptr, envFuncType(sig))
+	return fn, data
+}
+
+// envFuncType 接口方法的包装函数和闭包的函数指针类型, 第一个参数为数据指针或环境指针
+func envFuncType(sig *types.Signature) string {
+	params := []string{"i8*"}
+	for _, param := range sig.Params {
+		params = append(params, llType(param))
+	}
+	return fmt.Sprintf("%s (%s)*", resultType(sig), strings.Join(params, ", "))
+}
+
+// compileTypeAssert 编译类型断言 x.(T). commaOk 为 true 时断言失败返回零值和 false,
+// 否则断言失败时 panic
+func (p *Compiler) compileTypeAssert(w io.Writer, expr *ast.TypeAssertExpr, commaOk bool) (value, ok string) {
+	typ := p.exprType(expr.Type)
+	xTyp := p.exprType(expr.X)
+
+	line := fmt.Sprintf("%d", p.posLine(expr.Lparen))
+	assertCheck := p.genLabelId("assert.check.line" + line)
+	assertOk := p.genLabelId("assert.ok.line" + line)
+	assertFail := p.genLabelId("assert.fail.line" + line)
+	assertEnd := p.genLabelId("assert.end.line" + line)
+
+	_, _ = fmt.Fprintf(w, "\tbr label %%%s\n", assertCheck)
+	_, _ = fmt.Fprintf(w, "\n%s:\n", assertCheck)
+	itab, data := p.ifaceParts(w, p.compileExpr(w, expr.X))
+	dynType := p.dynamicType(w, itab)
+
+	ok = p.genId()
+	var newItab string
+	if iface, isIface := types.Underlying(typ).(*types.Interface); isIface {
+		newItab = p.genId()
+		_, _ = fmt.Fprintf(w, "\t%s = call i8* %s(i8* %s)\n", newItab, p.itabLookup(iface), dynType)
+		_, _ = fmt.Fprintf(w, "\t%s = icmp ne i8* %s, null\n", ok, newItab)
+	} else {
+		_, _ = fmt.Fprintf(w, "\t%s = icmp eq i8* %s, %s\n", ok, dynType, p.typeDesc(typ))
+	}
+	failTo := assertFail
+	if commaOk {
+		failTo = assertEnd
+	}
+	_, _ = fmt.Fprintf(w, "\tbr i1 %s, label %%%s, label %%%s\n", ok, assertOk, failTo)
+
+	if !commaOk {
+		_, _ = fmt.Fprintf(w, "\n%s:\n", assertFail)
+		pos, have, want := p.posString(expr.Lparen), xTyp.String(), typ.String()
+		_, _ = fmt.Fprintf(w, "\tcall void @tiny_go_builtin_panic_assert(i8* %s, i32 %d, i8* %s, i32 %d, i8* %s, i8* %s, i32 %d, i32 %d)\n",
+			p.stringConstPtr(pos), len(pos), p.stringConstPtr(have), len(have), dynType,
+			p.stringConstPtr(want), len(want), boolInt(types.IsInterface(typ)))
+		_, _ = fmt.Fprintf(w, "\tunreachable\n")
+	}
+
+	_, _ = fmt.Fprintf(w, "\n%s:\n", assertOk)
+	if newItab != "" {
+		value = p.makeIface(w, newItab, data)
+	} else {
+		value = p.fromIface(w, data, typ)
+	}
+	if !commaOk {
+		return value, ok
+	}
+	_, _ = fmt.Fprintf(w, "\tbr label %%%s\n", assertEnd)
+
+	_, _ = fmt.Fprintf(w, "\n%s:\n", assertEnd)
+	result := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = phi %s [ %s, %%%s ], [ %s, %%%s ]\n",
+		result, llType(typ), value, assertOk, zeroValue(typ), assertCheck)
+	return result, ok
+}
+
+// compileIfaceEqual 编译接口和接口或其他值的比较, 另一个操作数先转换为接口类型.
+// 动态类型相同时调用类型描述符中的比较函数, 动态类型不能比较时 panic
+func (p *Compiler) compileIfaceEqual(w io.Writer, expr *ast.BinaryExpr, xTyp, yTyp types.Type) string {
+	typ := xTyp
+	if !types.IsInterface(xTyp) {
+		typ = yTyp
+	}
+
+	x := p.convert(w, p.compileExpr(w, expr.X), xTyp, typ)
+	y := p.convert(w, p.compileExpr(w, expr.Y), yTyp, typ)
+	eq := p.genIfaceEqual(w, x, y, p.posString(expr.OpPos))
+	if expr.Op == token.NEQ {
+		localName := p.genId()
+		_, _ = fmt.Fprintf(w, "\t%s = xor i1 %s, true\n", localName, eq)
+		return localName
+	}
+	return eq
+}
+
+// genIfaceEqual 生成比较两个接口值的指令, 结果为 i1. pos 为比较发生的位置, 用于 panic 信息
+func (p *Compiler) genIfaceEqual(w io.Writer, x, y, pos string) string {
+	xItab, xData := p.ifaceParts(w, x)
+	yItab, yData := p.ifaceParts(w, y)
+	posPtr := "null"
+	if pos != "" {
+		posPtr = p.stringConstPtr(pos)
+	}
+	eq := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = call i32 @tiny_go_builtin_iface_equal(i8* %s, i8* %s, i8* %s, i8* %s, i8* %s, i32 %d)\n",
+		eq, xItab, xData, yItab, yData, posPtr, len(pos))
+	localName := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = icmp ne i32 %s, 0\n", localName, eq)
+	return localName
+}
+
+// genInterfaces 生成接口用到的 itab 查找函数, itab, 方法的包装函数和类型描述符.
+// 查找函数会生成新的 itab, itab 会登记新的类型, 所以按这个顺序生成
+func (p *Compiler) genInterfaces(w io.Writer) {
+	for i, iface := range p.lookups {
+		p.genItabLookup(w, i, iface)
+	}
+	wrappers := make(map[string]bool)
+	for _, itab := range p.itabs {
+		p.genItab(w, itab, wrappers)
+	}
+	for i, typ := range p.types {
+		p.genTypeDesc(w, i, typ)
+	}
+}
+
+// genItabLookup 生成根据动态类型描述符查找 iface 的 itab 的函数, 没有实现 iface 时返回 null
+func (p *Compiler) genItabLookup(w io.Writer, i int, iface *types.Interface) {
+	_, _ = fmt.Fprintf(w, "\ndefine private i8* @tiny_go_itab_lookup.%d(i8* %%type) {\n", i)
+	for _, typ := range p.types {
+		if m, _ := types.MissingMethod(typ, iface); m != nil {
+			continue
+		}
+		match := p.genId()
+		found := p.genLabelId("lookup.found")
+		next := p.genLabelId("lookup.next")
+		_, _ = fmt.Fprintf(w, "\t%s = icmp eq i8* %%type, %s\n", match, p.typeDesc(typ))
+		_, _ = fmt.Fprintf(w, "\tbr i1 %s, label %%%s, label %%%s\n", match, found, next)
+		_, _ = fmt.Fprintf(w, "\n%s:\n", found)
+		_, _ = fmt.Fprintf(w, "\tret i8* %s\n", p.itab(typ, iface))
+		_, _ = fmt.Fprintf(w, "\n%s:\n", next)
+	}
+	_, _ = fmt.Fprintf(w, "\tret i8* null\n")
+	_, _ = fmt.Fprintf(w, "}\n")
+}
+
+// genItab 生成 itab, 以及还没有生成过的方法包装函数
+func (p *Compiler) genItab(w io.Writer, itab *itabInfo, wrappers map[string]bool) {
+	entries := []string{p.typeDesc(itab.typ)}
+	for _, m := range itab.iface.Methods {
+		method, _ := types.MethodSetLookup(itab.typ, m.Name)
+		sig := method.Type.(*types.Signature)
+		wrapper := p.objName(method) + ".iface"
+		if !wrappers[wrapper] {
+			wrappers[wrapper] = true
+			p.genMethodWrapper(w, method, wrapper)
+		}
+		entries = append(entries, fmt.Sprintf("i8* bitcast (%s %s to i8*)", envFuncType(sig), wrapper))
+	}
+	entries[0] = "i8* " + entries[0]
+	_, _ = fmt.Fprintf(w, "%s = private constant [%d x i8*] [%s]\n", itab.name, len(entries), strings.Join(entries, ", "))
+}
+
+// genMethodWrapper 生成方法的包装函数, 把接口的数据指针转换为方法的接收者
+func (p *Compiler) genMethodWrapper(w io.Writer, method *types.Object, wrapper string) {
+	sig := method.Type.(*types.Signature)
+	named := recvBase(sig.Recv)
+	params := []string{"i8* %recv"}
+	args := make([]string, len(sig.Params)+1)
+	paramsType := []string{llType(sig.Recv)}
+	for i, param := range sig.Params {
+		args[i+1] = fmt.Sprintf("%%arg%d", i)
+		params = append(params, fmt.Sprintf("%s %s", llType(param), args[i+1]))
+		paramsType = append(paramsType, llType(param))
+	}
+
+	_, _ = fmt.Fprintf(w, "\ndefine private %s %s(%s) {\n", resultType(sig), wrapper, strings.Join(params, ", "))
+	ptr := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = bitcast i8* %%recv to %s*\n", ptr, llType(named))
+	args[0] = ptr
+	if !types.IsPointer(sig.Recv) {
+		// 值接收者的方法, 接口中保存的可能是 nil 指针
+		p.genNilCheck(w, method.Node.(*ast.FuncDecl).NamePos, ptr, &types.Pointer{Elem: named})
+		args[0] = p.genId()
+		_, _ = fmt.Fprintf(w, "\t%s = load %s, %s* %s, align %d\n", args[0], llType(named), llType(named), ptr, types.Alignof(named))
+	}
+	result := p.emitCall(w, p.objName(method), resultType(sig), paramsType, args)
+	_, _ = fmt.Fprintf(w, "\tret %s %s\n", resultType(sig), result)
+	_, _ = fmt.Fprintf(w, "}\n")
+}
+
+// genTypeDesc 生成类型描述符和比较两个数据指针指向的值的函数, 不能比较的类型没有比较函数
+func (p *Compiler) genTypeDesc(w io.Writer, i int, typ types.Type) {
+	equal := fmt.Sprintf("i32 (i8*, i8*)* @tiny_go_type.%d.equal", i)
+	if types.Comparable(typ) {
+		_, _ = fmt.Fprintf(w, "\ndefine private i32 @tiny_go_type.%d.equal(i8* %%x, i8* %%y) {\n", i)
+		// 指针类型的值就是数据指针, 直接比较
+		x, y, eqType := "%x", "%y", types.Type(&types.Pointer{Elem: types.Typ[types.Int8]})
+		if !types.IsPointer(typ) {
+			x, y, eqType = p.fromIface(w, x, typ), p.fromIface(w, y, typ), typ
+		}
+		eq := p.genEqual(w, eqType, x, y)
+		localName := p.genId()
+		_, _ = fmt.Fprintf(w, "\t%s = zext i1 %s to i32\n", localName, eq)
+		_, _ = fmt.Fprintf(w, "\tret i32 %s\n", localName)
+		_, _ = fmt.Fprintf(w, "}\n")
+	} else {
+		equal = "i32 (i8*, i8*)* null"
+	}
+	name := typ.String()
+	_, _ = fmt.Fprintf(w, "@tiny_go_type.%d = private constant %s { i8* %s, i32 %d, %s, i32 %d }\n",
+		i, ifaceType, p.stringConstPtr(name), len(name), equal, typeKind(typ))
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,33 @@
+package compiler
+
+import (
+	"fmt"
+	"io"
+	"tiny-go/ast"
+)
+
+// labelName 标号对应的 LLVM 基本块名字
+func labelName(name string) string {
+	return "label." + name
+}
+
+func (p *Compiler) compileStmtLabeled(w io.Writer, stmt *ast.LabeledStmt) {
+	name := labelName(stmt.Label.Name)
+	_, _ = fmt.Fprintf(w, "\tbr label %%%s\n", name)
+	_, _ = fmt.Fprintf(w, "\n%s:\n", name)
+	switch s := stmt.Stmt.(type) {
+	case nil:
+	case *ast.ForStmt:
+		p.compileStmtFor(w, s, stmt.Label.Name)
+	case *ast.RangeStmt:
+		p.compileStmtRange(w, s, stmt.Label.Name)
+	case *ast.SwitchStmt:
+		p.compileStmtSwitch(w, s, stmt.Label.Name)
+	case *ast.TypeSwitchStmt:
+		p.compileStmtTypeSwitch(w, s, stmt.Label.Name)
+	case *ast.SelectStmt:
+		p.compileStmtSelect(w, s, stmt.Label.Name)
+	default:
+		p.compileStmt(w, s)
+	}
+}
@@ -0,0 +1,145 @@
+package compiler
+
+import (
+	"fmt"
+	"io"
+	"tiny-go/ast"
+	"tiny-go/token"
+	"tiny-go/types"
+)
+
+// map 在 LLVM 中表示为 i8*, 即 builtin 运行时中哈希表的指针, nil map 为 null.
+// 键先保存到临时变量中再把地址传给运行时, 运行时按键的种类计算哈希和比较键.
+// 读取不存在的键得到值类型的零值; 赋值时运行时返回值的地址, 键不存在时先插入零值.
+
+// 键的种类, 和 builtin 运行时中的 TINY_GO_KEY_* 一致
+const (
+	mapKeyMem     = iota // 按字节比较, 如整数, 布尔值和指针
+	mapKeyString         // 比较字符串的内容
+	mapKeyFloat32        // 按浮点数的值比较
+	mapKeyFloat64
+)
+
+// mapKeyKind 获取键的种类, 运行时不支持的键类型 ok 为 false
+func mapKeyKind(key types.Type) (kind int, ok bool) {
+	switch t := types.Underlying(key).(type) {
+	case *types.Basic:
+		switch {
+		case types.IsString(t):
+			return mapKeyString, true
+		case types.IsBasic(t, types.Float):
+			return mapKeyFloat32, true
+		case types.IsBasic(t, types.Float64):
+			return mapKeyFloat64, true
+		}
+		return mapKeyMem, true
+	case *types.Pointer, *types.Chan:
+		return mapKeyMem, true
+	}
+	return 0, false
+}
+
+// genMakeMap 创建 map, hint 为预计的元素个数
+func (p *Compiler) genMakeMap(w io.Writer, typ *types.Map, hint string) string {
+	kind, _ := mapKeyKind(typ.Key)
+	localName := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = call i8* @tiny_go_builtin_map_make(i32 %s, i32 %s, i32 %d, i32 %s)\n",
+		localName, llSizeOf(typ.Key), llSizeOf(typ.Elem), kind, hint)
+	return localName
+}
+
+// compileMakeMap 编译 make(map[K]V) 和 make(map[K]V, hint)
+func (p *Compiler) compileMakeMap(w io.Writer, expr *ast.CallExpr, typ *types.Map) string {
+	hint := "0"
+	if len(expr.Args) == 2 {
+		hint = p.compileIndex(w, expr.Args[1])
+	}
+	return p.genMakeMap(w, typ, hint)
+}
+
+// compileMapLit 编译 map 的复合字面值 map[K]V{k: v, ...}
+func (p *Compiler) compileMapLit(w io.Writer, lit *ast.CompositeLit, typ *types.Map) string {
+	m := p.genMakeMap(w, typ, fmt.Sprint(len(lit.Elts)))
+	for _, elt := range lit.Elts {
+		kv := elt.(*ast.KeyValueExpr)
+		ptr := p.genMapAssign(w, m, p.compileMapKey(w, kv.Key, typ), typ, kv.Colon)
+		value := p.convert(w, p.compileExpr(w, kv.Value), p.exprType(kv.Value), typ.Elem)
+		_, _ = fmt.Fprintf(w, "\tstore %s %s, %s* %s\n", llType(typ.Elem), value, llType(typ.Elem), ptr)
+	}
+	return m
+}
+
+// compileMapKey 计算键的值并保存到临时变量中, 返回临时变量的地址
+func (p *Compiler) compileMapKey(w io.Writer, index ast.Expr, typ *types.Map) string {
+	keyTyp := p.exprType(index)
+	value := p.convert(w, p.compileExpr(w, index), keyTyp, typ.Key)
+	return p.spillRaw(w, value, typ.Key)
+}
+
+// compileMapIndex 编译 m[k], 键不存在时得到零值. commaOk 为 true 时还返回键是否存在
+func (p *Compiler) compileMapIndex(w io.Writer, expr *ast.IndexExpr, commaOk bool) (value, ok string) {
+	typ := types.Underlying(p.exprType(expr.X)).(*types.Map)
+	elemType := llType(typ.Elem)
+	m := p.compileExpr(w, expr.X)
+	key := p.compileMapKey(w, expr.Index, typ)
+
+	raw := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = call i8* @tiny_go_builtin_map_access(i8* %s, i8* %s)\n", raw, m, key)
+	ok = p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = icmp ne i8* %s, null\n", ok, raw)
+	hitLabel := p.genLabelId("map.hit")
+	missLabel := p.genLabelId("map.miss")
+	endLabel := p.genLabelId("map.end")
+	_, _ = fmt.Fprintf(w, "\tbr i1 %s, label %%%s, label %%%s\n", ok, hitLabel, missLabel)
+
+	_, _ = fmt.Fprintf(w, "\n%s:\n", hitLabel)
+	ptr := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = bitcast i8* %s to %s*\n", ptr, raw, elemType)
+	found := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = load %s, %s* %s, align %d\n", found, elemType, elemType, ptr, types.Alignof(typ.Elem))
+	_, _ = fmt.Fprintf(w, "\tbr label %%%s\n", endLabel)
+
+	_, _ = fmt.Fprintf(w, "\n%s:\n", missLabel)
+	_, _ = fmt.Fprintf(w, "\tbr label %%%s\n", endLabel)
+
+	_, _ = fmt.Fprintf(w, "\n%s:\n", endLabel)
+	value = p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = phi %s [ %s, %%%s ], [ %s, %%%s ]\n",
+		value, elemType, found, hitLabel, zeroValue(typ.Elem), missLabel)
+	if !commaOk {
+		return value, ""
+	}
+	return value, ok
+}
+
+// compileMapIndexAddr 计算赋值 m[k] = v 中值的地址, 键不存在时先插入零值
+func (p *Compiler) compileMapIndexAddr(w io.Writer, expr *ast.IndexExpr) string {
+	typ := types.Underlying(p.exprType(expr.X)).(*types.Map)
+	m := p.compileExpr(w, expr.X)
+	key := p.compileMapKey(w, expr.Index, typ)
+	return p.genMapAssign(w, m, key, typ, expr.Lbrack)
+}
+
+// genMapAssign 调用运行时获取键对应的值的地址, nil map 在运行时 panic
+func (p *Compiler) genMapAssign(w io.Writer, m, key string, typ *types.Map, pos token.Pos) string {
+	posStr := p.posString(pos)
+	raw := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = call i8* @tiny_go_builtin_map_assign(i8* %s, i8* %s, i8* %s, i32 %d)\n",
+		raw, m, key, p.stringConstPtr(posStr), len(posStr))
+	ptr := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = bitcast i8* %s to %s*\n", ptr, raw, llType(typ.Elem))
+	return ptr
+}
+
+// prepareDelete 计算 delete(m, k) 的 map 和键, 返回对运行时的调用, 也用于 defer delete(m, k)
+func (p *Compiler) prepareDelete(w io.Writer, expr *ast.CallExpr) *callInfo {
+	typ := types.Underlying(p.exprType(expr.Args[0])).(*types.Map)
+	m := p.compileExpr(w, expr.Args[0])
+	key := p.compileMapKey(w, expr.Args[1], typ)
+	return &callInfo{
+		fnName:     "@tiny_go_builtin_map_delete",
+		resultType: "i32",
+		paramsType: []string{"i8*", "i8*"},
+		args:       []string{m, key},
+	}
+}
@@ -0,0 +1,53 @@
+package compiler
+
+import (
+	"fmt"
+	"io"
+	"tiny-go/ast"
+	"tiny-go/types"
+)
+
+// 方法编译为普通函数, 接收者作为第一个参数, 函数名为 @tiny_go_<pkg>_<Type>.<Method>.
+// 调用 x.M() 时按方法的接收者类型自动取 x 的地址或解引用 x, 和 Go 一致.
+
+// recvBase 获取接收者类型 T 或 *T 中的命名类型 T, 不是命名类型时返回 nil
+func recvBase(typ types.Type) *types.Named {
+	if ptr, ok := typ.(*types.Pointer); ok {
+		typ = ptr.Elem
+	}
+	named, _ := typ.(*types.Named)
+	return named
+}
+
+// methodRecv 获取方法调用的接收者, 不是方法调用时返回 nil.
+// x.M() 和 pkg.fn() 的形式相同, 由 x 是否为包名区分
+func (p *Compiler) methodRecv(expr *ast.CallExpr) ast.Expr {
+	if expr.Recv != nil {
+		return expr.Recv
+	}
+	if expr.Pkg != nil && p.info.Uses[expr.Pkg].Kind != types.ObjPkg {
+		return expr.Pkg
+	}
+	return nil
+}
+
+// compileRecv 计算方法调用的接收者, 按方法的接收者类型自动取地址或解引用
+func (p *Compiler) compileRecv(w io.Writer, recv ast.Expr, recvType types.Type) string {
+	typ := p.exprType(recv)
+	_, wantPtr := recvType.(*types.Pointer)
+	_, isPtr := typ.(*types.Pointer)
+	switch {
+	case wantPtr == isPtr:
+		return p.compileExpr(w, recv)
+	case wantPtr:
+		// x.M() 是 (&x).M() 的简写
+		return p.compileAddr(w, recv)
+	default:
+		// p.M() 是 (*p).M() 的简写
+		ptr := p.compileDeref(w, &ast.StarExpr{Star: recv.Pos(), X: recv})
+		localName := p.genId()
+		_, _ = fmt.Fprintf(w, "\t%s = load %s, %s* %s, align %d\n",
+			localName, llType(recvType), llType(recvType), ptr, types.Alignof(recvType))
+		return localName
+	}
+}
@@ -0,0 +1,157 @@
+package compiler
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"tiny-go/ast"
+	"tiny-go/token"
+	"tiny-go/types"
+)
+
+// 每个函数在入口处把 %tiny_go_frame 压入当前 goroutine 的调用栈, 返回前弹出, 调用其他函数前
+// 在帧中记录调用的位置, panic 时运行时据此打印调用栈.
+//
+// 有 defer 的函数在入口处调用 setjmp, 把现场保存在帧中. panic 时运行时跳转回最近的有 defer 的函数,
+// setjmp 返回非 0, 函数直接执行延迟调用; 延迟调用中 recover 后函数正常返回, 否则在弹出帧时继续 panic.
+// 运行时错误也通过 panic 报告, panic 的值为 string 类型的错误信息.
+
+// 类型描述符中值的种类, 和 builtin 运行时中的 TINY_GO_KIND_* 一致
+const (
+	kindOther = iota
+	kindBool
+	kindInt8
+	kindInt16
+	kindInt32
+	kindInt64
+	kindUint8
+	kindUint16
+	kindUint32
+	kindUint64
+	kindFloat32
+	kindFloat64
+	kindString
+	kindPointer // 接口的数据就是指针本身
+
+	kindNamed = 0x100 // 命名类型的标记
+)
+
+// frameType LLVM 中调用栈上的一帧的类型, 和 builtin 中的 tiny_go_frame 一致
+const frameType = "%tiny_go_frame"
+
+// jmpBufSize 为 setjmp 的 jmp_buf 分配的字节数, 运行时检查各平台的 jmp_buf 不超过这个大小
+const jmpBufSize = 512
+
+// typeKind 获取类型描述符中值的种类
+func typeKind(typ types.Type) int {
+	kind := kindOther
+	if basic, ok := types.Underlying(typ).(*types.Basic); ok {
+		switch {
+		case types.IsBoolean(basic):
+			kind = kindBool
+		case types.IsString(basic):
+			kind = kindString
+		case types.IsFloat(basic):
+			kind = kindFloat32
+			if types.Sizeof(basic) == 8 {
+				kind = kindFloat64
+			}
+		case types.IsInteger(basic):
+			bits := map[int]int{1: 0, 2: 1, 4: 2, 8: 3}[types.Sizeof(basic)]
+			kind = kindInt8 + bits
+			if types.IsUnsigned(basic) {
+				kind = kindUint8 + bits
+			}
+		}
+	}
+	if types.IsPointer(typ) {
+		kind = kindPointer
+	}
+	if _, ok := typ.(*types.Named); ok && kind != kindOther {
+		kind |= kindNamed
+	}
+	return kind
+}
+
+// funcDisplayName 调用栈中显示的函数名, 如 @tiny_go_main_f.func1 显示为 main.f.func1
+func (p *Compiler) funcDisplayName(name string) string {
+	pkg := p.file.Pkg.Name
+	return pkg + "." + strings.TrimPrefix(name, fmt.Sprintf("@tiny_go_%s_", pkg))
+}
+
+// genFramePush 在函数入口处压入调用栈的帧. 函数有 defer 时调用 setjmp, panic 时跳转到 defer.run
+func (p *Compiler) genFramePush(w io.Writer, hasDefer bool) {
+	_, _ = fmt.Fprintf(w, "\t%%func.frame = alloca %s, align 8\n", frameType)
+	jmp := "null"
+	if hasDefer {
+		buf := p.genId()
+		_, _ = fmt.Fprintf(w, "\t%s = alloca [%d x i8], align 16\n", buf, jmpBufSize)
+		jmp = p.genId()
+		_, _ = fmt.Fprintf(w, "\t%s = bitcast [%d x i8]* %s to i8*\n", jmp, jmpBufSize, buf)
+	}
+	name := p.funcDisplayName(p.fn.name)
+	_, _ = fmt.Fprintf(w, "\tcall void @tiny_go_builtin_frame_push(%s* %%func.frame, i8* %s, i32 %d, i8* %s)\n",
+		frameType, p.stringConstPtr(name), len(name), jmp)
+	if !hasDefer {
+		return
+	}
+	ret := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = call i32 @_setjmp(i8* %s)\n", ret, jmp)
+	panicking := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = icmp ne i32 %s, 0\n", panicking, ret)
+	_, _ = fmt.Fprintf(w, "\tbr i1 %s, label %%defer.run, label %%func.body\n", panicking)
+	_, _ = fmt.Fprintf(w, "\nfunc.body:\n")
+}
+
+// genFramePop 在函数返回前弹出调用栈的帧, 没有被 recover 的 panic 在这里继续
+func (p *Compiler) genFramePop(w io.Writer) {
+	_, _ = fmt.Fprintf(w, "\tcall void @tiny_go_builtin_frame_pop(%s* %%func.frame)\n", frameType)
+}
+
+// genCallPos 在帧中记录当前调用的位置
+func (p *Compiler) genCallPos(w io.Writer, pos token.Pos) {
+	if p.fn == nil {
+		return
+	}
+	posStr := p.posString(pos)
+	p.storeField(w, frameType, "%func.frame", 3, "i8*", p.stringConstPtr(posStr))
+	p.storeField(w, frameType, "%func.frame", 4, "i32", fmt.Sprint(len(posStr)))
+}
+
+// preparePanic 把 panic(v) 的参数转换为 interface{}, 返回对运行时的调用, 也用于 defer 和 go 语句
+func (p *Compiler) preparePanic(w io.Writer, expr *ast.CallExpr) *callInfo {
+	arg := expr.Args[0]
+	typ := p.exprType(arg)
+	iface := &types.Interface{}
+	var value string
+	if typ == types.Typ[types.UntypedNil] {
+		value = zeroValue(iface)
+	} else {
+		value = p.toIface(w, p.compileExpr(w, arg), typ, iface)
+	}
+	itab, data := p.ifaceParts(w, value)
+	posStr := p.posString(expr.Pos())
+	return &callInfo{
+		fnName:     "@tiny_go_builtin_panic",
+		resultType: "i32",
+		paramsType: []string{"i8*", "i8*", "i8*", "i32"},
+		args:       []string{itab, data, p.stringConstPtr(posStr), fmt.Sprint(len(posStr))},
+	}
+}
+
+// compileRecover 编译 recover(), 结果为 interface{}
+func (p *Compiler) compileRecover(w io.Writer, expr *ast.CallExpr) string {
+	buf := p.genId()
+	p.genAlloca(w, buf, "{ i8*, i8* }", 8)
+	ptr := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = bitcast { i8*, i8* }* %s to i8**\n", ptr, buf)
+	_, _ = fmt.Fprintf(w, "\tcall void @tiny_go_builtin_recover(%s* %%func.frame, i8** %s)\n", frameType, ptr)
+	value := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = load { i8*, i8* }, { i8*, i8* }* %s, align 8\n", value, buf)
+	return value
+}
+
+// genStringItab 生成运行时错误使用的 string 实现 interface{} 的 itab
+func (p *Compiler) genStringItab(w io.Writer) {
+	_, _ = fmt.Fprintf(w, "\n@tiny_go_string_itab = constant i8* %s\n", p.itab(types.Typ[types.String], &types.Interface{}))
+}
@@ -0,0 +1,173 @@
+package compiler
+
+import (
+	"fmt"
+	"io"
+	"tiny-go/ast"
+	"tiny-go/token"
+	"tiny-go/types"
+)
+
+// 指针在 LLVM 中表示为 T*. 被取地址的局部变量在堆上分配, 这样函数返回后指针仍然有效.
+// 堆内存由 builtin 运行时分配并初始化为 0, 目前没有回收.
+
+// findEscapes 查找函数中被取地址或被闭包捕获的局部变量
+func (p *Compiler) findEscapes(body *ast.BlockStmt) map[*types.Object]bool {
+	escapes := make(map[*types.Object]bool)
+	escape := func(ident *ast.Ident) {
+		if obj := p.info.Uses[ident]; obj != nil {
+			escapes[obj] = true
+		}
+	}
+	ast.Inspect(body, func(node interface{}) bool {
+		switch node := node.(type) {
+		case *ast.FuncLit:
+			// 闭包按引用捕获外层的变量
+			for _, ident := range funcLitIdents(node) {
+				escape(ident)
+			}
+		case *ast.UnaryExpr:
+			if node.Op == token.BIT_AND {
+				if ident := rootIdent(node.X); ident != nil {
+					escape(ident)
+				}
+			}
+		case *ast.SliceExpr:
+			// 数组的切片引用了数组本身
+			if ident := rootIdent(node.X); ident != nil {
+				escape(ident)
+			}
+		case *ast.CallExpr:
+			// 调用指针接收者的方法时会隐式地取接收者的地址
+			if ident := rootIdent(node.Recv); ident != nil {
+				escape(ident)
+			} else if node.Pkg != nil {
+				escape(node.Pkg)
+			}
+		}
+		return true
+	})
+	return escapes
+}
+
+// rootIdent 获取 x.f, x[i] 等表达式最终引用的变量
+func rootIdent(expr ast.Expr) *ast.Ident {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		return expr
+	case *ast.SelectorExpr:
+		return rootIdent(expr.X)
+	case *ast.IndexExpr:
+		return rootIdent(expr.X)
+	case *ast.ParenExpr:
+		return rootIdent(expr.X)
+	}
+	return nil
+}
+
+// allocLocal 为局部变量 obj 分配内存, 被取地址的变量分配在堆上. 没有名字的参数 obj 为 nil
+func (p *Compiler) allocLocal(w io.Writer, obj *types.Object, mangledName string, typ types.Type) {
+	if obj != nil {
+		p.names[obj] = mangledName
+	}
+	if p.fn != nil && p.fn.escapes[obj] {
+		ptr := p.heapAlloc(w, typ)
+		_, _ = fmt.Fprintf(w, "\t%s = bitcast %s* %s to %s*\n", mangledName, llType(typ), ptr, llType(typ))
+		return
+	}
+	p.genAlloca(w, mangledName, llType(typ), types.Alignof(typ))
+}
+
+// genAlloca 在函数的入口处分配 typ 类型的栈内存. 循环中的 alloca 每次执行都会分配新的内存,
+// 栈会不断增长, 所以局部变量和临时变量都在入口处分配, 每次使用前重新初始化
+func (p *Compiler) genAlloca(w io.Writer, name, typ string, align int) {
+	if p.fn != nil {
+		w = &p.fn.allocas
+	}
+	_, _ = fmt.Fprintf(w, "\t%s = alloca %s, align %d\n", name, typ, align)
+}
+
+// heapAlloc 在堆上分配一个 typ 类型的值, 返回 T* 类型的指针
+func (p *Compiler) heapAlloc(w io.Writer, typ types.Type) string {
+	raw := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = call i8* @tiny_go_builtin_alloc(i32 %s)\n", raw, llSizeOf(typ))
+	ptr := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = bitcast i8* %s to %s*\n", ptr, raw, llType(typ))
+	return ptr
+}
+
+// llSizeOf 类型大小的常量表达式, 由 LLVM 根据目标平台计算
+func llSizeOf(typ types.Type) string {
+	return llTypeSize(llType(typ))
+}
+
+// llTypeSize LLVM 类型 t 的大小的常量表达式
+func llTypeSize(t string) string {
+	return fmt.Sprintf("ptrtoint (%s* getelementptr (%s, %s* null, i32 1) to i32)", t, t, t)
+}
+
+// compileAddrOf 编译 &x
+func (p *Compiler) compileAddrOf(w io.Writer, expr *ast.UnaryExpr) string {
+	// &T{...} 在堆上分配新的值
+	if lit, ok := expr.X.(*ast.CompositeLit); ok {
+		typ := p.exprType(lit)
+		ptr := p.heapAlloc(w, typ)
+		value := p.compileCompositeLit(w, lit)
+		_, _ = fmt.Fprintf(w, "\tstore %s %s, %s* %s\n", llType(typ), value, llType(typ), ptr)
+		return ptr
+	}
+	return p.compileAddr(w, expr.X)
+}
+
+// compileDeref 编译 *p 的地址, 并检查 p 不为 nil
+func (p *Compiler) compileDeref(w io.Writer, expr *ast.StarExpr) string {
+	ptr := p.compileExpr(w, expr.X)
+	p.genNilCheck(w, expr.Star, ptr, p.exprType(expr.X))
+	return ptr
+}
+
+// genNilCheck 检查指针不为 nil, 否则调用 builtin 的 panic 函数
+func (p *Compiler) genNilCheck(w io.Writer, pos token.Pos, ptr string, typ types.Type) {
+	isNil := p.genId()
+	panicLabel := p.genLabelId("nil.panic")
+	okLabel := p.genLabelId("nil.ok")
+	_, _ = fmt.Fprintf(w, "\t%s = icmp eq %s %s, null\n", isNil, llType(typ), ptr)
+	_, _ = fmt.Fprintf(w, "\tbr i1 %s, label %%%s, label %%%s\n", isNil, panicLabel, okLabel)
+
+	_, _ = fmt.Fprintf(w, "\n%s:\n", panicLabel)
+	posStr := p.posString(pos)
+	_, _ = fmt.Fprintf(w, "\tcall void @tiny_go_builtin_panic_nil(i8* %s, i32 %d)\n", p.stringConstPtr(posStr), len(posStr))
+	_, _ = fmt.Fprintf(w, "\tunreachable\n")
+
+	_, _ = fmt.Fprintf(w, "\n%s:\n", okLabel)
+}
+
+// compileNew 编译 new(T)
+func (p *Compiler) compileNew(w io.Writer, expr *ast.CallExpr) string {
+	return p.heapAlloc(w, p.exprType(expr.Args[0]))
+}
+
+// compileNilCompare 编译和 nil 的比较, 如 p == nil
+func (p *Compiler) compileNilCompare(w io.Writer, expr *ast.BinaryExpr, xTyp, yTyp types.Type) string {
+	value, typ := expr.X, xTyp
+	if xTyp == types.Typ[types.UntypedNil] {
+		value, typ = expr.Y, yTyp
+	}
+	x := p.compileExpr(w, value)
+	// 切片和 nil 比较时比较底层数组的指针
+	if s, ok := types.Underlying(typ).(*types.Slice); ok {
+		data := p.genId()
+		_, _ = fmt.Fprintf(w, "\t%s = extractvalue %s %s, 0\n", data, llType(s), x)
+		x, typ = data, &types.Pointer{Elem: s.Elem}
+	}
+	// 接口和函数值和 nil 比较时比较 itab 或代码指针
+	switch types.Underlying(typ).(type) {
+	case *types.Interface, *types.Signature:
+		ptr := p.genId()
+		_, _ = fmt.Fprintf(w, "\t%s = extractvalue { i8*, i8* } %s, 0\n", ptr, x)
+		x, typ = ptr, &types.Pointer{Elem: types.Typ[types.Int8]}
+	}
+	localName := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = %s %s %s, null\n", localName, opType(expr.Op, typ), llType(typ), x)
+	return localName
+}
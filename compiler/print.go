@@ -0,0 +1,41 @@
+package compiler
+
+import (
+	"fmt"
+	"io"
+	"tiny-go/types"
+)
+
+// print, println 和 printf 的参数为 ...interface{}, 编译器把参数打包为 []interface{} 后,
+// 把其中的字符串和切片拆为数据指针和长度传给运行时, 运行时根据类型描述符中的种类格式化每个参数.
+
+// printFuncs 运行时中的打印函数
+var printFuncs = map[string]bool{
+	"@tiny_go_builtin_print":   true,
+	"@tiny_go_builtin_println": true,
+	"@tiny_go_builtin_printf":  true,
+}
+
+// lowerPrintArgs 把打印函数调用参数中的字符串和切片拆为数据指针和长度
+func (p *Compiler) lowerPrintArgs(w io.Writer, call *callInfo, sig *types.Signature) {
+	var paramsType, args []string
+	for i, arg := range call.args {
+		switch typ := sig.Params[i].(type) {
+		case *types.Basic:
+			ptr, n := p.stringParts(w, arg)
+			paramsType = append(paramsType, "i8*", "i32")
+			args = append(args, ptr, n)
+		case *types.Slice:
+			data := p.genId()
+			_, _ = fmt.Fprintf(w, "\t%s = extractvalue %s %s, 0\n", data, llType(typ), arg)
+			ptr := p.genId()
+			_, _ = fmt.Fprintf(w, "\t%s = bitcast %s* %s to i8*\n", ptr, llType(typ.Elem), data)
+			n := p.genId()
+			_, _ = fmt.Fprintf(w, "\t%s = extractvalue %s %s, 1\n", n, llType(typ), arg)
+			paramsType = append(paramsType, "i8*", "i32")
+			args = append(args, ptr, n)
+		}
+	}
+	call.paramsType = paramsType
+	call.args = args
+}
@@ -0,0 +1,297 @@
+package compiler
+
+import (
+	"fmt"
+	"io"
+	"tiny-go/ast"
+	"tiny-go/token"
+	"tiny-go/types"
+)
+
+// for range 语句: 被迭代的值在循环开始前求值一次, 迭代的状态(下标, 长度, map 的条目等)保存在
+// 循环外的临时变量中. 和 compileStmtFor 一样使用 for.init/for.cond/for.body/for.post/for.end
+// 基本块, continue 跳转到 for.post. 字符串按 UTF-8 解码, 下标为字符的第一个字节的位置;
+// map 按插入的顺序迭代, 见 builtin 运行时的 map_next.
+//
+// := 声明的迭代变量每次迭代都是新的变量: 被闭包捕获或取地址的变量在每次迭代中重新分配在堆上,
+// 其余的变量无法区分, 在循环外分配一次.
+
+// rangeState for range 循环的状态
+type rangeState struct {
+	typ   types.Type // 被迭代的值的底层类型
+	x     string     // 数组副本的地址, 切片和字符串的数据指针, map 和 channel
+	n     string     // 整数的值或数组, 切片, 字符串的长度
+	index string     // 当前下标的地址, map 为上一个条目的地址
+	next  string     // 字符串中下一个字符的下标的地址
+	key   string     // map 的键的地址的地址
+	elem  string     // map 的值的地址的地址, channel 接收的值的地址
+}
+
+// compileStmtRange 编译 for range 语句, label 为循环的标号
+func (p *Compiler) compileStmtRange(w io.Writer, stmt *ast.RangeStmt, label string) {
+	forPos := fmt.Sprintf("%d", p.posLine(stmt.For))
+	forInit := p.genLabelId("for.init.line" + forPos)
+	forCond := p.genLabelId("for.cond.line" + forPos)
+	forPost := p.genLabelId("for.post.line" + forPos)
+	forBody := p.genLabelId("for.body.line" + forPos)
+	forEnd := p.genLabelId("for.end.line" + forPos)
+
+	p.fn.branches = append(p.fn.branches, &branchTarget{
+		label:      label,
+		breakTo:    forEnd,
+		continueTo: forPost,
+	})
+	defer func() { p.fn.branches = p.fn.branches[:len(p.fn.branches)-1] }()
+
+	keyTyp, valueTyp := p.rangeTypes(stmt)
+
+	// br for.init
+	_, _ = fmt.Fprintf(w, "\tbr label %%%s\n", forInit)
+
+	// for.init
+	_, _ = fmt.Fprintf(w, "\n%s:\n", forInit)
+	s := p.compileRangeInit(w, stmt, keyTyp)
+	vars := []ast.Expr{stmt.Key, stmt.Value}
+	typeList := []types.Type{keyTyp, valueTyp}
+	if stmt.Tok == token.DEFINE {
+		for i, x := range vars {
+			if obj := p.rangeVar(x); obj != nil && !p.fn.escapes[obj] {
+				p.allocLocal(w, obj, rangeVarName(x.(*ast.Ident)), typeList[i])
+			}
+		}
+	}
+	_, _ = fmt.Fprintf(w, "\tbr label %%%s\n", forCond)
+
+	// for.cond
+	_, _ = fmt.Fprintf(w, "\n%s:\n", forCond)
+	p.compileRangeCond(w, s, forBody, forEnd)
+
+	// for.body
+	_, _ = fmt.Fprintf(w, "\n%s:\n", forBody)
+	values := p.compileRangeValues(w, s, stmt.Value != nil)
+	for i, x := range vars {
+		if x == nil || isBlank(x) {
+			continue
+		}
+		if obj := p.rangeVar(x); stmt.Tok == token.DEFINE && p.fn.escapes[obj] {
+			p.allocLocal(w, obj, rangeVarName(x.(*ast.Ident)), typeList[i])
+		}
+		ptr := p.compileAddr(w, x)
+		targetType := p.exprType(x)
+		value := p.convert(w, values[i], typeList[i], targetType)
+		_, _ = fmt.Fprintf(w, "\tstore %s %s, %s* %s\n", llType(targetType), value, llType(targetType), ptr)
+	}
+	p.compileStmt(w, stmt.Body)
+	_, _ = fmt.Fprintf(w, "\tbr label %%%s\n", forPost)
+
+	// for.post
+	_, _ = fmt.Fprintf(w, "\n%s:\n", forPost)
+	p.compileRangePost(w, s)
+	_, _ = fmt.Fprintf(w, "\tbr label %%%s\n", forCond)
+
+	//end
+	_, _ = fmt.Fprintf(w, "\n%s:\n", forEnd)
+}
+
+// rangeVarName := 声明的迭代变量在 LLVM 中的名字
+func rangeVarName(ident *ast.Ident) string {
+	return fmt.Sprintf("%%local_%s.pos.%d", ident.Name, ident.NamePos)
+}
+
+// rangeVar 获取 := 声明的迭代变量对应的对象, x 为 nil 或 _ 时返回 nil
+func (p *Compiler) rangeVar(x ast.Expr) *types.Object {
+	if ident, ok := x.(*ast.Ident); ok {
+		return p.info.Defs[ident]
+	}
+	return nil
+}
+
+// rangeTypes 获取两个迭代变量的类型, 迭代变量的个数已经由类型检查保证
+func (p *Compiler) rangeTypes(stmt *ast.RangeStmt) (key, value types.Type) {
+	typ := p.exprType(stmt.X)
+	switch u := types.Underlying(typ).(type) {
+	case *types.Array:
+		return types.Typ[types.Int], u.Elem
+	case *types.Slice:
+		return types.Typ[types.Int], u.Elem
+	case *types.Map:
+		return u.Key, u.Elem
+	case *types.Chan:
+		return u.Elem, nil
+	}
+	if types.IsString(typ) {
+		return types.Typ[types.Int], types.Typ[types.Int32]
+	}
+	return types.Default(typ), nil
+}
+
+// compileRangeInit 计算被迭代的值, 为迭代的状态分配临时变量. keyTyp 为整数的 range 中下标的类型
+func (p *Compiler) compileRangeInit(w io.Writer, stmt *ast.RangeStmt, keyTyp types.Type) *rangeState {
+	xTyp := p.exprType(stmt.X)
+	s := &rangeState{typ: types.Underlying(xTyp)}
+	switch typ := s.typ.(type) {
+	case *types.Array:
+		// 只有下标时不需要数组的值
+		s.n = fmt.Sprint(typ.Len)
+		if stmt.Value != nil {
+			s.x = p.spill(w, p.compileExpr(w, stmt.X), typ)
+		}
+		s.index = p.spill(w, "0", types.Typ[types.Int])
+	case *types.Slice:
+		value := p.compileExpr(w, stmt.X)
+		s.x = p.genId()
+		_, _ = fmt.Fprintf(w, "\t%s = extractvalue %s %s, 0\n", s.x, llType(typ), value)
+		s.n = p.genId()
+		_, _ = fmt.Fprintf(w, "\t%s = extractvalue %s %s, 1\n", s.n, llType(typ), value)
+		s.index = p.spill(w, "0", types.Typ[types.Int])
+	case *types.Map:
+		s.x = p.compileExpr(w, stmt.X)
+		s.index = p.spill(w, "null", &types.Pointer{Elem: types.Typ[types.Int8]})
+		s.key = p.spill(w, "null", &types.Pointer{Elem: types.Typ[types.Int8]})
+		s.elem = p.spill(w, "null", &types.Pointer{Elem: types.Typ[types.Int8]})
+	case *types.Chan:
+		s.x = p.compileExpr(w, stmt.X)
+		s.elem = p.genId()
+		p.genAlloca(w, s.elem, llType(typ.Elem), types.Alignof(typ.Elem))
+	default:
+		if types.IsString(typ) {
+			s.x, s.n = p.stringParts(w, p.compileExpr(w, stmt.X))
+			s.index = p.spill(w, "0", types.Typ[types.Int])
+			s.next = p.spill(w, "0", types.Typ[types.Int])
+			break
+		}
+		// 整数
+		s.typ = keyTyp
+		s.n = p.convert(w, p.compileExpr(w, stmt.X), xTyp, keyTyp)
+		s.index = p.spill(w, zeroValue(keyTyp), keyTyp)
+	}
+	return s
+}
+
+// compileRangeCond 判断是否还有下一次迭代
+func (p *Compiler) compileRangeCond(w io.Writer, s *rangeState, bodyLabel, endLabel string) {
+	cond := p.genId()
+	switch typ := s.typ.(type) {
+	case *types.Map:
+		prev := p.genId()
+		_, _ = fmt.Fprintf(w, "\t%s = load i8*, i8** %s\n", prev, s.index)
+		entry := p.genId()
+		_, _ = fmt.Fprintf(w, "\t%s = call i8* @tiny_go_builtin_map_next(i8* %s, i8* %s, i8** %s, i8** %s)\n",
+			entry, s.x, prev, s.key, s.elem)
+		_, _ = fmt.Fprintf(w, "\tstore i8* %s, i8** %s\n", entry, s.index)
+		_, _ = fmt.Fprintf(w, "\t%s = icmp ne i8* %s, null\n", cond, entry)
+	case *types.Chan:
+		elem := p.genId()
+		_, _ = fmt.Fprintf(w, "\t%s = bitcast %s* %s to i8*\n", elem, llType(typ.Elem), s.elem)
+		received := p.genId()
+		_, _ = fmt.Fprintf(w, "\t%s = call i32 @tiny_go_builtin_chan_recv(i8* %s, i8* %s)\n", received, s.x, elem)
+		_, _ = fmt.Fprintf(w, "\t%s = icmp ne i32 %s, 0\n", cond, received)
+	default:
+		var indexTyp types.Type = types.Typ[types.Int]
+		if types.IsInteger(typ) {
+			indexTyp = typ
+		}
+		index := p.genId()
+		_, _ = fmt.Fprintf(w, "\t%s = load %s, %s* %s\n", index, llType(indexTyp), llType(indexTyp), s.index)
+		op := "icmp slt"
+		if types.IsUnsigned(indexTyp) {
+			op = "icmp ult"
+		}
+		_, _ = fmt.Fprintf(w, "\t%s = %s %s %s, %s\n", cond, op, llType(indexTyp), index, s.n)
+	}
+	_, _ = fmt.Fprintf(w, "\tbr i1 %s, label %%%s, label %%%s\n", cond, bodyLabel, endLabel)
+}
+
+// compileRangeValues 计算本次迭代的两个值, withValue 为 false 时不需要第二个值
+func (p *Compiler) compileRangeValues(w io.Writer, s *rangeState, withValue bool) []string {
+	switch typ := s.typ.(type) {
+	case *types.Array:
+		index := p.loadRangeIndex(w, s)
+		if !withValue {
+			return []string{index, ""}
+		}
+		ptr := p.genId()
+		_, _ = fmt.Fprintf(w, "\t%s = getelementptr inbounds %s, %s* %s, i32 0, i32 %s\n",
+			ptr, llType(typ), llType(typ), s.x, index)
+		return []string{index, p.loadValue(w, ptr, typ.Elem)}
+	case *types.Slice:
+		index := p.loadRangeIndex(w, s)
+		if !withValue {
+			return []string{index, ""}
+		}
+		ptr := p.genId()
+		_, _ = fmt.Fprintf(w, "\t%s = getelementptr inbounds %s, %s* %s, i32 %s\n",
+			ptr, llType(typ.Elem), llType(typ.Elem), s.x, index)
+		return []string{index, p.loadValue(w, ptr, typ.Elem)}
+	case *types.Map:
+		return []string{p.loadRangeEntry(w, s.key, typ.Key), p.loadRangeEntry(w, s.elem, typ.Elem)}
+	case *types.Chan:
+		return []string{p.loadValue(w, s.elem, typ.Elem), ""}
+	default:
+		index := p.loadRangeIndex(w, s)
+		if !types.IsString(typ) {
+			return []string{index, ""}
+		}
+		width := p.genId()
+		p.genAlloca(w, width, "i32", 4)
+		r := p.genId()
+		_, _ = fmt.Fprintf(w, "\t%s = call i32 @tiny_go_builtin_decode_rune(i8* %s, i32 %s, i32 %s, i32* %s)\n",
+			r, s.x, s.n, index, width)
+		n := p.genId()
+		_, _ = fmt.Fprintf(w, "\t%s = load i32, i32* %s\n", n, width)
+		next := p.genId()
+		_, _ = fmt.Fprintf(w, "\t%s = add i32 %s, %s\n", next, index, n)
+		_, _ = fmt.Fprintf(w, "\tstore i32 %s, i32* %s\n", next, s.next)
+		return []string{index, r}
+	}
+}
+
+// compileRangePost 移动到下一次迭代, map 和 channel 在 for.cond 中移动
+func (p *Compiler) compileRangePost(w io.Writer, s *rangeState) {
+	switch typ := s.typ.(type) {
+	case *types.Map, *types.Chan:
+	default:
+		if types.IsString(typ) {
+			next := p.genId()
+			_, _ = fmt.Fprintf(w, "\t%s = load i32, i32* %s\n", next, s.next)
+			_, _ = fmt.Fprintf(w, "\tstore i32 %s, i32* %s\n", next, s.index)
+			return
+		}
+		var indexTyp types.Type = types.Typ[types.Int]
+		if types.IsInteger(typ) {
+			indexTyp = typ
+		}
+		index := p.genId()
+		_, _ = fmt.Fprintf(w, "\t%s = load %s, %s* %s\n", index, llType(indexTyp), llType(indexTyp), s.index)
+		inc := p.genId()
+		_, _ = fmt.Fprintf(w, "\t%s = add %s %s, 1\n", inc, llType(indexTyp), index)
+		_, _ = fmt.Fprintf(w, "\tstore %s %s, %s* %s\n", llType(indexTyp), inc, llType(indexTyp), s.index)
+	}
+}
+
+// loadRangeIndex 读取当前下标, 整数的 range 中下标和整数的类型相同
+func (p *Compiler) loadRangeIndex(w io.Writer, s *rangeState) string {
+	indexType := "i32"
+	if types.IsInteger(s.typ) {
+		indexType = llType(s.typ)
+	}
+	index := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = load %s, %s* %s\n", index, indexType, indexType, s.index)
+	return index
+}
+
+// loadRangeEntry 读取 map 当前条目的键或值, ptr 为保存键或值的地址的临时变量
+func (p *Compiler) loadRangeEntry(w io.Writer, ptr string, typ types.Type) string {
+	raw := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = load i8*, i8** %s\n", raw, ptr)
+	typed := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = bitcast i8* %s to %s*\n", typed, raw, llType(typ))
+	return p.loadValue(w, typed, typ)
+}
+
+// loadValue 读取 typ 类型的指针 ptr 指向的值
+func (p *Compiler) loadValue(w io.Writer, ptr string, typ types.Type) string {
+	value := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = load %s, %s* %s, align %d\n", value, llType(typ), llType(typ), ptr, types.Alignof(typ))
+	return value
+}
@@ -0,0 +1,149 @@
+package compiler
+
+import (
+	"fmt"
+	"io"
+	"tiny-go/ast"
+	"tiny-go/types"
+)
+
+// select 语句: 进入 select 时按源码顺序计算每个分支的 channel 和要发送的值, 组成分支数组
+// { chan, elem, dir } 传给运行时. 运行时返回执行的分支在数组中的下标, 执行 default 分支时为 -1,
+// 接收到的值已经保存在分支的临时变量中, 再由 switch 指令跳转到对应分支的语句.
+
+// select 分支的方向, 和 builtin 运行时中的 TINY_GO_SELECT_* 一致
+const (
+	selectRecv = iota
+	selectSend
+)
+
+// selectCaseType 运行时中 select 分支的类型
+const selectCaseType = "{ i8*, i8*, i32 }"
+
+// compileStmtSelect 编译 select 语句, label 为 select 语句的标号
+func (p *Compiler) compileStmtSelect(w io.Writer, stmt *ast.SelectStmt, label string) {
+	selectPos := fmt.Sprintf("%d", p.posLine(stmt.Select))
+	selectEnd := p.genLabelId("select.end.line" + selectPos)
+
+	var clauses []*ast.CommClause
+	defaultIndex := -1
+	for i, x := range stmt.Body.List {
+		clause := x.(*ast.CommClause)
+		if clause.Comm == nil {
+			defaultIndex = i
+		}
+		clauses = append(clauses, clause)
+	}
+	bodies := make([]string, len(clauses))
+	for i, clause := range clauses {
+		name := "select.case.line"
+		if clause.Comm == nil {
+			name = "select.default.line"
+		}
+		bodies[i] = p.genLabelId(name + fmt.Sprintf("%d", p.posLine(clause.Case)))
+	}
+	defaultTo := selectEnd
+	if defaultIndex >= 0 {
+		defaultTo = bodies[defaultIndex]
+	}
+
+	// 分支数组不包括 default 分支
+	n := len(clauses)
+	if defaultIndex >= 0 {
+		n--
+	}
+	casesType := fmt.Sprintf("[%d x %s]", n, selectCaseType)
+	cases := p.genId()
+	p.genAlloca(w, cases, casesType, 8)
+
+	caseIndex := make([]int, len(clauses)) // 分支在数组中的下标
+	recvPtrs := make([]string, len(clauses))
+	k := 0
+	for i, clause := range clauses {
+		if clause.Comm == nil {
+			continue
+		}
+		var c, elem string
+		dir := selectRecv
+		if send, ok := clause.Comm.(*ast.SendStmt); ok {
+			c, elem = p.compileSendValue(w, send)
+			dir = selectSend
+		} else {
+			recv := commRecv(clause.Comm)
+			typ := p.chanType(recv.X)
+			c = p.compileExpr(w, recv.X)
+			recvPtrs[i] = p.genId()
+			p.genAlloca(w, recvPtrs[i], llType(typ.Elem), types.Alignof(typ.Elem))
+			elem = p.genId()
+			_, _ = fmt.Fprintf(w, "\t%s = bitcast %s* %s to i8*\n", elem, llType(typ.Elem), recvPtrs[i])
+		}
+		casePtr := p.genId()
+		_, _ = fmt.Fprintf(w, "\t%s = getelementptr inbounds %s, %s* %s, i32 0, i32 %d\n", casePtr, casesType, casesType, cases, k)
+		p.storeField(w, selectCaseType, casePtr, 0, "i8*", c)
+		p.storeField(w, selectCaseType, casePtr, 1, "i8*", elem)
+		p.storeField(w, selectCaseType, casePtr, 2, "i32", fmt.Sprint(dir))
+		caseIndex[i] = k
+		k++
+	}
+
+	ok := p.genId()
+	p.genAlloca(w, ok, "i32", 4)
+	casesPtr := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = bitcast %s* %s to i8*\n", casesPtr, casesType, cases)
+	hasDefault := 0
+	if defaultIndex >= 0 {
+		hasDefault = 1
+	}
+	posStr := p.posString(stmt.Select)
+	chosen := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = call i32 @tiny_go_builtin_select(i8* %s, i32 %d, i32 %d, i32* %s, i8* %s, i32 %d)\n",
+		chosen, casesPtr, n, hasDefault, ok, p.stringConstPtr(posStr), len(posStr))
+	_, _ = fmt.Fprintf(w, "\tswitch i32 %s, label %%%s [", chosen, defaultTo)
+	for i, clause := range clauses {
+		if clause.Comm != nil {
+			_, _ = fmt.Fprintf(w, " i32 %d, label %%%s", caseIndex[i], bodies[i])
+		}
+	}
+	_, _ = fmt.Fprintf(w, " ]\n")
+
+	// break 跳出 select, continue 跳转到外层的循环
+	p.fn.branches = append(p.fn.branches, &branchTarget{
+		label:   label,
+		breakTo: selectEnd,
+	})
+	defer func() { p.fn.branches = p.fn.branches[:len(p.fn.branches)-1] }()
+
+	for i, clause := range clauses {
+		_, _ = fmt.Fprintf(w, "\n%s:\n", bodies[i])
+		// case v, ok := <-ch 的变量在分支的作用域中
+		if assign, isAssign := clause.Comm.(*ast.AssignStmt); isAssign {
+			typ := p.exprType(assign.Value[0])
+			value := p.genId()
+			_, _ = fmt.Fprintf(w, "\t%s = load %s, %s* %s, align %d\n", value, llType(typ), llType(typ), recvPtrs[i], types.Alignof(typ))
+			values, typeList := []string{value}, []types.Type{typ}
+			if len(assign.Target) == 2 {
+				received := p.genId()
+				_, _ = fmt.Fprintf(w, "\t%s = load i32, i32* %s, align 4\n", received, ok)
+				okValue := p.genId()
+				_, _ = fmt.Fprintf(w, "\t%s = icmp ne i32 %s, 0\n", okValue, received)
+				values, typeList = append(values, okValue), append(typeList, types.Typ[types.UntypedBool])
+			}
+			p.assignValues(w, assign, values, typeList)
+		}
+		for _, x := range clause.Body {
+			p.compileStmt(w, x)
+		}
+		_, _ = fmt.Fprintf(w, "\tbr label %%%s\n", selectEnd)
+	}
+
+	// end
+	_, _ = fmt.Fprintf(w, "\n%s:\n", selectEnd)
+}
+
+// commRecv 获取 select 接收分支中的 <-ch, 语法已经由 parser 检查过
+func commRecv(comm ast.Stmt) *ast.UnaryExpr {
+	if assign, ok := comm.(*ast.AssignStmt); ok {
+		return assign.Value[0].(*ast.UnaryExpr)
+	}
+	return comm.(*ast.ExprStmt).X.(*ast.UnaryExpr)
+}
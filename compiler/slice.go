@@ -0,0 +1,236 @@
+package compiler
+
+import (
+	"fmt"
+	"io"
+	"tiny-go/ast"
+	"tiny-go/types"
+)
+
+// 切片在 LLVM 中表示为 { T*, i32, i32 }, 即数据指针, 长度和容量.
+// 底层数组由 builtin 运行时在堆上分配, make 和 append 通过指针修改切片头.
+
+// sliceRuntime 调用以切片头指针为第一个参数的运行时函数, args 为带类型的其余参数, 如 "i32 %t1"
+func (p *Compiler) sliceRuntime(w io.Writer, typ *types.Slice, value, fnName string, args ...string) string {
+	ptr := p.spill(w, value, typ)
+	header := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = bitcast %s* %s to i8*\n", header, llType(typ), ptr)
+	_, _ = fmt.Fprintf(w, "\tcall void %s(i8* %s", fnName, header)
+	for _, arg := range args {
+		_, _ = fmt.Fprintf(w, ", %s", arg)
+	}
+	_, _ = fmt.Fprintf(w, ")\n")
+
+	localName := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = load %s, %s* %s, align 8\n", localName, llType(typ), llType(typ), ptr)
+	return localName
+}
+
+// compileMake 编译 make([]T, len, cap), make(map[K]V, hint) 和 make(chan T, size)
+func (p *Compiler) compileMake(w io.Writer, expr *ast.CallExpr) string {
+	switch typ := types.Underlying(p.exprType(expr.Args[0])).(type) {
+	case *types.Map:
+		return p.compileMakeMap(w, expr, typ)
+	case *types.Chan:
+		return p.compileMakeChan(w, expr, typ)
+	}
+	typ := types.Underlying(p.exprType(expr.Args[0])).(*types.Slice)
+
+	var sizes []string
+	for _, arg := range expr.Args[1:] {
+		sizes = append(sizes, p.compileIndex(w, arg))
+	}
+	if len(expr.Args) == 2 {
+		sizes = append(sizes, sizes[0])
+	}
+
+	posStr := p.posString(expr.Pos())
+	return p.sliceRuntime(w, typ, zeroValue(typ), "@tiny_go_builtin_make_slice",
+		"i32 "+sizes[0], "i32 "+sizes[1], "i32 "+llSizeOf(typ.Elem),
+		"i8* "+p.stringConstPtr(posStr), fmt.Sprintf("i32 %d", len(posStr)))
+}
+
+// compileAppend 编译 append(s, x, y, ...) 和 append(s, t...)
+func (p *Compiler) compileAppend(w io.Writer, expr *ast.CallExpr) string {
+	typ := types.Underlying(p.exprType(expr.Args[0])).(*types.Slice)
+	if expr.Ellipsis.IsValid() {
+		return p.compileAppendSlice(w, expr, typ)
+	}
+	elems := expr.Args[1:]
+
+	s := p.compileExpr(w, expr.Args[0])
+	if len(elems) == 0 {
+		return s
+	}
+
+	var values []string
+	for _, elem := range elems {
+		value := p.compileExpr(w, elem)
+		values = append(values, p.convert(w, value, p.exprType(elem), typ.Elem))
+	}
+
+	oldLen := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = extractvalue %s %s, 1\n", oldLen, llType(typ), s)
+	newLen := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = add i32 %s, %d\n", newLen, oldLen, len(elems))
+	s = p.sliceRuntime(w, typ, s, "@tiny_go_builtin_slice_grow", "i32 "+newLen, "i32 "+llSizeOf(typ.Elem))
+
+	elemType := llType(typ.Elem)
+	data := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = extractvalue %s %s, 0\n", data, llType(typ), s)
+	for i, value := range values {
+		index := p.genId()
+		_, _ = fmt.Fprintf(w, "\t%s = add i32 %s, %d\n", index, oldLen, i)
+		ptr := p.genId()
+		_, _ = fmt.Fprintf(w, "\t%s = getelementptr inbounds %s, %s* %s, i32 %s\n", ptr, elemType, elemType, data, index)
+		_, _ = fmt.Fprintf(w, "\tstore %s %s, %s* %s, align %d\n", elemType, value, elemType, ptr, types.Alignof(typ.Elem))
+	}
+	return s
+}
+
+// compileAppendSlice 编译 append(s, t...), []byte 还可以追加字符串 append(b, str...)
+func (p *Compiler) compileAppendSlice(w io.Writer, expr *ast.CallExpr, typ *types.Slice) string {
+	arg := expr.Args[1]
+	argTyp := p.exprType(arg)
+	isStr := types.IsString(argTyp) && types.IsBasic(typ.Elem, types.Uint8)
+
+	s := p.compileExpr(w, expr.Args[0])
+	var src, n string
+	if isStr {
+		src, n = p.stringParts(w, p.compileExpr(w, arg))
+	} else {
+		t := p.compileExpr(w, arg)
+		data := p.genId()
+		_, _ = fmt.Fprintf(w, "\t%s = extractvalue %s %s, 0\n", data, llType(typ), t)
+		src = p.genId()
+		_, _ = fmt.Fprintf(w, "\t%s = bitcast %s* %s to i8*\n", src, llType(typ.Elem), data)
+		n = p.genId()
+		_, _ = fmt.Fprintf(w, "\t%s = extractvalue %s %s, 1\n", n, llType(typ), t)
+	}
+
+	oldLen := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = extractvalue %s %s, 1\n", oldLen, llType(typ), s)
+	newLen := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = add i32 %s, %s\n", newLen, oldLen, n)
+	s = p.sliceRuntime(w, typ, s, "@tiny_go_builtin_slice_grow", "i32 "+newLen, "i32 "+llSizeOf(typ.Elem))
+
+	// t 可能和 s 共享底层数组, 使用 memmove 复制
+	elemType := llType(typ.Elem)
+	data := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = extractvalue %s %s, 0\n", data, llType(typ), s)
+	ptr := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = getelementptr inbounds %s, %s* %s, i32 %s\n", ptr, elemType, elemType, data, oldLen)
+	dst := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = bitcast %s* %s to i8*\n", dst, elemType, ptr)
+	size := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = mul i32 %s, %s\n", size, n, llSizeOf(typ.Elem))
+	_, _ = fmt.Fprintf(w, "\tcall void @llvm.memmove.p0i8.p0i8.i32(i8* %s, i8* %s, i32 %s, i1 false)\n", dst, src, size)
+	return s
+}
+
+// spill 把值保存到临时变量中, 返回临时变量的地址
+func (p *Compiler) spill(w io.Writer, value string, typ types.Type) string {
+	ptr := p.genId()
+	p.genAlloca(w, ptr, llType(typ), types.Alignof(typ))
+	_, _ = fmt.Fprintf(w, "\tstore %s %s, %s* %s\n", llType(typ), value, llType(typ), ptr)
+	return ptr
+}
+
+// spillRaw 把值保存到临时变量中, 返回 i8* 类型的地址, 用于把值的地址传给运行时
+func (p *Compiler) spillRaw(w io.Writer, value string, typ types.Type) string {
+	ptr := p.spill(w, value, typ)
+	raw := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = bitcast %s* %s to i8*\n", raw, llType(typ), ptr)
+	return raw
+}
+
+// compileSliceIndexAddr 计算切片元素 s[i] 的地址
+func (p *Compiler) compileSliceIndexAddr(w io.Writer, expr *ast.IndexExpr) string {
+	typ := types.Underlying(p.exprType(expr.X)).(*types.Slice)
+	s := p.compileExpr(w, expr.X)
+	index := p.compileIndex(w, expr.Index)
+
+	data := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = extractvalue %s %s, 0\n", data, llType(typ), s)
+	n := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = extractvalue %s %s, 1\n", n, llType(typ), s)
+	p.genBoundsCheck(w, expr.Index.Pos(), index, n)
+
+	elemType := llType(typ.Elem)
+	ptr := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = getelementptr inbounds %s, %s* %s, i32 %s\n", ptr, elemType, elemType, data, index)
+	return ptr
+}
+
+// compileSliceExpr 编译 x[low:high], x 可以是字符串, 数组或切片
+func (p *Compiler) compileSliceExpr(w io.Writer, expr *ast.SliceExpr) string {
+	var data, n, max, elemType string
+	var strIndex int // 字符串越界时报告长度而不是容量
+	switch typ := types.Underlying(p.exprType(expr.X)).(type) {
+	case *types.Array:
+		elemType = llType(typ.Elem)
+		data = p.genId()
+		_, _ = fmt.Fprintf(w, "\t%s = getelementptr inbounds %s, %s* %s, i32 0, i32 0\n",
+			data, llType(typ), llType(typ), p.compileAddr(w, expr.X))
+		n, max = fmt.Sprint(typ.Len), fmt.Sprint(typ.Len)
+	case *types.Slice:
+		elemType = llType(typ.Elem)
+		s := p.compileExpr(w, expr.X)
+		data, n, max = p.genId(), p.genId(), p.genId()
+		_, _ = fmt.Fprintf(w, "\t%s = extractvalue %s %s, 0\n", data, llType(typ), s)
+		_, _ = fmt.Fprintf(w, "\t%s = extractvalue %s %s, 1\n", n, llType(typ), s)
+		_, _ = fmt.Fprintf(w, "\t%s = extractvalue %s %s, 2\n", max, llType(typ), s)
+	default:
+		elemType = "i8"
+		data, n = p.stringParts(w, p.compileExpr(w, expr.X))
+		max = n
+		strIndex = 1
+	}
+
+	low, high := "0", n
+	if expr.Low != nil {
+		low = p.compileIndex(w, expr.Low)
+	}
+	if expr.High != nil {
+		high = p.compileIndex(w, expr.High)
+	}
+
+	// 检查 0 <= low <= high <= max
+	highOut, lowOut, outOfRange := p.genId(), p.genId(), p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = icmp ugt i32 %s, %s\n", highOut, high, max)
+	_, _ = fmt.Fprintf(w, "\t%s = icmp ugt i32 %s, %s\n", lowOut, low, high)
+	_, _ = fmt.Fprintf(w, "\t%s = or i1 %s, %s\n", outOfRange, highOut, lowOut)
+	panicLabel := p.genLabelId("slice.panic")
+	okLabel := p.genLabelId("slice.ok")
+	_, _ = fmt.Fprintf(w, "\tbr i1 %s, label %%%s, label %%%s\n", outOfRange, panicLabel, okLabel)
+
+	_, _ = fmt.Fprintf(w, "\n%s:\n", panicLabel)
+	posStr := p.posString(expr.Lbrack)
+	_, _ = fmt.Fprintf(w, "\tcall void @tiny_go_builtin_panic_slice(i8* %s, i32 %d, i32 %s, i32 %s, i32 %s, i32 %d)\n",
+		p.stringConstPtr(posStr), len(posStr), low, high, max, strIndex)
+	_, _ = fmt.Fprintf(w, "\tunreachable\n")
+	_, _ = fmt.Fprintf(w, "\n%s:\n", okLabel)
+
+	newData := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = getelementptr inbounds %s, %s* %s, i32 %s\n", newData, elemType, elemType, data, low)
+	newLen := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = sub i32 %s, %s\n", newLen, high, low)
+
+	typ := p.exprType(expr)
+	if types.IsString(typ) {
+		return p.makeString(w, newData, newLen)
+	}
+	newCap := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = sub i32 %s, %s\n", newCap, max, low)
+	return p.makeSlice(w, types.Underlying(typ).(*types.Slice), newData, newLen, newCap)
+}
+
+// makeSlice 由数据指针, 长度和容量构造切片
+func (p *Compiler) makeSlice(w io.Writer, typ *types.Slice, data, n, c string) string {
+	sliceType := llType(typ)
+	withData, withLen, localName := p.genId(), p.genId(), p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = insertvalue %s undef, %s* %s, 0\n", withData, sliceType, llType(typ.Elem), data)
+	_, _ = fmt.Fprintf(w, "\t%s = insertvalue %s %s, i32 %s, 1\n", withLen, sliceType, withData, n)
+	_, _ = fmt.Fprintf(w, "\t%s = insertvalue %s %s, i32 %s, 2\n", localName, sliceType, withLen, c)
+	return localName
+}
@@ -0,0 +1,104 @@
+package compiler
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"tiny-go/ast"
+	"tiny-go/token"
+	"tiny-go/types"
+)
+
+// 字符串在 LLVM 中表示为 %string = type { i8*, i32 }, 即数据指针和字节长度.
+// 字符串字面值保存在只读的全局常量中, 拼接和比较由 builtin 运行时完成.
+
+// stringConst 获取字符串常量对应的全局变量, 相同的字符串共享一个常量
+func (p *Compiler) stringConst(s string) string {
+	if name, ok := p.stringsIdx[s]; ok {
+		return name
+	}
+	name := fmt.Sprintf("@.str.%d", len(p.strings))
+	p.strings = append(p.strings, s)
+	p.stringsIdx[s] = name
+	return name
+}
+
+// stringConstPtr 获取字符串常量首字节的指针常量表达式
+func (p *Compiler) stringConstPtr(s string) string {
+	n := len(s)
+	return fmt.Sprintf("getelementptr inbounds ([%d x i8], [%d x i8]* %s, i32 0, i32 0)", n, n, p.stringConst(s))
+}
+
+func (p *Compiler) compileStringLit(w io.Writer, lit *ast.StringLit) string {
+	return p.makeString(w, p.stringConstPtr(lit.Value), fmt.Sprint(len(lit.Value)))
+}
+
+// makeString 由数据指针和长度构造字符串
+func (p *Compiler) makeString(w io.Writer, ptr, n string) string {
+	withPtr := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = insertvalue %%string undef, i8* %s, 0\n", withPtr, ptr)
+	localName := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = insertvalue %%string %s, i32 %s, 1\n", localName, withPtr, n)
+	return localName
+}
+
+// stringParts 获取字符串的数据指针和长度
+func (p *Compiler) stringParts(w io.Writer, s string) (ptr, n string) {
+	ptr = p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = extractvalue %%string %s, 0\n", ptr, s)
+	n = p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = extractvalue %%string %s, 1\n", n, s)
+	return ptr, n
+}
+
+// compileStringOp 编译字符串的拼接和比较
+func (p *Compiler) compileStringOp(w io.Writer, expr *ast.BinaryExpr, x, y string) string {
+	xPtr, xLen := p.stringParts(w, x)
+	yPtr, yLen := p.stringParts(w, y)
+
+	switch expr.Op {
+	case token.ADD:
+		ptr := p.genId()
+		_, _ = fmt.Fprintf(w, "\t%s = call i8* @tiny_go_builtin_string_concat(i8* %s, i32 %s, i8* %s, i32 %s)\n",
+			ptr, xPtr, xLen, yPtr, yLen)
+		n := p.genId()
+		_, _ = fmt.Fprintf(w, "\t%s = add i32 %s, %s\n", n, xLen, yLen)
+		return p.makeString(w, ptr, n)
+	case token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ:
+		cmp := p.genId()
+		_, _ = fmt.Fprintf(w, "\t%s = call i32 @tiny_go_builtin_string_compare(i8* %s, i32 %s, i8* %s, i32 %s)\n",
+			cmp, xPtr, xLen, yPtr, yLen)
+		localName := p.genId()
+		_, _ = fmt.Fprintf(w, "\t%s = %s i32 %s, 0\n", localName, opType(expr.Op, types.Typ[types.Int]), cmp)
+		return localName
+	}
+	panic("unreachable")
+}
+
+// compileStringIndex 编译 s[i], 结果为字节
+func (p *Compiler) compileStringIndex(w io.Writer, expr *ast.IndexExpr) string {
+	s := p.compileExpr(w, expr.X)
+	index := p.compileIndex(w, expr.Index)
+
+	ptr, n := p.stringParts(w, s)
+	p.genBoundsCheck(w, expr.Index.Pos(), index, n)
+	elemPtr := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = getelementptr inbounds i8, i8* %s, i32 %s\n", elemPtr, ptr, index)
+	localName := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = load i8, i8* %s, align 1\n", localName, elemPtr)
+	return localName
+}
+
+// llString 转义字符串常量, 用于 LLVM 的 c"..." 语法
+func llString(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < ' ' || c > '~' || c == '"' || c == '\\' {
+			_, _ = fmt.Fprintf(&sb, "\\%02X", c)
+		} else {
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,183 @@
+package compiler
+
+import (
+	"fmt"
+	"io"
+	"tiny-go/ast"
+	"tiny-go/token"
+	"tiny-go/types"
+)
+
+// 由 type 声明的结构体在模块头部定义为 LLVM 的命名结构体类型, 如
+// %tiny_go_main_Point = type { i32, i32 }, 字段通过 getelementptr 或 extractvalue 访问.
+// 别名 type A = B 的对象直接使用 B 的类型, A 和 B 是同一个类型.
+
+// genTypeDefs 生成文件中声明的结构体的类型定义
+func (p *Compiler) genTypeDefs(w io.Writer, file *ast.File) {
+	for _, spec := range file.Types {
+		if spec.Assign.IsValid() {
+			continue
+		}
+		named := p.info.Defs[spec.Name].Type.(*types.Named)
+		if _, ok := named.Underlying.(*types.Struct); ok {
+			_, _ = fmt.Fprintf(w, "%s = type %s\n", llType(named), llType(named.Underlying))
+		}
+	}
+	if len(file.Types) > 0 {
+		_, _ = fmt.Fprintln(w)
+	}
+}
+
+func (p *Compiler) compileExprSelector(w io.Writer, expr *ast.SelectorExpr) string {
+	return p.compileField(w, expr.X, expr.Sel.NamePos, p.info.Selections[expr])
+}
+
+// compileSelectorAddr 计算字段 x.sel 的地址
+func (p *Compiler) compileSelectorAddr(w io.Writer, expr *ast.SelectorExpr) string {
+	return p.compileFieldAddr(w, expr.X, expr.Sel.NamePos, p.info.Selections[expr])
+}
+
+// compileField 计算 x 中由 sel 选择的字段的值, pos 为字段名的位置
+func (p *Compiler) compileField(w io.Writer, x ast.Expr, pos token.Pos, sel *types.Selection) string {
+	localName := p.genId()
+	if sel.Indirect || p.addressable(x) {
+		ptr := p.compileFieldAddr(w, x, pos, sel)
+		_, _ = fmt.Fprintf(w, "\t%s = load %s, %s* %s, align %d\n",
+			localName, llType(sel.Type), llType(sel.Type), ptr, types.Alignof(sel.Type))
+		return localName
+	}
+
+	// 不可取地址的结构体 (如函数返回值) 直接取出字段的值
+	_, _ = fmt.Fprintf(w, "\t%s = extractvalue %s %s, %d\n", localName, llType(sel.Recv), p.compileExpr(w, x), sel.Index)
+	return localName
+}
+
+// compileFieldAddr 计算 x 中由 sel 选择的字段的地址, 通过指针访问字段时检查指针是否为 nil
+func (p *Compiler) compileFieldAddr(w io.Writer, x ast.Expr, pos token.Pos, sel *types.Selection) string {
+	typ := sel.Recv
+	var base string
+	if sel.Indirect {
+		typ = types.Underlying(typ).(*types.Pointer).Elem
+		base = p.compileExpr(w, x)
+		p.genNilCheck(w, pos, base, sel.Recv)
+	} else {
+		base = p.compileAddr(w, x)
+	}
+	ptr := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = getelementptr inbounds %s, %s* %s, i32 0, i32 %d\n",
+		ptr, llType(typ), llType(typ), base, sel.Index)
+	return ptr
+}
+
+// compileCompositeLit 编译结构体, 数组, 切片和 map 的复合字面值
+func (p *Compiler) compileCompositeLit(w io.Writer, lit *ast.CompositeLit) string {
+	typ := p.exprType(lit.Type)
+	switch u := types.Underlying(typ).(type) {
+	case *types.Struct:
+		values := structLitValues(lit, u)
+		localName := zeroValue(typ)
+		for i, value := range values {
+			if value != nil {
+				localName = p.insertElem(w, typ, localName, value, u.Fields[i].Type, i)
+			}
+		}
+		return localName
+	case *types.Array:
+		localName := zeroValue(typ)
+		for i, elt := range lit.Elts {
+			localName = p.insertElem(w, typ, localName, elt, u.Elem, i)
+		}
+		return localName
+	case *types.Slice:
+		n := fmt.Sprint(len(lit.Elts))
+		s := p.sliceRuntime(w, u, zeroValue(u), "@tiny_go_builtin_make_slice",
+			"i32 "+n, "i32 "+n, "i32 "+llSizeOf(u.Elem), "i8* null", "i32 0")
+		data := p.genId()
+		_, _ = fmt.Fprintf(w, "\t%s = extractvalue %s %s, 0\n", data, llType(u), s)
+		elemType := llType(u.Elem)
+		for i, elt := range lit.Elts {
+			value := p.convert(w, p.compileExpr(w, elt), p.exprType(elt), u.Elem)
+			ptr := p.genId()
+			_, _ = fmt.Fprintf(w, "\t%s = getelementptr inbounds %s, %s* %s, i32 %d\n", ptr, elemType, elemType, data, i)
+			_, _ = fmt.Fprintf(w, "\tstore %s %s, %s* %s, align %d\n", elemType, value, elemType, ptr, types.Alignof(u.Elem))
+		}
+		return s
+	case *types.Map:
+		return p.compileMapLit(w, lit, u)
+	}
+	panic("unreachable")
+}
+
+// insertElem 编译元素的值并插入到聚合类型的第 i 个位置
+func (p *Compiler) insertElem(w io.Writer, typ types.Type, agg string, elt ast.Expr, elemType types.Type, i int) string {
+	value := p.convert(w, p.compileExpr(w, elt), p.exprType(elt), elemType)
+	localName := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = insertvalue %s %s, %s %s, %d\n", localName, llType(typ), agg, llType(elemType), value, i)
+	return localName
+}
+
+// structLitValues 按字段顺序整理结构体字面值的元素, 没有给出的字段为 nil
+func structLitValues(lit *ast.CompositeLit, s *types.Struct) []ast.Expr {
+	values := make([]ast.Expr, len(s.Fields))
+	for i, elt := range lit.Elts {
+		if kv, ok := elt.(*ast.KeyValueExpr); ok {
+			values[s.FieldIndex(kv.Key.(*ast.Ident).Name)] = kv.Value
+		} else {
+			values[i] = elt
+		}
+	}
+	return values
+}
+
+// compileAggregateEqual 逐个比较结构体的字段或数组的元素
+func (p *Compiler) compileAggregateEqual(w io.Writer, expr *ast.BinaryExpr, typ types.Type) string {
+	eq := p.genEqual(w, typ, p.compileExpr(w, expr.X), p.compileExpr(w, expr.Y))
+	if expr.Op == token.NEQ {
+		localName := p.genId()
+		_, _ = fmt.Fprintf(w, "\t%s = xor i1 %s, true\n", localName, eq)
+		return localName
+	}
+	return eq
+}
+
+// genEqual 生成判断 x == y 的指令, 结果为 i1
+func (p *Compiler) genEqual(w io.Writer, typ types.Type, x, y string) string {
+	var elems []types.Type
+	switch u := types.Underlying(typ).(type) {
+	case *types.Struct:
+		for _, f := range u.Fields {
+			elems = append(elems, f.Type)
+		}
+	case *types.Array:
+		for i := 0; i < u.Len; i++ {
+			elems = append(elems, u.Elem)
+		}
+	case *types.Interface:
+		return p.genIfaceEqual(w, x, y, "")
+	default:
+		localName := p.genId()
+		if types.IsString(typ) {
+			xPtr, xLen := p.stringParts(w, x)
+			yPtr, yLen := p.stringParts(w, y)
+			cmp := p.genId()
+			_, _ = fmt.Fprintf(w, "\t%s = call i32 @tiny_go_builtin_string_compare(i8* %s, i32 %s, i8* %s, i32 %s)\n",
+				cmp, xPtr, xLen, yPtr, yLen)
+			_, _ = fmt.Fprintf(w, "\t%s = icmp eq i32 %s, 0\n", localName, cmp)
+			return localName
+		}
+		_, _ = fmt.Fprintf(w, "\t%s = %s %s %s, %s\n", localName, opType(token.EQL, typ), llType(typ), x, y)
+		return localName
+	}
+
+	result := "true"
+	for i, elemType := range elems {
+		xi, yi := p.genId(), p.genId()
+		_, _ = fmt.Fprintf(w, "\t%s = extractvalue %s %s, %d\n", xi, llType(typ), x, i)
+		_, _ = fmt.Fprintf(w, "\t%s = extractvalue %s %s, %d\n", yi, llType(typ), y, i)
+		eq := p.genEqual(w, elemType, xi, yi)
+		and := p.genId()
+		_, _ = fmt.Fprintf(w, "\t%s = and i1 %s, %s\n", and, result, eq)
+		result = and
+	}
+	return result
+}
@@ -0,0 +1,142 @@
+package compiler
+
+import (
+	"fmt"
+	"io"
+	"tiny-go/ast"
+	"tiny-go/token"
+	"tiny-go/types"
+)
+
+// switch 语句: 每个分支的语句在单独的基本块中, 分支结束后跳转到 switch.end,
+// 以 fallthrough 结束的分支跳转到下一个分支. 分支的值都是整数常量时生成 LLVM 的 switch 指令,
+// 由 LLVM 根据分布选择跳转表或二分查找; 否则按顺序逐个比较.
+
+// compileStmtSwitch 编译 switch 语句, label 为 switch 语句的标号
+func (p *Compiler) compileStmtSwitch(w io.Writer, stmt *ast.SwitchStmt, label string) {
+	switchPos := fmt.Sprintf("%d", p.posLine(stmt.Switch))
+	switchEnd := p.genLabelId("switch.end.line" + switchPos)
+
+	clauses, defaultIndex := p.caseClauses(stmt.Body)
+	bodies := make([]string, len(clauses))
+	for i, clause := range clauses {
+		name := "switch.case.line"
+		if clause.List == nil {
+			name = "switch.default.line"
+		}
+		bodies[i] = p.genLabelId(name + fmt.Sprintf("%d", p.posLine(clause.Case)))
+	}
+	defaultTo := switchEnd
+	if defaultIndex >= 0 {
+		defaultTo = bodies[defaultIndex]
+	}
+
+	if stmt.Init != nil {
+		p.compileStmt(w, stmt.Init)
+	}
+	if stmt.Tag == nil {
+		p.compileSwitchCond(w, clauses, bodies, defaultTo)
+	} else {
+		p.compileSwitchTag(w, stmt, clauses, bodies, defaultTo)
+	}
+
+	// break 跳出 switch, continue 跳转到外层的循环
+	p.fn.branches = append(p.fn.branches, &branchTarget{
+		label:   label,
+		breakTo: switchEnd,
+	})
+	defer func() { p.fn.branches = p.fn.branches[:len(p.fn.branches)-1] }()
+
+	for i, clause := range clauses {
+		_, _ = fmt.Fprintf(w, "\n%s:\n", bodies[i])
+		body, next := clause.Body, switchEnd
+		if n := len(body); n > 0 && isFallthrough(body[n-1]) {
+			body, next = body[:n-1], bodies[i+1]
+		}
+		for _, x := range body {
+			p.compileStmt(w, x)
+		}
+		_, _ = fmt.Fprintf(w, "\tbr label %%%s\n", next)
+	}
+
+	// end
+	_, _ = fmt.Fprintf(w, "\n%s:\n", switchEnd)
+}
+
+// caseClauses 返回 switch 的分支和 default 分支的下标, 没有 default 分支时下标为 -1
+func (p *Compiler) caseClauses(body *ast.BlockStmt) (clauses []*ast.CaseClause, defaultIndex int) {
+	defaultIndex = -1
+	for i, x := range body.List {
+		clause := x.(*ast.CaseClause)
+		if clause.List == nil {
+			defaultIndex = i
+		}
+		clauses = append(clauses, clause)
+	}
+	return clauses, defaultIndex
+}
+
+// isFallthrough 判断语句是否为 fallthrough
+func isFallthrough(stmt ast.Stmt) bool {
+	branch, ok := stmt.(*ast.BranchStmt)
+	return ok && branch.TokType == token.FALLTHROUGH
+}
+
+// compileSwitchCond 编译没有 tag 的 switch, 依次判断每个分支的条件
+func (p *Compiler) compileSwitchCond(w io.Writer, clauses []*ast.CaseClause, bodies []string, defaultTo string) {
+	for i, clause := range clauses {
+		for _, x := range clause.List {
+			next := p.genLabelId(fmt.Sprintf("switch.next.line%d", p.posLine(x.Pos())))
+			p.compileCond(w, x, bodies[i], next)
+			_, _ = fmt.Fprintf(w, "\n%s:\n", next)
+		}
+	}
+	_, _ = fmt.Fprintf(w, "\tbr label %%%s\n", defaultTo)
+}
+
+// compileSwitchTag 编译 switch tag, tag 只求值一次
+func (p *Compiler) compileSwitchTag(w io.Writer, stmt *ast.SwitchStmt, clauses []*ast.CaseClause, bodies []string, defaultTo string) {
+	tagTyp := p.exprType(stmt.Tag)
+	typ := types.Default(tagTyp)
+	dense := types.IsInteger(typ)
+	for _, clause := range clauses {
+		for _, x := range clause.List {
+			if _, _, ok := p.constExpr(x); !ok {
+				dense = false
+			}
+		}
+	}
+
+	tag := p.convert(w, p.compileExpr(w, stmt.Tag), tagTyp, typ)
+	if dense {
+		_, _ = fmt.Fprintf(w, "\tswitch %s %s, label %%%s [\n", llType(typ), tag, defaultTo)
+		for i, clause := range clauses {
+			for _, x := range clause.List {
+				value, _, _ := p.constExpr(x)
+				_, _ = fmt.Fprintf(w, "\t\t%s %s, label %%%s\n", llType(typ), p.compileConst(w, value, typ), bodies[i])
+			}
+		}
+		_, _ = fmt.Fprintf(w, "\t]\n")
+		return
+	}
+
+	// tag 保存在局部变量中, 每个值和它比较时生成 tag == x.
+	// 这个变量不在源码中, 它的对象和类型补充到 Info 中, 和源码中的变量一样编译
+	tagIdent := &ast.Ident{NamePos: stmt.Tag.Pos(), Name: "switch.tag"}
+	tagObj := &types.Object{Name: tagIdent.Name, Kind: types.ObjVar, Type: typ, Node: tagIdent}
+	p.info.Uses[tagIdent] = tagObj
+	p.info.Types[tagIdent] = types.TypeAndValue{Type: typ}
+	mangledName := fmt.Sprintf("%%switch.tag.pos.%d", stmt.Switch)
+	p.allocLocal(w, tagObj, mangledName, typ)
+	_, _ = fmt.Fprintf(w, "\tstore %s %s, %s* %s\n", llType(typ), tag, llType(typ), mangledName)
+
+	for i, clause := range clauses {
+		for _, x := range clause.List {
+			next := p.genLabelId(fmt.Sprintf("switch.next.line%d", p.posLine(x.Pos())))
+			cond := &ast.BinaryExpr{X: tagIdent, OpPos: x.Pos(), Op: token.EQL, Y: x}
+			p.compileCond(w, cond, bodies[i], next)
+			_, _ = fmt.Fprintf(w, "\n%s:\n", next)
+		}
+	}
+	_, _ = fmt.Fprintf(w, "\tbr label %%%s\n", defaultTo)
+}
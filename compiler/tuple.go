@@ -0,0 +1,137 @@
+package compiler
+
+import (
+	"fmt"
+	"io"
+	"tiny-go/ast"
+	"tiny-go/token"
+	"tiny-go/types"
+)
+
+// 多个返回值在 LLVM 中作为一个结构体返回, 如 func() (int, float) 返回 { i32, float },
+// 调用方通过 extractvalue 取出每个值. 命名返回值是函数的局部变量, 在 return 块中组合为返回值.
+
+// resultTypes 获取函数返回值的类型列表
+func resultTypes(sig *types.Signature) []types.Type {
+	switch result := sig.Result.(type) {
+	case nil:
+		return nil
+	case *types.Tuple:
+		return result.Types
+	default:
+		return []types.Type{result}
+	}
+}
+
+// compileValues 编译赋值或 return 右边的值, 返回每个值和它的类型
+func (p *Compiler) compileValues(w io.Writer, exprs []ast.Expr) (values []string, typeList []types.Type) {
+	if len(exprs) == 1 {
+		if tuple, ok := p.exprType(exprs[0]).(*types.Tuple); ok {
+			value := p.compileExpr(w, exprs[0])
+			for i, typ := range tuple.Types {
+				localName := p.genId()
+				_, _ = fmt.Fprintf(w, "\t%s = extractvalue %s %s, %d\n", localName, llType(tuple), value, i)
+				values = append(values, localName)
+				typeList = append(typeList, typ)
+			}
+			return values, typeList
+		}
+	}
+	for _, expr := range exprs {
+		typeList = append(typeList, p.exprType(expr))
+		values = append(values, p.compileExpr(w, expr))
+	}
+	return values, typeList
+}
+
+// compileAssignValues 编译赋值语句右边的值, 支持 v, ok := x.(T), v, ok := m[k] 和 v, ok := <-ch
+func (p *Compiler) compileAssignValues(w io.Writer, stmt *ast.AssignStmt) (values []string, typeList []types.Type) {
+	if len(stmt.Target) == 2 && len(stmt.Value) == 1 {
+		if assert, ok := stmt.Value[0].(*ast.TypeAssertExpr); ok {
+			value, ok := p.compileTypeAssert(w, assert, true)
+			return []string{value, ok}, []types.Type{p.exprType(assert), types.Typ[types.UntypedBool]}
+		}
+		if index, ok := stmt.Value[0].(*ast.IndexExpr); ok && p.isMapIndex(index) {
+			value, ok := p.compileMapIndex(w, index, true)
+			return []string{value, ok}, []types.Type{p.exprType(index), types.Typ[types.UntypedBool]}
+		}
+		if recv, ok := stmt.Value[0].(*ast.UnaryExpr); ok && recv.Op == token.ARROW {
+			value, ok := p.compileChanRecv(w, recv, true)
+			return []string{value, ok}, []types.Type{p.exprType(recv), types.Typ[types.UntypedBool]}
+		}
+	}
+	return p.compileValues(w, stmt.Value)
+}
+
+// declareResults 为命名返回值分配局部变量, 初始值为零值
+func (p *Compiler) declareResults(w io.Writer, ftype *ast.FuncType, sig *types.Signature) {
+	if ftype.Results == nil || len(ftype.Results.List) == 0 || ftype.Results.List[0].Name == nil {
+		return
+	}
+	for i, typ := range resultTypes(sig) {
+		name := ftype.Results.List[i].Name
+		var mangledName = fmt.Sprintf("%%local_%s.pos.%d", name.Name, name.NamePos)
+		p.allocLocal(w, p.info.Defs[name], mangledName, typ)
+		_, _ = fmt.Fprintf(w, "\tstore %s %s, %s* %s\n", llType(typ), zeroValue(typ), llType(typ), mangledName)
+		p.fn.results = append(p.fn.results, mangledName)
+	}
+}
+
+func (p *Compiler) compileStmtReturn(w io.Writer, stmt *ast.ReturnStmt) {
+	results := resultTypes(p.fn.sig)
+
+	// 命名返回值的函数可以使用不带值的 return
+	if len(stmt.Results) == 0 && p.fn.results != nil {
+		_, _ = fmt.Fprintf(w, "\tbr label %%return\n")
+		return
+	}
+
+	values, typeList := p.compileValues(w, stmt.Results)
+	for i, typ := range results {
+		values[i] = p.convert(w, values[i], typeList[i], typ)
+	}
+
+	switch {
+	case p.fn.results != nil:
+		for i, typ := range results {
+			_, _ = fmt.Fprintf(w, "\tstore %s %s, %s* %s\n", llType(typ), values[i], llType(typ), p.fn.results[i])
+		}
+	case len(results) > 0:
+		value := p.packResults(w, values)
+		_, _ = fmt.Fprintf(w, "\tstore %s %s, %s* %%ret.value\n", llType(p.fn.sig.Result), value, llType(p.fn.sig.Result))
+	}
+	_, _ = fmt.Fprintf(w, "\tbr label %%return\n")
+}
+
+// genLoadResults 在 return 块中读取命名返回值, 组合为函数的返回值
+func (p *Compiler) genLoadResults(w io.Writer) string {
+	var values []string
+	for i, typ := range resultTypes(p.fn.sig) {
+		localName := p.genId()
+		_, _ = fmt.Fprintf(w, "\t%s = load %s, %s* %s, align %d\n", localName, llType(typ), llType(typ), p.fn.results[i], types.Alignof(typ))
+		values = append(values, localName)
+	}
+	return p.packResults(w, values)
+}
+
+// packResults 把返回值组合为函数的返回值, 只有一个返回值时直接返回
+func (p *Compiler) packResults(w io.Writer, values []string) string {
+	if len(values) == 1 {
+		return values[0]
+	}
+	typ := llType(p.fn.sig.Result)
+	agg := "undef"
+	for i, value := range values {
+		localName := p.genId()
+		_, _ = fmt.Fprintf(w, "\t%s = insertvalue %s %s, %s %s, %d\n",
+			localName, typ, agg, llType(p.fn.sig.Result.(*types.Tuple).Types[i]), value, i)
+		agg = localName
+	}
+	return agg
+}
+
+// isBlank 判断表达式是否为空白标识符 _
+func isBlank(expr ast.Expr) bool {
+	ident, ok := expr.(*ast.Ident)
+	return ok && ident.Name == "_"
+}
@@ -0,0 +1,90 @@
+package compiler
+
+import (
+	"fmt"
+	"strings"
+	"tiny-go/types"
+)
+
+// llType 获取类型对应的 LLVM 类型
+func llType(t types.Type) string {
+	switch t := t.(type) {
+	case *types.Basic:
+		if types.IsUntyped(t) && t != types.Typ[types.UntypedNil] {
+			return llType(types.Default(t))
+		}
+		switch {
+		case t.Kind == types.String:
+			return "%string"
+		case t.Kind == types.Bool:
+			return "i1"
+		case t.Kind == types.Float:
+			return "float"
+		case t.Kind == types.Float64:
+			return "double"
+		case types.IsInteger(t):
+			return fmt.Sprintf("i%d", types.Sizeof(t)*8)
+		}
+	case *types.Array:
+		return fmt.Sprintf("[%d x %s]", t.Len, llType(t.Elem))
+	case *types.Slice:
+		return fmt.Sprintf("{ %s*, i32, i32 }", llType(t.Elem))
+	case *types.Pointer:
+		return llType(t.Elem) + "*"
+	case *types.Map:
+		// 运行时哈希表的指针, 见 map.go
+		return "i8*"
+	case *types.Chan:
+		// 运行时 channel 的指针, 见 chan.go
+		return "i8*"
+	case *types.Struct:
+		var fields []string
+		for _, f := range t.Fields {
+			fields = append(fields, llType(f.Type))
+		}
+		if len(fields) == 0 {
+			return "{}"
+		}
+		return "{ " + strings.Join(fields, ", ") + " }"
+	case *types.Tuple:
+		var typeList []string
+		for _, typ := range t.Types {
+			typeList = append(typeList, llType(typ))
+		}
+		return "{ " + strings.Join(typeList, ", ") + " }"
+	case *types.Interface:
+		// itab 和数据指针, 见 interface.go
+		return "{ i8*, i8* }"
+	case *types.Signature:
+		// 代码指针和环境指针, 见 closure.go
+		return "{ i8*, i8* }"
+	case *types.Named:
+		if _, ok := t.Underlying.(*types.Struct); ok {
+			return fmt.Sprintf("%%tiny_go_%s_%s", t.Pkg, t.Name)
+		}
+		return llType(t.Underlying)
+	}
+	panic(fmt.Sprintf("unknown type: %v", t))
+}
+
+// zeroValue 获取类型的零值
+func zeroValue(t types.Type) string {
+	switch t := t.(type) {
+	case *types.Basic:
+		switch t.Kind {
+		case types.Float, types.Float64, types.UntypedFloat:
+			return "0.0"
+		case types.String, types.UntypedString:
+			return "zeroinitializer"
+		case types.Bool, types.UntypedBool:
+			return "false"
+		}
+	case *types.Array, *types.Slice, *types.Struct, *types.Tuple, *types.Interface, *types.Signature:
+		return "zeroinitializer"
+	case *types.Pointer, *types.Map, *types.Chan:
+		return "null"
+	case *types.Named:
+		return zeroValue(t.Underlying)
+	}
+	return "0"
+}
@@ -0,0 +1,114 @@
+package compiler
+
+import (
+	"fmt"
+	"io"
+	"tiny-go/ast"
+	"tiny-go/types"
+)
+
+// 类型 switch: 接口的值只求值一次, 依次比较每个 case 的类型. 具体类型比较类型描述符,
+// 接口类型在运行时查找 itab, nil 判断 itab 是否为 null. 每个分支中 x := v.(type) 声明的变量
+// 在只有一个类型的分支中为该类型, 否则为 v 的类型.
+
+// compileStmtTypeSwitch 编译类型 switch 语句, label 为 switch 语句的标号
+func (p *Compiler) compileStmtTypeSwitch(w io.Writer, stmt *ast.TypeSwitchStmt, label string) {
+	switchPos := fmt.Sprintf("%d", p.posLine(stmt.Switch))
+	switchEnd := p.genLabelId("switch.end.line" + switchPos)
+
+	clauses, defaultIndex := p.caseClauses(stmt.Body)
+	bodies := make([]string, len(clauses))
+	for i, clause := range clauses {
+		name := "switch.case.line"
+		if clause.List == nil {
+			name = "switch.default.line"
+		}
+		bodies[i] = p.genLabelId(name + fmt.Sprintf("%d", p.posLine(clause.Case)))
+	}
+	defaultTo := switchEnd
+	if defaultIndex >= 0 {
+		defaultTo = bodies[defaultIndex]
+	}
+
+	if stmt.Init != nil {
+		p.compileStmt(w, stmt.Init)
+	}
+
+	// x := v.(type) 或 v.(type)
+	var assert *ast.TypeAssertExpr
+	switch guard := stmt.Assign.(type) {
+	case *ast.AssignStmt:
+		assert = guard.Value[0].(*ast.TypeAssertExpr)
+	case *ast.ExprStmt:
+		assert = guard.X.(*ast.TypeAssertExpr)
+	}
+
+	// 每个 case 的类型, nil 为 nil
+	caseTypes := make([][]types.Type, len(clauses))
+	for i, clause := range clauses {
+		for _, x := range clause.List {
+			var typ types.Type
+			if tv := p.info.Types[x]; !tv.IsNil() {
+				typ = tv.Type
+			}
+			caseTypes[i] = append(caseTypes[i], typ)
+		}
+	}
+
+	value := p.compileExpr(w, assert.X)
+	itab, data := p.ifaceParts(w, value)
+	dynType := p.dynamicType(w, itab)
+	for i, clause := range clauses {
+		for j, x := range clause.List {
+			cond := p.genId()
+			switch typ := caseTypes[i][j]; {
+			case typ == nil:
+				_, _ = fmt.Fprintf(w, "\t%s = icmp eq i8* %s, null\n", cond, itab)
+			case types.IsInterface(typ):
+				newItab := p.genId()
+				_, _ = fmt.Fprintf(w, "\t%s = call i8* %s(i8* %s)\n", newItab, p.itabLookup(types.Underlying(typ).(*types.Interface)), dynType)
+				_, _ = fmt.Fprintf(w, "\t%s = icmp ne i8* %s, null\n", cond, newItab)
+			default:
+				_, _ = fmt.Fprintf(w, "\t%s = icmp eq i8* %s, %s\n", cond, dynType, p.typeDesc(typ))
+			}
+			next := p.genLabelId(fmt.Sprintf("switch.next.line%d", p.posLine(x.Pos())))
+			_, _ = fmt.Fprintf(w, "\tbr i1 %s, label %%%s, label %%%s\n", cond, bodies[i], next)
+			_, _ = fmt.Fprintf(w, "\n%s:\n", next)
+		}
+	}
+	_, _ = fmt.Fprintf(w, "\tbr label %%%s\n", defaultTo)
+
+	// break 跳出 switch, continue 跳转到外层的循环
+	p.fn.branches = append(p.fn.branches, &branchTarget{
+		label:   label,
+		breakTo: switchEnd,
+	})
+	defer func() { p.fn.branches = p.fn.branches[:len(p.fn.branches)-1] }()
+
+	// 每个分支中 x := v.(type) 声明的变量由类型检查记录在 Implicits 中
+	for i, clause := range clauses {
+		_, _ = fmt.Fprintf(w, "\n%s:\n", bodies[i])
+		if obj := p.info.Implicits[clause]; obj != nil {
+			typ, symbolValue := obj.Type, value
+			if len(caseTypes[i]) == 1 && caseTypes[i][0] != nil {
+				if caseIface, ok := types.Underlying(typ).(*types.Interface); ok {
+					newItab := p.genId()
+					_, _ = fmt.Fprintf(w, "\t%s = call i8* %s(i8* %s)\n", newItab, p.itabLookup(caseIface), dynType)
+					symbolValue = p.makeIface(w, newItab, data)
+				} else {
+					symbolValue = p.fromIface(w, data, typ)
+				}
+			}
+			var mangledName = fmt.Sprintf("%%local_%s.pos.%d", obj.Name, clause.Colon)
+			p.allocLocal(w, obj, mangledName, typ)
+			_, _ = fmt.Fprintf(w, "\tstore %s %s, %s* %s\n", llType(typ), symbolValue, llType(typ), mangledName)
+		}
+		for _, x := range clause.Body {
+			p.compileStmt(w, x)
+		}
+		_, _ = fmt.Fprintf(w, "\tbr label %%%s\n", switchEnd)
+	}
+
+	// end
+	_, _ = fmt.Fprintf(w, "\n%s:\n", switchEnd)
+}
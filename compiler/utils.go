@@ -4,8 +4,16 @@ import (
 	"fmt"
 	"tiny-go/ast"
 	"tiny-go/token"
+	"tiny-go/types"
 )
 
+func (p *Compiler) posString(pos token.Pos) string {
+	if p.file != nil {
+		return pos.Position(p.file.FileName, p.file.Source).String()
+	}
+	return fmt.Sprintf("%d", pos)
+}
+
 func (p *Compiler) posLine(pos token.Pos) int {
 	if p.file != nil && p.file.Source != "" {
 		line := pos.Position(p.file.FileName, p.file.Source).Line
@@ -14,100 +22,142 @@ func (p *Compiler) posLine(pos token.Pos) int {
 	return 0
 }
 
-// getType 用于获取表达式类型
-func getType(expr ast.Expr) (string, bool) {
-	switch expr.(type) {
-	case *ast.BinaryExpr:
-		return getType(expr.(*ast.BinaryExpr).X)
-	case *ast.UnaryExpr:
-		return getType(expr.(*ast.UnaryExpr).X)
-	case *ast.CallExpr:
-		return "i32", true
-	case *ast.Int:
-		return "i32", true
-	case *ast.Char:
-		return "i8", true
-	case *ast.Float:
-		return "float", true
-	case *ast.Ident:
-		return expr.(*ast.Ident).Name, false
-	default:
-		panic(fmt.Sprintf("unknown: %[1]T, %[1]v", expr))
+// exprType 获取表达式的类型, 类型表达式为它表示的类型. 类型检查已经记录了所有表达式的类型,
+// 多返回值的函数调用的类型为 *types.Tuple
+func (p *Compiler) exprType(expr ast.Expr) types.Type {
+	tv, ok := p.info.Types[expr]
+	if !ok {
+		panic(fmt.Sprintf("%s: no type for %T", p.posString(expr.Pos()), expr))
+	}
+	return tv.Type
+}
+
+// objectOf 获取标识符声明或引用的对象
+func (p *Compiler) objectOf(ident *ast.Ident) *types.Object {
+	if obj := p.info.Defs[ident]; obj != nil {
+		return obj
 	}
+	return p.info.Uses[ident]
+}
+
+// calleeObject 获取按名字调用的函数 f(...) 中 f 对应的对象, 不是按名字调用时返回 nil
+func (p *Compiler) calleeObject(expr *ast.CallExpr) *types.Object {
+	if expr.Fun != nil || expr.Pkg != nil || expr.Recv != nil {
+		return nil
+	}
+	return p.info.Uses[expr.FuncName]
+}
+
+// paramName 参数的名字, 没有名字的参数 (如 func (Point) M() 的接收者) 视为 _
+func paramName(field *ast.Field) string {
+	if field.Name == nil {
+		return "_"
+	}
+	return field.Name.Name
 }
 
 // opType 用于获取表达式操作指令
-func opType(op token.TokenType, typ string) string {
+func opType(op token.TokenType, typ types.Type) string {
 	switch op {
 	case token.ADD:
-		switch typ {
-		case "float":
+		switch {
+		case types.IsFloat(typ):
 			return "fadd"
 		default:
 			return "add"
 		}
 	case token.SUB:
-		switch typ {
-		case "float":
+		switch {
+		case types.IsFloat(typ):
 			return "fsub"
 		default:
 			return "sub"
 		}
 	case token.MUL:
-		switch typ {
-		case "float":
+		switch {
+		case types.IsFloat(typ):
 			return "fmul"
 		default:
 			return "mul"
 		}
 	case token.DIV:
-		switch typ {
-		case "float":
+		switch {
+		case types.IsFloat(typ):
 			return "fdiv"
+		case types.IsUnsigned(typ):
+			return "udiv"
 		default:
-			return "div"
+			return "sdiv"
 		}
 	case token.MOD:
-		return "srem"
+		switch {
+		case types.IsFloat(typ):
+			return "frem"
+		case types.IsUnsigned(typ):
+			return "urem"
+		default:
+			return "srem"
+		}
+	case token.BIT_AND:
+		return "and"
+	case token.BIT_OR:
+		return "or"
+	case token.XOR:
+		return "xor"
+	case token.SHL:
+		return "shl"
+	case token.SHR:
+		if types.IsUnsigned(typ) {
+			return "lshr"
+		}
+		return "ashr"
 	case token.EQL:
-		switch typ {
-		case "float":
+		switch {
+		case types.IsFloat(typ):
 			return "fcmp oeq"
 		default:
 			return "icmp eq"
 		}
 	case token.NEQ:
-		switch typ {
-		case "float":
+		switch {
+		case types.IsFloat(typ):
 			return "fcmp une"
 		default:
 			return "icmp ne"
 		}
 	case token.GTR:
-		switch typ {
-		case "float":
+		switch {
+		case types.IsFloat(typ):
 			return "fcmp ogt"
+		case types.IsUnsigned(typ):
+			return "icmp ugt"
 		default:
 			return "icmp sgt"
 		}
 	case token.GEQ:
-		switch typ {
-		case "float":
+		switch {
+		case types.IsFloat(typ):
 			return "fcmp oge"
+		case types.IsUnsigned(typ):
+			return "icmp uge"
 		default:
 			return "icmp sge"
 		}
 	case token.LSS:
-		switch typ {
-		case "float":
+		switch {
+		case types.IsFloat(typ):
 			return "fcmp olt"
+		case types.IsUnsigned(typ):
+			return "icmp ult"
 		default:
 			return "icmp slt"
 		}
 	case token.LEQ:
-		switch typ {
-		case "float":
+		switch {
+		case types.IsFloat(typ):
 			return "fcmp ole"
+		case types.IsUnsigned(typ):
+			return "icmp ule"
 		default:
 			return "icmp sle"
 		}
@@ -0,0 +1,48 @@
+package compiler
+
+import (
+	"fmt"
+	"io"
+	"tiny-go/ast"
+	"tiny-go/types"
+)
+
+// 可变参数 ...T 在函数中是 []T 类型的参数. 调用时多出的参数打包到新分配的切片中,
+// f(xs...) 直接把 xs 作为最后一个参数, 没有多出的参数时传递 nil 切片.
+
+// compileArgs 计算调用参数, 返回转换为参数类型后的值
+func (p *Compiler) compileArgs(w io.Writer, expr *ast.CallExpr, sig *types.Signature) []string {
+	values, typeList := p.compileValues(w, expr.Args)
+
+	var args []string
+	for i, param := range sig.Params {
+		if sig.Variadic && i == len(sig.Params)-1 && !expr.Ellipsis.IsValid() {
+			args = append(args, p.packVariadic(w, param.(*types.Slice), values[i:], typeList[i:]))
+			break
+		}
+		args = append(args, p.convert(w, values[i], typeList[i], param))
+	}
+	return args
+}
+
+// packVariadic 把多出的参数打包为 typ 类型的切片
+func (p *Compiler) packVariadic(w io.Writer, typ *types.Slice, values []string, typeList []types.Type) string {
+	if len(values) == 0 {
+		return zeroValue(typ)
+	}
+	arrayType := fmt.Sprintf("[%d x %s]", len(values), llType(typ.Elem))
+	raw := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = call i8* @tiny_go_builtin_alloc(i32 %s)\n", raw, llTypeSize(arrayType))
+	data := p.genId()
+	_, _ = fmt.Fprintf(w, "\t%s = bitcast i8* %s to %s*\n", data, raw, llType(typ.Elem))
+
+	elemType := llType(typ.Elem)
+	for i, value := range values {
+		value = p.convert(w, value, typeList[i], typ.Elem)
+		ptr := p.genId()
+		_, _ = fmt.Fprintf(w, "\t%s = getelementptr inbounds %s, %s* %s, i32 %d\n", ptr, elemType, elemType, data, i)
+		_, _ = fmt.Fprintf(w, "\tstore %s %s, %s* %s, align %d\n", elemType, value, elemType, ptr, types.Alignof(typ.Elem))
+	}
+	n := fmt.Sprint(len(values))
+	return p.makeSlice(w, typ, data, n, n)
+}
@@ -3,6 +3,7 @@ package lexer
 import (
 	"fmt"
 	gotoken "go/token"
+	"strings"
 	"tiny-go/token"
 )
 
@@ -82,13 +83,15 @@ func (p *Lexer) run() (tokens []token.Token) {
 
 		switch {
 		case r == '\n':
-			p.src.IgnoreToken()
+			// 自动插入的分号位于换行处, 字面值为 "\n"
 			if len(p.tokens) > 0 {
 				switch p.tokens[len(p.tokens)-1].Type {
-				case token.RPAREN, token.IDENT, token.INT, token.RETURN, token.FLOAT:
+				case token.RPAREN, token.RBRACK, token.IDENT, token.INT, token.RETURN, token.FLOAT,
+					token.CHAR, token.STRING, token.BREAK, token.CONTINUE, token.FALLTHROUGH, token.INC, token.DEC:
 					p.emit(token.SEMICOLON)
 				}
 			}
+			p.src.IgnoreToken()
 		case isSpace(r):
 			p.src.IgnoreToken()
 		case isAlpha(r):
@@ -107,11 +110,33 @@ func (p *Lexer) run() (tokens []token.Token) {
 			typ := p.src.AcceptRun(digits)
 			p.emit(typ)
 		case r == '+': // +, +=, ++
-			p.emit(token.ADD)
+			switch p.src.Read() {
+			case '=':
+				p.emit(token.ADD_ASSIGN)
+			case '+':
+				p.emit(token.INC)
+			default:
+				p.src.Unread()
+				p.emit(token.ADD)
+			}
 		case r == '-': // -, -=, --
-			p.emit(token.SUB)
+			switch p.src.Read() {
+			case '=':
+				p.emit(token.SUB_ASSIGN)
+			case '-':
+				p.emit(token.DEC)
+			default:
+				p.src.Unread()
+				p.emit(token.SUB)
+			}
 		case r == '*': // *, *=
-			p.emit(token.MUL)
+			switch p.src.Read() {
+			case '=':
+				p.emit(token.MUL_ASSIGN)
+			default:
+				p.src.Unread()
+				p.emit(token.MUL)
+			}
 		case r == '/': // /, //, /*, /=
 			peek := p.src.Peek()
 			if peek == '/' {
@@ -142,11 +167,20 @@ func (p *Lexer) run() (tokens []token.Token) {
 						return
 					}
 				}
+			} else if peek == '=' {
+				p.src.Read()
+				p.emit(token.DIV_ASSIGN)
 			} else {
 				p.emit(token.DIV)
 			}
-		case r == '%': // %
-			p.emit(token.MOD)
+		case r == '%': // %, %=
+			switch p.src.Read() {
+			case '=':
+				p.emit(token.MOD_ASSIGN)
+			default:
+				p.src.Unread()
+				p.emit(token.MOD)
+			}
 		case r == '=': // =,==
 			switch p.src.Read() {
 			case '=':
@@ -161,21 +195,39 @@ func (p *Lexer) run() (tokens []token.Token) {
 				p.emit(token.NEQ)
 			default:
 				p.src.Unread()
-				//p.errorf("unrecognized character: %#u", r)
+				//p.errorf("unrecognized character: %#U", r)
 				p.emit(token.NOT)
 			}
-		case r == '<': // <,<=
+		case r == '<': // <, <=, <<, <<=, <-
 			switch p.src.Read() {
 			case '=':
 				p.emit(token.LEQ)
+			case '-':
+				p.emit(token.ARROW)
+			case '<':
+				switch p.src.Read() {
+				case '=':
+					p.emit(token.SHL_ASSIGN)
+				default:
+					p.src.Unread()
+					p.emit(token.SHL)
+				}
 			default:
 				p.src.Unread()
 				p.emit(token.LSS)
 			}
-		case r == '>': // >,>=
+		case r == '>': // >, >=, >>, >>=
 			switch p.src.Read() {
 			case '=':
 				p.emit(token.GEQ)
+			case '>':
+				switch p.src.Read() {
+				case '=':
+					p.emit(token.SHR_ASSIGN)
+				default:
+					p.src.Unread()
+					p.emit(token.SHR)
+				}
 			default:
 				p.src.Unread()
 				p.emit(token.GTR)
@@ -185,33 +237,57 @@ func (p *Lexer) run() (tokens []token.Token) {
 			case '=':
 				p.emit(token.DEFINE)
 			default:
-				//p.errorf("unrecognized character: %#u", r)
+				p.src.Unread()
 				p.emit(token.COLON)
 			}
-		case r == '&': // &&
+		case r == '&': // &, &&, &=, &^, &^=
 			switch p.src.Read() {
 			case '&':
 				p.emit(token.AND)
+			case '=':
+				p.emit(token.AND_ASSIGN)
+			case '^':
+				switch p.src.Read() {
+				case '=':
+					p.emit(token.AND_NOT_ASSIGN)
+				default:
+					p.src.Unread()
+					p.emit(token.AND_NOT)
+				}
 			default:
-				p.errorf("unrecognized character: %#u", r)
+				p.src.Unread()
+				p.emit(token.BIT_AND)
 			}
-		case r == '|':
+		case r == '|': // |, ||, |=
 			switch p.src.Read() {
 			case '|':
 				p.emit(token.OR)
+			case '=':
+				p.emit(token.OR_ASSIGN)
+			default:
+				p.src.Unread()
+				p.emit(token.BIT_OR)
+			}
+		case r == '^': // ^, ^=
+			switch p.src.Read() {
+			case '=':
+				p.emit(token.XOR_ASSIGN)
 			default:
-				p.errorf("unrecognized character: %#u", r)
+				p.src.Unread()
+				p.emit(token.XOR)
 			}
 		case r == '"':
 			p.lexQuote()
 		case r == '\'':
-			if p.src.Read() == '\\' {
+			p.lexChar()
+		case r == '.': // ., ...
+			if strings.HasPrefix(p.src.input[p.src.pos:], "..") {
+				p.src.Read()
 				p.src.Read()
+				p.emit(token.ELLIPSIS)
+			} else {
+				p.emit(token.PERIOD)
 			}
-			p.src.Read()
-			p.emit(token.CHAR)
-		case r == '.':
-			p.emit(token.PERIOD)
 		case r == '(':
 			p.emit(token.LPAREN)
 		case r == '[':
@@ -250,6 +326,22 @@ func (p *Lexer) lexQuote() {
 	}
 }
 
+// lexChar 读到字符字面值的结束引号, 转义序列如 '\377' 和 '\u00e9' 可以有多个字符
+func (p *Lexer) lexChar() {
+	for {
+		switch p.src.Read() {
+		case rune(token.EOF), '\n':
+			p.errorf("unterminated char literal")
+			return
+		case '\\':
+			p.src.Read()
+		case '\'':
+			p.emit(token.CHAR)
+			return
+		}
+	}
+}
+
 func Lex(name, input string) (tokens, comments []token.Token) {
 	l := NewLexer(name, input)
 	tokens = l.Tokens()
@@ -29,8 +29,11 @@ func main() {
 			Usage: "compile and run tGo program",
 			Action: func(c *cli.Context) error {
 				ctx := build.NewContext(buildOptions(c))
-				output, _ := ctx.Run(c.Args().First(), nil)
+				output, err := ctx.Run(c.Args().First(), nil)
 				fmt.Print(string(output))
+				if err != nil {
+					fmt.Println(err)
+				}
 				return nil
 			},
 		},
@@ -80,7 +83,11 @@ func main() {
 			Usage: "parse tGo source code and print llvm-ir",
 			Action: func(c *cli.Context) error {
 				ctx := build.NewContext(buildOptions(c))
-				ll, _ := ctx.ASM(c.Args().First(), nil)
+				ll, err := ctx.ASM(c.Args().First(), nil)
+				if err != nil {
+					fmt.Println(err)
+					os.Exit(1)
+				}
 				fmt.Println(ll)
 				return nil
 			},
@@ -4,6 +4,7 @@ import (
 	"strconv"
 	"tiny-go/ast"
 	"tiny-go/token"
+	"unicode/utf8"
 )
 
 func (p *Parser) parseExpr() ast.Expr {
@@ -38,31 +39,155 @@ func (p *Parser) parseExprBinary(prec int) ast.Expr {
 			Y:     y,
 		}
 	}
-	return nil
 }
 
 func (p *Parser) parseExprUnary() ast.Expr {
 	if _, ok := p.AcceptToken(token.ADD); ok {
-		return p.parseExprPrimary()
+		return p.parseExprUnary()
 	}
-	if tok, ok := p.AcceptToken(token.SUB, token.NOT); ok {
+	if tok, ok := p.AcceptToken(token.SUB, token.NOT, token.BIT_AND, token.XOR); ok {
 		return &ast.UnaryExpr{
 			OpPos: tok.Pos,
 			Op:    tok.Type,
-			X:     p.parseExprPrimary(),
+			X:     p.parseExprUnary(),
+		}
+	}
+	// <-ch 或 channel 类型 <-chan T
+	if tok, ok := p.AcceptToken(token.ARROW); ok {
+		if p.PeekToken().Type == token.CHAN {
+			p.UnreadToken()
+			return p.parseType()
+		}
+		return &ast.UnaryExpr{
+			OpPos: tok.Pos,
+			Op:    tok.Type,
+			X:     p.parseExprUnary(),
+		}
+	}
+	// *p 或指针类型 *T
+	if tok, ok := p.AcceptToken(token.MUL); ok {
+		return &ast.StarExpr{
+			Star: tok.Pos,
+			X:    p.parseExprUnary(),
 		}
 	}
 	return p.parseExprPrimary()
 }
 
 func (p *Parser) parseExprPrimary() ast.Expr {
+	x := p.parseExprOperand()
+	for {
+		// f(...)(...), func() { ... }()
+		if p.PeekToken().Type == token.LPAREN {
+			tokLparen, args, ellipsis, tokRparen := p.parseCallArgs()
+			x = &ast.CallExpr{
+				Fun:      x,
+				Lparen:   tokLparen.Pos,
+				Args:     args,
+				Ellipsis: ellipsis,
+				Rparen:   tokRparen.Pos,
+			}
+			continue
+		}
+
+		// x.sel, x.method(...), x.(T)
+		if _, ok := p.AcceptToken(token.PERIOD); ok {
+			if p.PeekToken().Type == token.LPAREN {
+				x = p.parseTypeAssert(x)
+				continue
+			}
+			tokSel := p.MustAcceptToken(token.IDENT)
+			if p.PeekToken().Type == token.LPAREN {
+				tokLparen, args, ellipsis, tokRparen := p.parseCallArgs()
+				x = &ast.CallExpr{
+					Recv:     x,
+					FuncName: &ast.Ident{NamePos: tokSel.Pos, Name: tokSel.Literal},
+					Lparen:   tokLparen.Pos,
+					Args:     args,
+					Ellipsis: ellipsis,
+					Rparen:   tokRparen.Pos,
+				}
+				continue
+			}
+			x = &ast.SelectorExpr{
+				X: x,
+				Sel: &ast.Ident{
+					NamePos: tokSel.Pos,
+					Name:    tokSel.Literal,
+				},
+			}
+			continue
+		}
+
+		// x[index], x[low:high]
+		tokLbrack, ok := p.AcceptToken(token.LBRACK)
+		if !ok {
+			return x
+		}
+		p.exprLev++
+		var index ast.Expr
+		if p.PeekToken().Type != token.COLON {
+			index = p.parseExpr()
+		}
+		if _, ok := p.AcceptToken(token.COLON); ok {
+			var high ast.Expr
+			if p.PeekToken().Type != token.RBRACK {
+				high = p.parseExpr()
+			}
+			tokRbrack := p.MustAcceptToken(token.RBRACK)
+			p.exprLev--
+			x = &ast.SliceExpr{
+				X:      x,
+				Lbrack: tokLbrack.Pos,
+				Low:    index,
+				High:   high,
+				Rbrack: tokRbrack.Pos,
+			}
+			continue
+		}
+		if index == nil {
+			p.errorf(tokLbrack.Pos, "expected operand")
+		}
+		tokRbrack := p.MustAcceptToken(token.RBRACK)
+		p.exprLev--
+		x = &ast.IndexExpr{
+			X:      x,
+			Lbrack: tokLbrack.Pos,
+			Index:  index,
+			Rbrack: tokRbrack.Pos,
+		}
+	}
+}
+
+func (p *Parser) parseExprOperand() ast.Expr {
 	if _, ok := p.AcceptToken(token.LPAREN); ok {
+		p.exprLev++
 		expr := p.parseExpr()
+		p.exprLev--
 		p.MustAcceptToken(token.RPAREN)
 		return expr
 	}
 
 	switch tok := p.PeekToken(); tok.Type {
+	case token.INTERFACE: // 类型 switch 中的接口类型
+		return p.parseType()
+	case token.CHAN: // 类型作为参数, 如 make(chan int)
+		return p.parseType()
+	case token.FUNC: // 函数字面值 func(x int) int { ... } 或函数类型
+		typ := p.parseFuncType()
+		if p.PeekToken().Type == token.LBRACE {
+			return &ast.FuncLit{
+				Type: typ,
+				Body: p.parseStmtBlock(),
+			}
+		}
+		return typ
+	case token.LBRACK, token.MAP: // 类型作为参数, 如 make([]int, n) 和 make(map[string]int)
+		typ := p.parseType()
+		if p.PeekToken().Type == token.LBRACE {
+			return p.parseExprCompositeLit(typ)
+		}
+		return typ
 	case token.IDENT: // call
 		p.ReadToken()
 		nextTok := p.PeekToken()
@@ -75,39 +200,56 @@ func (p *Parser) parseExprPrimary() ast.Expr {
 			return p.parseExprSelector()
 		default:
 			p.MustAcceptToken(token.IDENT)
-			return &ast.Ident{
+			ident := &ast.Ident{
 				NamePos: tok.Pos,
 				Name:    tok.Literal,
 			}
+			// T{...}, 在 if/for 的头部中需要加括号
+			if nextTok.Type == token.LBRACE && p.exprLev >= 0 {
+				return p.parseExprCompositeLit(ident)
+			}
+			return ident
 		}
 	case token.INT:
 		tokInt := p.MustAcceptToken(token.INT)
-		value, _ := strconv.Atoi(tokInt.Literal)
 		return &ast.Int{
 			ValuePos: tokInt.Pos,
 			ValueEnd: tokInt.Pos + token.Pos(len(tokInt.Literal)),
-			Value:    value,
+			Value:    tokInt.Literal,
 		}
 	case token.FLOAT:
 		tokFloat := p.MustAcceptToken(token.FLOAT)
-		value, _ := strconv.ParseFloat(tokFloat.Literal, 64)
 		return &ast.Float{
 			ValuePos: tokFloat.Pos,
 			ValueEnd: tokFloat.Pos + token.Pos(len(tokFloat.Literal)),
-			Value:    value,
+			Value:    tokFloat.Literal,
 		}
 	case token.CHAR:
 		tokChar := p.MustAcceptToken(token.CHAR)
-		var value int
-		if len(tokChar.Literal) == 3 {
-			value = int(tokChar.Literal[1])
-		} else {
-			value, _ =strconv.Atoi(tokChar.Literal[1:2])
+		value, err := strconv.Unquote(tokChar.Literal)
+		// 单字节的转义如 '\377' 直接取字节的值, 其他字符按 UTF-8 解码为 Unicode 码点
+		r, size := utf8.DecodeRuneInString(value)
+		if len(value) == 1 {
+			r = rune(value[0])
+		}
+		if err != nil || size == 0 || size != len(value) {
+			p.errorf(tokChar.Pos, "invalid char literal: %s", tokChar.Literal)
 		}
 		return &ast.Char{
 			ValuePos: tokChar.Pos,
-			ValueEnd: tokChar.Pos+token.Pos(len(tokChar.Literal)),
-			Value: value,
+			ValueEnd: tokChar.Pos + token.Pos(len(tokChar.Literal)),
+			Value:    int(r),
+		}
+	case token.STRING:
+		tokString := p.MustAcceptToken(token.STRING)
+		value, err := strconv.Unquote(tokString.Literal)
+		if err != nil {
+			p.errorf(tokString.Pos, "invalid string literal: %s", tokString.Literal)
+		}
+		return &ast.StringLit{
+			ValuePos: tokString.Pos,
+			ValueEnd: tokString.Pos + token.Pos(len(tokString.Literal)),
+			Value:    value,
 		}
 	default:
 		p.errorf(tok.Pos, "unknown tok: type=%v, lit=%q", tok.Type, tok.Literal)
@@ -117,31 +259,48 @@ func (p *Parser) parseExprPrimary() ast.Expr {
 
 func (p *Parser) parseExprCall() *ast.CallExpr {
 	tokIdent := p.MustAcceptToken(token.IDENT)
-	tokLparen := p.MustAcceptToken(token.LPAREN)
-	arg0 := p.parseExpr()
-	tokRparen := p.MustAcceptToken(token.RPAREN)
+	tokLparen, args, ellipsis, tokRparen := p.parseCallArgs()
 
 	return &ast.CallExpr{
 		FuncName: &ast.Ident{NamePos: tokIdent.Pos, Name: tokIdent.Literal},
 		Lparen:   tokLparen.Pos,
-		Args:     []ast.Expr{arg0},
+		Args:     args,
+		Ellipsis: ellipsis,
 		Rparen:   tokRparen.Pos,
 	}
 }
 
+// parseCallArgs parse: (arg, arg), (arg, args...), ellipsis 为 '...' 的位置
+func (p *Parser) parseCallArgs() (lparen token.Token, args []ast.Expr, ellipsis token.Pos, rparen token.Token) {
+	lparen = p.MustAcceptToken(token.LPAREN)
+	p.exprLev++
+	if p.PeekToken().Type != token.RPAREN {
+		args = p.parseExprList()
+		if tok, ok := p.AcceptToken(token.ELLIPSIS); ok {
+			ellipsis = tok.Pos
+		}
+	}
+	p.exprLev--
+	rparen = p.MustAcceptToken(token.RPAREN)
+	return
+}
+
 func (p *Parser) parseExprSelector() ast.Expr {
 	tokX := p.MustAcceptToken(token.IDENT)
 	_ = p.MustAcceptToken(token.PERIOD)
+
+	// x.(T)
+	if p.PeekToken().Type == token.LPAREN {
+		return p.parseTypeAssert(&ast.Ident{
+			NamePos: tokX.Pos,
+			Name:    tokX.Literal,
+		})
+	}
 	tokSel := p.MustAcceptToken(token.IDENT)
 
-	// pkg.fn(...)
+	// pkg.fn(...) 或 x.method(...), 由编译器根据 x 是否为包名区分
 	if nextTok := p.PeekToken(); nextTok.Type == token.LPAREN {
-		var arg0 ast.Expr
-		tokLparen := p.MustAcceptToken(token.LPAREN)
-		if tok := p.PeekToken(); tok.Type != token.RPAREN {
-			arg0 = p.parseExpr()
-		}
-		tokRparen := p.MustAcceptToken(token.RPAREN)
+		tokLparen, args, ellipsis, tokRparen := p.parseCallArgs()
 
 		return &ast.CallExpr{
 			Pkg: &ast.Ident{
@@ -152,9 +311,10 @@ func (p *Parser) parseExprSelector() ast.Expr {
 				NamePos: tokSel.Pos,
 				Name:    tokSel.Literal,
 			},
-			Lparen: tokLparen.Pos,
-			Args:   []ast.Expr{arg0},
-			Rparen: tokRparen.Pos,
+			Lparen:   tokLparen.Pos,
+			Args:     args,
+			Ellipsis: ellipsis,
+			Rparen:   tokRparen.Pos,
 		}
 	}
 
@@ -165,7 +325,57 @@ func (p *Parser) parseExprSelector() ast.Expr {
 		},
 		Sel: &ast.Ident{
 			NamePos: tokSel.Pos,
-			Name:    tokX.Literal,
+			Name:    tokSel.Literal,
 		},
 	}
 }
+
+// parseExprCompositeLit parse:
+// T{x, y}
+// T{name: x}
+func (p *Parser) parseExprCompositeLit(typ ast.Expr) *ast.CompositeLit {
+	tokLbrace := p.MustAcceptToken(token.LBRACE)
+	lit := &ast.CompositeLit{
+		Type:   typ,
+		Lbrace: tokLbrace.Pos,
+	}
+
+	p.exprLev++
+	for {
+		if tok, ok := p.AcceptToken(token.RBRACE); ok {
+			lit.Rbrace = tok.Pos
+			break
+		}
+
+		elt := p.parseExpr()
+		if tokColon, ok := p.AcceptToken(token.COLON); ok {
+			elt = &ast.KeyValueExpr{
+				Key:   elt,
+				Colon: tokColon.Pos,
+				Value: p.parseExpr(),
+			}
+		}
+		lit.Elts = append(lit.Elts, elt)
+
+		if _, ok := p.AcceptToken(token.COMMA); !ok {
+			lit.Rbrace = p.MustAcceptToken(token.RBRACE).Pos
+			break
+		}
+	}
+	p.exprLev--
+	return lit
+}
+
+// parseTypeAssert parse: x.(T), x.(type), '.' 已经被读取
+func (p *Parser) parseTypeAssert(x ast.Expr) *ast.TypeAssertExpr {
+	tokLparen := p.MustAcceptToken(token.LPAREN)
+	assert := &ast.TypeAssertExpr{
+		X:      x,
+		Lparen: tokLparen.Pos,
+	}
+	if _, ok := p.AcceptToken(token.TYPE); !ok {
+		assert.Type = p.parseType()
+	}
+	assert.Rparen = p.MustAcceptToken(token.RPAREN).Pos
+	return assert
+}
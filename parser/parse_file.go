@@ -24,6 +24,10 @@ func (p *Parser) parseFile() {
 			p.AcceptTokenList(token.SEMICOLON)
 		case token.IMPORT:
 			p.file.Imports = append(p.file.Imports, p.parseImport()...)
+		case token.TYPE:
+			p.file.Types = append(p.file.Types, p.parseStmtType())
+		case token.CONST:
+			p.file.Consts = append(p.file.Consts, p.parseStmtConst().Specs...)
 		case token.VAR:
 			p.file.Globals = append(p.file.Globals, p.parseStmtVar())
 		case token.FUNC:
@@ -7,54 +7,29 @@ import (
 
 func (p *Parser) parseFunc() *ast.FuncDecl {
 	// func main()
+	// func (p *Point) Move(dx int)
 	tokFunc := p.MustAcceptToken(token.FUNC)
+
+	var recv *ast.FieldList
+	if p.PeekToken().Type == token.LPAREN {
+		recv = p.parseParameters()
+	}
 	tokFuncIdent := p.MustAcceptToken(token.IDENT)
 
 	fn := &ast.FuncDecl{
 		FuncPos: tokFunc.Pos,
+		Recv:    recv,
 		NamePos: tokFuncIdent.Pos,
 		Name:    tokFuncIdent.Literal,
 		Type: &ast.FuncType{
-			Params: &ast.FieldList{},
+			Func: tokFunc.Pos,
 		},
 	}
 	// parse params
-	p.MustAcceptToken(token.LPAREN) // (
-	for {
-		// )
-		if _, ok := p.AcceptToken(token.RPAREN); ok {
-			break
-		}
-
-		// arg type, ...
-		tokArg := p.MustAcceptToken(token.IDENT)
-		tokTyp := p.MustAcceptToken(token.IDENT)
-
-		fn.Type.Params.List = append(fn.Type.Params.List, &ast.Field{
-			Name: &ast.Ident{
-				NamePos: tokArg.Pos,
-				Name:    tokArg.Literal,
-				Type:    LoopUp(tokTyp.Literal),
-			},
-			Type: &ast.Ident{
-				NamePos: tokTyp.Pos,
-				Name:    tokTyp.Literal,
-				Type:    LoopUp(tokTyp.Literal),
-			},
-		})
-	}
+	fn.Type.Params = p.parseParameters()
 
 	// result type
-	if _, ok := p.AcceptToken(token.LBRACE, token.SEMICOLON); ok {
-		p.UnreadToken()
-	} else {
-		tok := p.MustAcceptToken(token.IDENT)
-		fn.Type.Result = &ast.Ident{
-			NamePos: tok.Pos,
-			Name:    tok.Literal,
-			Type:    LoopUp(tok.Literal),
-		}
-	}
+	fn.Type.Results = p.parseResults()
 
 	// body: {}
 	if _, ok := p.AcceptToken(token.LBRACE); ok {
@@ -64,3 +39,85 @@ func (p *Parser) parseFunc() *ast.FuncDecl {
 
 	return fn
 }
+
+// parseResults parse:
+// (int, error)
+// int
+// 没有返回值时返回 nil
+func (p *Parser) parseResults() *ast.FieldList {
+	if tok := p.PeekToken(); tok.Type == token.LPAREN {
+		return p.parseParameters()
+	} else if isTypeStart(tok) {
+		typ := p.parseType()
+		return &ast.FieldList{
+			Opening: typ.Pos(),
+			List:    []*ast.Field{{Type: typ}},
+			Closing: typ.Pos(),
+		}
+	}
+	return nil
+}
+
+// parseParameters parse:
+// (a int, b, c float)
+// (int, string)
+// (format string, args ...int)
+func (p *Parser) parseParameters() *ast.FieldList {
+	tokLparen := p.MustAcceptToken(token.LPAREN)
+	params := &ast.FieldList{Opening: tokLparen.Pos}
+
+	named := false
+	for p.PeekToken().Type != token.RPAREN {
+		typ := p.parseParamType()
+		field := &ast.Field{Type: typ}
+		if tok := p.PeekToken(); tok.Type != token.COMMA && tok.Type != token.RPAREN {
+			// name type
+			field.Name = p.paramName(typ)
+			field.Type = p.parseParamType()
+			named = true
+		}
+		params.List = append(params.List, field)
+		if _, ok := p.AcceptToken(token.COMMA); !ok {
+			break
+		}
+	}
+	params.Closing = p.MustAcceptToken(token.RPAREN).Pos
+
+	// 有名字的参数列表中, 只有名字的参数使用后面最近的类型, 如 (a, b int)
+	if named {
+		var typ ast.Expr
+		for i := len(params.List) - 1; i >= 0; i-- {
+			field := params.List[i]
+			if field.Name != nil {
+				typ = field.Type
+				continue
+			}
+			if typ == nil {
+				p.errorf(field.Type.Pos(), "mixed named and unnamed parameters")
+			}
+			field.Name = p.paramName(field.Type)
+			field.Type = typ
+		}
+	}
+	return params
+}
+
+// parseParamType parse: int, ...int
+func (p *Parser) parseParamType() ast.Expr {
+	if tok, ok := p.AcceptToken(token.ELLIPSIS); ok {
+		return &ast.Ellipsis{
+			Ellipsis: tok.Pos,
+			Elt:      p.parseType(),
+		}
+	}
+	return p.parseType()
+}
+
+// paramName 把解析为类型的表达式转换为参数名
+func (p *Parser) paramName(expr ast.Expr) *ast.Ident {
+	ident, ok := expr.(*ast.Ident)
+	if !ok {
+		p.errorf(expr.Pos(), "mixed named and unnamed parameters")
+	}
+	return &ast.Ident{NamePos: ident.NamePos, Name: ident.Name}
+}
@@ -32,53 +32,71 @@ func (p *Parser) parseStmtExprOrAssign() ast.Stmt {
 	// exprList = exprList;
 	exprList := p.parseExprList()
 	switch tok := p.PeekToken(); tok.Type {
-	case token.SEMICOLON, token.LBRACE:
+	case token.SEMICOLON, token.LBRACE, token.RBRACE, token.COLON:
 		if len(exprList) != 1 {
 			p.errorf(tok.Pos, "unknown token: %v", tok.Type)
 		}
 		return &ast.ExprStmt{
 			X: exprList[0],
 		}
+	case token.ARROW:
+		// ch <- v
+		p.ReadToken()
+		if len(exprList) != 1 {
+			p.errorf(tok.Pos, "unexpected %v, expected := or = or comma", tok.Type)
+		}
+		return &ast.SendStmt{
+			Chan:  exprList[0],
+			Arrow: tok.Pos,
+			Value: p.parseExpr(),
+		}
+	case token.INC, token.DEC:
+		// x++, x--
+		p.ReadToken()
+		if len(exprList) != 1 {
+			p.errorf(tok.Pos, "unexpected %v, expected := or = or comma", tok.Type)
+		}
+		return &ast.IncDecStmt{
+			X:      exprList[0],
+			TokPos: tok.Pos,
+			Tok:    tok.Type,
+		}
+	case token.ADD_ASSIGN, token.SUB_ASSIGN, token.MUL_ASSIGN, token.DIV_ASSIGN, token.MOD_ASSIGN,
+		token.AND_ASSIGN, token.OR_ASSIGN, token.XOR_ASSIGN, token.SHL_ASSIGN, token.SHR_ASSIGN, token.AND_NOT_ASSIGN:
+		// x += y
+		p.ReadToken()
+		if len(exprList) != 1 {
+			p.errorf(tok.Pos, "assignment operation %v requires single-valued expressions", tok.Type)
+		}
+		return &ast.AssignStmt{
+			Target: exprList,
+			OpPos:  tok.Pos,
+			Op:     tok.Type,
+			Value:  []ast.Expr{p.parseExpr()},
+		}
 	case token.DEFINE, token.ASSIGN:
 		p.ReadToken()
-		exprValueList := p.parseExprList()
-		if len(exprList) != len(exprValueList) {
-			p.errorf(tok.Pos, "unknown token: %v", tok)
+		if p.rangeOk && p.PeekToken().Type == token.RANGE {
+			return p.parseRangeClause(exprList, tok)
 		}
+		exprValueList := p.parseExprList()
+		// 两边个数不一致时 (如 x, y := f()) 由编译器检查
 		var assignStmt = &ast.AssignStmt{
-			Target: make([]*ast.Ident, len(exprList)),
+			Target: exprList,
 			OpPos:  tok.Pos,
 			Op:     tok.Type,
-			Value:  make([]ast.Expr, len(exprList)),
+			Value:  exprValueList,
 		}
-		for i, target := range exprList {
-			assignStmt.Target[i] = target.(*ast.Ident)
-			assignStmt.Value[i] = exprValueList[i]
-			var typ string
-			switch exprValueList[i].(type) {
-			case *ast.Float:
-				typ = "float"
-			case *ast.Int:
-				typ = "i32"
-			case *ast.Char:
-				typ = "i8"
+		for _, target := range exprList {
+			switch target := target.(type) {
 			case *ast.Ident:
-				typ = exprValueList[i].(*ast.Ident).Type
-			case *ast.BinaryExpr:
-				switch exprValueList[i].(*ast.BinaryExpr).X.(type) {
-				case *ast.Float:
-					typ = "float"
-				case *ast.Int:
-					typ = "i32"
-				case *ast.Char:
-					typ = "i8"
-				case *ast.Ident:
-					typ = exprValueList[i].(*ast.BinaryExpr).X.(*ast.Ident).Type
-				default:
-					p.errorf(tok.Pos, "unknown token: %v", tok)
+			case *ast.IndexExpr, *ast.SelectorExpr, *ast.StarExpr:
+				if tok.Type == token.DEFINE {
+					p.errorf(target.Pos(), "non-name on left side of :=")
 				}
+			default:
+				p.errorf(tok.Pos, "cannot assign to %T", target)
 			}
-			assignStmt.Target[i].Type = typ
 		}
 		return assignStmt
 	default:
@@ -92,6 +110,9 @@ func (p *Parser) parseStmtBlock() *ast.BlockStmt {
 
 	tokBegin := p.MustAcceptToken(token.LBRACE) // {
 
+	defer func(lev int) { p.exprLev = lev }(p.exprLev)
+	p.exprLev = 0
+
 Loop:
 	for {
 		switch tok := p.PeekToken(); tok.Type {
@@ -101,33 +122,10 @@ Loop:
 			p.errorf(tok.Pos, "invalid token: %s", tok.Literal)
 		case token.SEMICOLON:
 			p.AcceptTokenList(token.SEMICOLON)
-		case token.LBRACE: // {
-			block.List = append(block.List, p.parseStmtBlock())
 		case token.RBRACE: // }
 			break Loop
-		case token.VAR:
-			block.List = append(block.List, p.parseStmtVar())
-		case token.RETURN:
-			block.List = append(block.List, p.parseStmtReturn())
-		case token.IF:
-			block.List = append(block.List, p.parseStmtIf())
-		case token.FOR:
-			block.List = append(block.List, p.parseStmtFor())
-		case token.BREAK:
-			block.List = append(block.List, p.parseStmtBreak())
-		case token.CONTINUE:
-			block.List = append(block.List, p.parseStmtContinue())
-		case token.GOTO:
-			block.List = append(block.List, p.parseStmtGoto())
 		default:
-			p.ReadToken()
-			tok = p.PeekToken()
-			p.UnreadToken()
-			if tok.Type == token.COLON {
-				block.List = append(block.List, p.parseStmtLabeled())
-			} else {
-				block.List = append(block.List, p.parseStmtExprOrAssign())
-			}
+			block.List = append(block.List, p.parseStmtInBlock())
 		}
 	}
 
@@ -139,6 +137,48 @@ Loop:
 	return block
 }
 
+// parseStmtInBlock 解析块中的一条语句
+func (p *Parser) parseStmtInBlock() ast.Stmt {
+	switch tok := p.PeekToken(); tok.Type {
+	case token.LBRACE: // {
+		return p.parseStmtBlock()
+	case token.VAR:
+		return p.parseStmtVar()
+	case token.CONST:
+		return p.parseStmtConst()
+	case token.RETURN:
+		return p.parseStmtReturn()
+	case token.DEFER:
+		return p.parseStmtDefer()
+	case token.GO:
+		return p.parseStmtGo()
+	case token.IF:
+		return p.parseStmtIf()
+	case token.FOR:
+		return p.parseStmtFor()
+	case token.SWITCH:
+		return p.parseStmtSwitch()
+	case token.SELECT:
+		return p.parseStmtSelect()
+	case token.BREAK:
+		return p.parseStmtBreak()
+	case token.CONTINUE:
+		return p.parseStmtContinue()
+	case token.GOTO:
+		return p.parseStmtGoto()
+	case token.FALLTHROUGH:
+		return p.parseStmtFallthrough()
+	default:
+		p.ReadToken()
+		tok = p.PeekToken()
+		p.UnreadToken()
+		if tok.Type == token.COLON {
+			return p.parseStmtLabeled()
+		}
+		return p.parseStmtExprOrAssign()
+	}
+}
+
 func (p *Parser) parseStmtExpr() *ast.ExprStmt {
 	return &ast.ExprStmt{
 		X: p.parseExpr(),
@@ -147,22 +187,38 @@ func (p *Parser) parseStmtExpr() *ast.ExprStmt {
 
 func (p *Parser) parseStmtGoto() *ast.BranchStmt {
 	tokGoto := p.MustAcceptToken(token.GOTO)
+	tokLabel := p.MustAcceptToken(token.IDENT)
 
 	return &ast.BranchStmt{
 		TokPos:  tokGoto.Pos,
 		TokType: token.GOTO,
+		Label: &ast.Ident{
+			NamePos: tokLabel.Pos,
+			Name:    tokLabel.Literal,
+		},
 	}
 }
 
+// parseStmtLabeled parse:
+// Label: stmt
+// Label: }
 func (p *Parser) parseStmtLabeled() *ast.LabeledStmt {
 	tokLabel := p.MustAcceptToken(token.IDENT)
 	tokColon := p.MustAcceptToken(token.COLON)
 
-	return &ast.LabeledStmt{
+	labeledStmt := &ast.LabeledStmt{
 		Label: &ast.Ident{
 			NamePos: tokLabel.Pos,
 			Name:    tokLabel.Literal,
 		},
 		Colon: tokColon.Pos,
 	}
+
+	// 标号后面可以是空语句
+	switch p.PeekToken().Type {
+	case token.SEMICOLON, token.RBRACE, token.EOF:
+		return labeledStmt
+	}
+	labeledStmt.Stmt = p.parseStmtInBlock()
+	return labeledStmt
 }
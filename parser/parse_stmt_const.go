@@ -0,0 +1,68 @@
+package parser
+
+import (
+	"tiny-go/ast"
+	"tiny-go/token"
+)
+
+// parseStmtConst parse:
+// const Name = Value
+// const Name Type = Value
+// const ( A = iota; B; C )
+//
+// 声明组中省略类型和值的常量重复上一个常量的类型和表达式
+func (p *Parser) parseStmtConst() *ast.ConstDecl {
+	tokConst := p.MustAcceptToken(token.CONST)
+
+	constDecl := &ast.ConstDecl{
+		ConstPos: tokConst.Pos,
+	}
+
+	if _, ok := p.AcceptToken(token.LPAREN); !ok {
+		spec := p.parseConstSpec(tokConst, nil, 0)
+		constDecl.Specs = append(constDecl.Specs, spec)
+		p.AcceptTokenList(token.SEMICOLON)
+		return constDecl
+	}
+
+	var prev *ast.ConstSpec
+	for {
+		p.AcceptTokenList(token.SEMICOLON)
+		if _, ok := p.AcceptToken(token.RPAREN); ok {
+			break
+		}
+		prev = p.parseConstSpec(tokConst, prev, len(constDecl.Specs))
+		constDecl.Specs = append(constDecl.Specs, prev)
+	}
+	p.AcceptTokenList(token.SEMICOLON)
+	return constDecl
+}
+
+// parseConstSpec 解析一个常量, prev 为声明组中的上一个常量
+func (p *Parser) parseConstSpec(tokConst token.Token, prev *ast.ConstSpec, iota int) *ast.ConstSpec {
+	tokIdent := p.MustAcceptToken(token.IDENT)
+
+	spec := &ast.ConstSpec{
+		ConstPos: tokConst.Pos,
+		Name: &ast.Ident{
+			NamePos: tokIdent.Pos,
+			Name:    tokIdent.Literal,
+		},
+		Iota: iota,
+	}
+
+	// const name type?
+	if isTypeStart(p.PeekToken()) {
+		spec.Type = p.parseType()
+	}
+
+	// const name =
+	if _, ok := p.AcceptToken(token.ASSIGN); ok {
+		spec.Value = p.parseExpr()
+	} else if spec.Type == nil && prev != nil {
+		spec.Type, spec.Value = prev.Type, prev.Value
+	} else {
+		p.errorf(tokIdent.Pos, "missing init expr for const declaration")
+	}
+	return spec
+}
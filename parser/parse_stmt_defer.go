@@ -0,0 +1,20 @@
+package parser
+
+import (
+	"tiny-go/ast"
+	"tiny-go/token"
+)
+
+func (p *Parser) parseStmtDefer() *ast.DeferStmt {
+	tokDefer := p.MustAcceptToken(token.DEFER)
+
+	call, ok := p.parseExpr().(*ast.CallExpr)
+	if !ok {
+		p.errorf(tokDefer.Pos, "expression in defer must be function call")
+	}
+
+	return &ast.DeferStmt{
+		DeferPos: tokDefer.Pos,
+		Call:     call,
+	}
+}
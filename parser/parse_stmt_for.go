@@ -5,13 +5,27 @@ import (
 	"tiny-go/token"
 )
 
-func (p *Parser) parseStmtFor() *ast.ForStmt {
+func (p *Parser) parseStmtFor() ast.Stmt {
 	tokFor := p.MustAcceptToken(token.FOR)
 
+	// 头部中的 T{ 会和语句块的 { 混淆, 复合字面值需要加括号
+	defer func(lev int) { p.exprLev = lev }(p.exprLev)
+	p.exprLev = -1
+
 	forStmt := &ast.ForStmt{
 		For: tokFor.Pos,
 	}
 
+	// for range x {}
+	if tokRange, ok := p.AcceptToken(token.RANGE); ok {
+		return &ast.RangeStmt{
+			For:   tokFor.Pos,
+			Range: tokRange.Pos,
+			X:     p.parseExpr(),
+			Body:  p.parseStmtBlock(),
+		}
+	}
+
 	// for {}
 	if _, ok := p.AcceptToken(token.LBRACE); ok {
 		p.UnreadToken()
@@ -57,13 +71,22 @@ func (p *Parser) parseStmtFor() *ast.ForStmt {
 		}
 	} else {
 		// for expr ... {}
+		p.rangeOk = true
 		stmt := p.parseStmt()
+		p.rangeOk = false
+
+		// for k, v := range x {}
+		if rangeStmt, ok := stmt.(*ast.RangeStmt); ok {
+			rangeStmt.For = tokFor.Pos
+			rangeStmt.Body = p.parseStmtBlock()
+			return rangeStmt
+		}
 
 		if _, ok := p.AcceptToken(token.LBRACE); ok {
 			// for cond {}
 			p.UnreadToken()
-			if expr, ok := stmt.(ast.Expr); ok {
-				forStmt.Cond = expr
+			if expr, ok := stmt.(*ast.ExprStmt); ok {
+				forStmt.Cond = expr.X
 			}
 			forStmt.Body = p.parseStmtBlock()
 			return forStmt
@@ -105,12 +128,41 @@ func (p *Parser) parseStmtFor() *ast.ForStmt {
 	}
 }
 
+// parseRangeClause 解析 for 头部中的 k, v := range x 或 k, v = range x, exprList 为迭代变量
+func (p *Parser) parseRangeClause(exprList []ast.Expr, tok token.Token) *ast.RangeStmt {
+	p.rangeOk = false
+	tokRange := p.MustAcceptToken(token.RANGE)
+	if len(exprList) > 2 {
+		p.errorf(exprList[2].Pos(), "range clause permits at most two iteration variables")
+	}
+	if tok.Type == token.DEFINE {
+		for _, x := range exprList {
+			if _, ok := x.(*ast.Ident); !ok {
+				p.errorf(x.Pos(), "non-name on left side of :=")
+			}
+		}
+	}
+
+	rangeStmt := &ast.RangeStmt{
+		Key:    exprList[0],
+		TokPos: tok.Pos,
+		Tok:    tok.Type,
+		Range:  tokRange.Pos,
+		X:      p.parseExpr(),
+	}
+	if len(exprList) == 2 {
+		rangeStmt.Value = exprList[1]
+	}
+	return rangeStmt
+}
+
 func (p *Parser) parseStmtBreak() *ast.BranchStmt {
 	tokBreak := p.MustAcceptToken(token.BREAK)
 
 	return &ast.BranchStmt{
 		TokPos:  tokBreak.Pos,
 		TokType: token.BREAK,
+		Label:   p.parseBranchLabel(),
 	}
 }
 
@@ -120,5 +172,17 @@ func (p *Parser) parseStmtContinue() *ast.BranchStmt {
 	return &ast.BranchStmt{
 		TokPos:  tokContinue.Pos,
 		TokType: token.CONTINUE,
+		Label:   p.parseBranchLabel(),
+	}
+}
+
+// parseBranchLabel 解析 break/continue 后面可选的标号
+func (p *Parser) parseBranchLabel() *ast.Ident {
+	if tokLabel, ok := p.AcceptToken(token.IDENT); ok {
+		return &ast.Ident{
+			NamePos: tokLabel.Pos,
+			Name:    tokLabel.Literal,
+		}
 	}
+	return nil
 }
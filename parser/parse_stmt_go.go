@@ -0,0 +1,20 @@
+package parser
+
+import (
+	"tiny-go/ast"
+	"tiny-go/token"
+)
+
+func (p *Parser) parseStmtGo() *ast.GoStmt {
+	tokGo := p.MustAcceptToken(token.GO)
+
+	call, ok := p.parseExpr().(*ast.CallExpr)
+	if !ok {
+		p.errorf(tokGo.Pos, "expression in go must be function call")
+	}
+
+	return &ast.GoStmt{
+		GoPos: tokGo.Pos,
+		Call:  call,
+	}
+}
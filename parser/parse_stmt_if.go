@@ -8,6 +8,10 @@ import (
 func (p *Parser) parseStmtIf() *ast.IfStmt {
 	tokIf := p.MustAcceptToken(token.IF)
 
+	// 头部中的 T{ 会和语句块的 { 混淆, 复合字面值需要加括号
+	defer func(lev int) { p.exprLev = lev }(p.exprLev)
+	p.exprLev = -1
+
 	ifStmt := &ast.IfStmt{
 		If: tokIf.Pos,
 	}
@@ -16,7 +16,13 @@ func (p *Parser) parseStmtReturn() *ast.ReturnStmt {
 		token.LBRACE,    // {
 		token.RBRACE,    // }
 	); !ok {
-		retStmt.Result = p.parseExpr()
+		retStmt.Results = append(retStmt.Results, p.parseExpr())
+		for {
+			if _, ok := p.AcceptToken(token.COMMA); !ok {
+				break
+			}
+			retStmt.Results = append(retStmt.Results, p.parseExpr())
+		}
 	} else {
 		p.UnreadToken()
 	}
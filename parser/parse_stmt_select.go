@@ -0,0 +1,61 @@
+package parser
+
+import (
+	"tiny-go/ast"
+	"tiny-go/token"
+)
+
+// parseStmtSelect parse:
+// select { case v := <-ch: ... case ch <- v: ... default: ... }
+func (p *Parser) parseStmtSelect() *ast.SelectStmt {
+	tokSelect := p.MustAcceptToken(token.SELECT)
+
+	return &ast.SelectStmt{
+		Select: tokSelect.Pos,
+		Body:   p.parseCaseBlock(p.parseCommClause),
+	}
+}
+
+// parseCommClause parse:
+// case ch <- v: stmts
+// case <-ch: stmts
+// case v, ok := <-ch: stmts
+// default: stmts
+func (p *Parser) parseCommClause() ast.Stmt {
+	clause := &ast.CommClause{}
+
+	if tokCase, ok := p.AcceptToken(token.CASE); ok {
+		clause.Case = tokCase.Pos
+		clause.Comm = p.parseStmtExprOrAssign()
+		if !isCommStmt(clause.Comm) {
+			p.errorf(tokCase.Pos, "select case must be receive, send or assign recv")
+		}
+	} else {
+		clause.Case = p.MustAcceptToken(token.DEFAULT).Pos
+	}
+	clause.Colon = p.MustAcceptToken(token.COLON).Pos
+	clause.Body = p.parseClauseBody()
+	return clause
+}
+
+// isCommStmt 判断语句是否为 select 分支中的发送语句或接收语句
+func isCommStmt(stmt ast.Stmt) bool {
+	switch stmt := stmt.(type) {
+	case *ast.SendStmt:
+		return true
+	case *ast.ExprStmt:
+		return isRecvExpr(stmt.X)
+	case *ast.AssignStmt:
+		if stmt.Op != token.DEFINE && stmt.Op != token.ASSIGN {
+			return false
+		}
+		return len(stmt.Target) <= 2 && len(stmt.Value) == 1 && isRecvExpr(stmt.Value[0])
+	}
+	return false
+}
+
+// isRecvExpr 判断表达式是否为接收表达式 <-ch
+func isRecvExpr(expr ast.Expr) bool {
+	unary, ok := expr.(*ast.UnaryExpr)
+	return ok && unary.Op == token.ARROW
+}
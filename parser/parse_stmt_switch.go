@@ -0,0 +1,150 @@
+package parser
+
+import (
+	"tiny-go/ast"
+	"tiny-go/token"
+)
+
+// parseStmtSwitch parse:
+// switch { ... }
+// switch tag { ... }
+// switch init; tag { ... }
+// switch init; { ... }
+// switch x := v.(type) { ... }
+func (p *Parser) parseStmtSwitch() ast.Stmt {
+	tokSwitch := p.MustAcceptToken(token.SWITCH)
+
+	switchStmt := &ast.SwitchStmt{
+		Switch: tokSwitch.Pos,
+	}
+
+	var guard ast.Stmt // 类型 switch 的 x := v.(type) 或 v.(type)
+	func() {
+		// 头部中的 T{ 会和语句块的 { 混淆, 复合字面值需要加括号
+		defer func(lev int) { p.exprLev = lev }(p.exprLev)
+		p.exprLev = -1
+
+		if p.PeekToken().Type == token.LBRACE {
+			return
+		}
+		var stmt ast.Stmt
+		if p.PeekToken().Type != token.SEMICOLON {
+			stmt = p.parseStmtExprOrAssign()
+		}
+		if _, ok := p.AcceptToken(token.SEMICOLON); ok {
+			switchStmt.Init = stmt
+			stmt = nil
+			if p.PeekToken().Type != token.LBRACE {
+				stmt = p.parseStmtExprOrAssign()
+			}
+		}
+		if isTypeSwitchGuard(stmt) {
+			guard = stmt
+		} else if tag, ok := stmt.(*ast.ExprStmt); ok {
+			switchStmt.Tag = tag.X
+		} else if stmt != nil {
+			p.errorf(tokSwitch.Pos, "switch expression expect expr: %#v", stmt)
+		}
+	}()
+
+	body := p.parseCaseBlock(p.parseCaseClause)
+	if guard != nil {
+		return &ast.TypeSwitchStmt{
+			Switch: tokSwitch.Pos,
+			Init:   switchStmt.Init,
+			Assign: guard,
+			Body:   body,
+		}
+	}
+	switchStmt.Body = body
+	return switchStmt
+}
+
+// isTypeSwitchGuard 判断语句是否为类型 switch 的 x := v.(type) 或 v.(type)
+func isTypeSwitchGuard(stmt ast.Stmt) bool {
+	var x ast.Expr
+	switch stmt := stmt.(type) {
+	case *ast.ExprStmt:
+		x = stmt.X
+	case *ast.AssignStmt:
+		if stmt.Op != token.DEFINE || len(stmt.Target) != 1 || len(stmt.Value) != 1 {
+			return false
+		}
+		x = stmt.Value[0]
+	}
+	assert, ok := x.(*ast.TypeAssertExpr)
+	return ok && assert.Type == nil
+}
+
+// parseCaseBlock 解析 switch 和 select 的语句块, 块中只有 case 和 default 分支, 由 parseClause 解析
+func (p *Parser) parseCaseBlock(parseClause func() ast.Stmt) *ast.BlockStmt {
+	block := &ast.BlockStmt{}
+
+	tokBegin := p.MustAcceptToken(token.LBRACE) // {
+
+	defer func(lev int) { p.exprLev = lev }(p.exprLev)
+	p.exprLev = 0
+
+Loop:
+	for {
+		switch tok := p.PeekToken(); tok.Type {
+		case token.SEMICOLON:
+			p.AcceptTokenList(token.SEMICOLON)
+		case token.CASE, token.DEFAULT:
+			block.List = append(block.List, parseClause())
+		case token.RBRACE: // }
+			break Loop
+		default:
+			p.errorf(tok.Pos, "unexpected %v, expected case or default or }", tok.Type)
+		}
+	}
+
+	tokEnd := p.MustAcceptToken(token.RBRACE) // }
+
+	block.Lbrace = tokBegin.Pos
+	block.Rbrace = tokEnd.Pos
+
+	return block
+}
+
+// parseCaseClause parse:
+// case x, y: stmts
+// default: stmts
+func (p *Parser) parseCaseClause() ast.Stmt {
+	clause := &ast.CaseClause{}
+
+	if tokCase, ok := p.AcceptToken(token.CASE); ok {
+		clause.Case = tokCase.Pos
+		clause.List = p.parseExprList()
+	} else {
+		clause.Case = p.MustAcceptToken(token.DEFAULT).Pos
+	}
+	clause.Colon = p.MustAcceptToken(token.COLON).Pos
+	clause.Body = p.parseClauseBody()
+	return clause
+}
+
+// parseClauseBody 解析 case 分支的语句列表, 直到下一个分支或 }
+func (p *Parser) parseClauseBody() (body []ast.Stmt) {
+	for {
+		switch tok := p.PeekToken(); tok.Type {
+		case token.EOF, token.CASE, token.DEFAULT, token.RBRACE:
+			return body
+		case token.ERROR:
+			p.errorf(tok.Pos, "invalid token: %s", tok.Literal)
+		case token.SEMICOLON:
+			p.AcceptTokenList(token.SEMICOLON)
+		default:
+			body = append(body, p.parseStmtInBlock())
+		}
+	}
+}
+
+func (p *Parser) parseStmtFallthrough() *ast.BranchStmt {
+	tokFallthrough := p.MustAcceptToken(token.FALLTHROUGH)
+
+	return &ast.BranchStmt{
+		TokPos:  tokFallthrough.Pos,
+		TokType: token.FALLTHROUGH,
+	}
+}
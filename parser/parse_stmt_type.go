@@ -0,0 +1,29 @@
+package parser
+
+import (
+	"tiny-go/ast"
+	"tiny-go/token"
+)
+
+// parseStmtType parse:
+// type Name Type
+// type Name = Type
+func (p *Parser) parseStmtType() *ast.TypeSpec {
+	tokType := p.MustAcceptToken(token.TYPE)
+	tokIdent := p.MustAcceptToken(token.IDENT)
+
+	typeSpec := &ast.TypeSpec{
+		TypePos: tokType.Pos,
+		Name: &ast.Ident{
+			NamePos: tokIdent.Pos,
+			Name:    tokIdent.Literal,
+		},
+	}
+	if tokAssign, ok := p.AcceptToken(token.ASSIGN); ok {
+		typeSpec.Assign = tokAssign.Pos
+	}
+	typeSpec.Type = p.parseType()
+
+	p.AcceptTokenList(token.SEMICOLON)
+	return typeSpec
+}
@@ -19,14 +19,8 @@ func (p *Parser) parseStmtVar() *ast.VarSpec {
 	}
 
 	// var name type?
-	if typ, ok := p.AcceptToken(token.IDENT); ok {
-		tokType := LoopUp(typ.Literal)
-		varSpec.Type = &ast.Ident{
-			NamePos: typ.Pos,
-			Name:    typ.Literal,
-			Type:    tokType,
-		}
-		varSpec.Name.Type = tokType
+	if isTypeStart(p.PeekToken()) {
+		varSpec.Type = p.parseType()
 	}
 
 	// var name =
@@ -0,0 +1,177 @@
+package parser
+
+import (
+	"tiny-go/ast"
+	"tiny-go/token"
+)
+
+// parseType parse:
+// int
+// [N]int
+// []int
+// struct { ... }
+// interface { ... }
+// func(int) int
+// map[string]int
+// chan int, chan<- int, <-chan int
+// *int
+func (p *Parser) parseType() ast.Expr {
+	switch tok := p.PeekToken(); tok.Type {
+	case token.IDENT:
+		p.ReadToken()
+		return &ast.Ident{
+			NamePos: tok.Pos,
+			Name:    tok.Literal,
+		}
+	case token.LBRACK:
+		p.ReadToken()
+		var length ast.Expr
+		if p.PeekToken().Type != token.RBRACK {
+			length = p.parseExpr()
+		}
+		p.MustAcceptToken(token.RBRACK)
+		return &ast.ArrayType{
+			Lbrack: tok.Pos,
+			Len:    length,
+			Elem:   p.parseType(),
+		}
+	case token.STRUCT:
+		return p.parseStructType()
+	case token.INTERFACE:
+		return p.parseInterfaceType()
+	case token.FUNC:
+		return p.parseFuncType()
+	case token.MAP:
+		p.ReadToken()
+		p.MustAcceptToken(token.LBRACK)
+		key := p.parseType()
+		p.MustAcceptToken(token.RBRACK)
+		return &ast.MapType{
+			Map:   tok.Pos,
+			Key:   key,
+			Value: p.parseType(),
+		}
+	case token.CHAN:
+		p.ReadToken()
+		dir := ast.SEND | ast.RECV
+		if _, ok := p.AcceptToken(token.ARROW); ok {
+			dir = ast.SEND
+		}
+		return &ast.ChanType{
+			Begin: tok.Pos,
+			Dir:   dir,
+			Value: p.parseType(),
+		}
+	case token.ARROW:
+		p.ReadToken()
+		p.MustAcceptToken(token.CHAN)
+		return &ast.ChanType{
+			Begin: tok.Pos,
+			Dir:   ast.RECV,
+			Value: p.parseType(),
+		}
+	case token.MUL:
+		p.ReadToken()
+		return &ast.StarExpr{
+			Star: tok.Pos,
+			X:    p.parseType(),
+		}
+	default:
+		p.errorf(tok.Pos, "expect type, got %v", tok.Type)
+		panic("unreachable")
+	}
+}
+
+// parseStructType parse:
+// struct { x, y int; name string }
+func (p *Parser) parseStructType() *ast.StructType {
+	tokStruct := p.MustAcceptToken(token.STRUCT)
+	tokLbrace := p.MustAcceptToken(token.LBRACE)
+
+	structType := &ast.StructType{
+		Struct: tokStruct.Pos,
+		Fields: &ast.FieldList{Opening: tokLbrace.Pos},
+	}
+	for {
+		p.AcceptTokenList(token.SEMICOLON)
+		if tok, ok := p.AcceptToken(token.RBRACE); ok {
+			structType.Fields.Closing = tok.Pos
+			return structType
+		}
+
+		names := []token.Token{p.MustAcceptToken(token.IDENT)}
+		for {
+			if _, ok := p.AcceptToken(token.COMMA); !ok {
+				break
+			}
+			names = append(names, p.MustAcceptToken(token.IDENT))
+		}
+		typ := p.parseType()
+		for _, name := range names {
+			structType.Fields.List = append(structType.Fields.List, &ast.Field{
+				Name: &ast.Ident{
+					NamePos: name.Pos,
+					Name:    name.Literal,
+				},
+				Type: typ,
+			})
+		}
+	}
+}
+
+// parseInterfaceType parse:
+// interface { Area() float; Scale(k float) }
+func (p *Parser) parseInterfaceType() *ast.InterfaceType {
+	tokInterface := p.MustAcceptToken(token.INTERFACE)
+	tokLbrace := p.MustAcceptToken(token.LBRACE)
+
+	interfaceType := &ast.InterfaceType{
+		Interface: tokInterface.Pos,
+		Methods:   &ast.FieldList{Opening: tokLbrace.Pos},
+	}
+	for {
+		p.AcceptTokenList(token.SEMICOLON)
+		if tok, ok := p.AcceptToken(token.RBRACE); ok {
+			interfaceType.Methods.Closing = tok.Pos
+			return interfaceType
+		}
+
+		name := p.MustAcceptToken(token.IDENT)
+		if p.PeekToken().Type != token.LPAREN {
+			p.errorf(name.Pos, "embedded interface %s is not supported", name.Literal)
+		}
+		funcType := &ast.FuncType{
+			Func:   name.Pos,
+			Params: p.parseParameters(),
+		}
+		funcType.Results = p.parseResults()
+		interfaceType.Methods.List = append(interfaceType.Methods.List, &ast.Field{
+			Name: &ast.Ident{
+				NamePos: name.Pos,
+				Name:    name.Literal,
+			},
+			Type: funcType,
+		})
+	}
+}
+
+// parseFuncType parse:
+// func(a int, b float) (int, error)
+func (p *Parser) parseFuncType() *ast.FuncType {
+	tokFunc := p.MustAcceptToken(token.FUNC)
+	funcType := &ast.FuncType{
+		Func:   tokFunc.Pos,
+		Params: p.parseParameters(),
+	}
+	funcType.Results = p.parseResults()
+	return funcType
+}
+
+// isTypeStart 判断 tok 是否为类型的开始
+func isTypeStart(tok token.Token) bool {
+	switch tok.Type {
+	case token.IDENT, token.LBRACK, token.STRUCT, token.INTERFACE, token.FUNC, token.MAP, token.CHAN, token.ARROW, token.MUL:
+		return true
+	}
+	return false
+}
@@ -2,6 +2,7 @@ package parser
 
 import (
 	"fmt"
+	"strings"
 	"tiny-go/ast"
 	"tiny-go/lexer"
 	"tiny-go/token"
@@ -14,13 +15,60 @@ type Parser struct {
 	*TokenStream
 	file *ast.File
 	err  error
+
+	exprLev int  // < 0: 在 if/for 的头部中, 不允许复合字面值
+	rangeOk bool // 在 for 的头部中, 赋值语句可以是 range 子句
 }
 
 func (p *Parser) errorf(pos token.Pos, format string, args ...interface{}) {
-	p.err = fmt.Errorf("%s: %s", lexer.PosString(p.fileName, p.src, int(pos)), fmt.Sprintf(format, args...))
+	p.err = fmt.Errorf("%s: %s", pos.Position(p.fileName, p.src), fmt.Sprintf(format, args...))
 	panic(p.err)
 }
 
+// MustAcceptToken 读取一个 expectTypes 中的记号, 否则报告语法错误
+func (p *Parser) MustAcceptToken(expectTypes ...token.TokenType) (tok token.Token) {
+	tok, ok := p.AcceptToken(expectTypes...)
+	if !ok {
+		p.errorf(tok.Pos, "unexpected %s, expected %s", tokenString(tok), tokenList(expectTypes))
+	}
+	return tok
+}
+
+// MustAcceptTokenList 读取一个或多个 expectTypes 中的记号, 否则报告语法错误
+func (p *Parser) MustAcceptTokenList(expectTypes ...token.TokenType) (toks []token.Token) {
+	toks, ok := p.AcceptTokenList(expectTypes...)
+	if !ok {
+		tok := p.PeekToken()
+		p.errorf(tok.Pos, "unexpected %s, expected %s", tokenString(tok), tokenList(expectTypes))
+	}
+	return toks
+}
+
+// tokenString 返回语法错误中记号的描述, 自动插入的分号显示为 newline
+func tokenString(tok token.Token) string {
+	switch {
+	case tok.Type == token.SEMICOLON && tok.Literal == "\n":
+		return "newline"
+	case tok.Type == token.IDENT:
+		return "name " + tok.Literal
+	}
+	return tok.Type.String()
+}
+
+// tokenList 返回 "a, b or c" 形式的记号列表
+func tokenList(types []token.TokenType) string {
+	var sb strings.Builder
+	for i, typ := range types {
+		if i > 0 && i == len(types)-1 {
+			sb.WriteString(" or ")
+		} else if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(typ.String())
+	}
+	return sb.String()
+}
+
 func (p *Parser) ParseFile() (file *ast.File, err error) {
 	defer func() {
 		if r := recover(); r != p.err {
@@ -52,16 +100,3 @@ func ParseFile(fileName, src string) (*ast.File, error) {
 	p := NewParser(fileName, src)
 	return p.ParseFile()
 }
-
-var keywords = map[string]string{
-	"int":   "i32",
-	"float": "float",
-	"char":  "i8",
-}
-
-func LoopUp(ident string) string {
-	if typ, isKeyword := keywords[ident]; isKeyword {
-		return typ
-	}
-	return "ident"
-}
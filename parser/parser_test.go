@@ -0,0 +1,75 @@
+package parser
+
+import "testing"
+
+// TestParseErrors 测试语法错误报告位置而不是 panic
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "unclosed paren",
+			src: `package main
+
+func main() {
+	x := (1
+}
+`,
+			want: "x.tgo:4:9: unexpected newline, expected )",
+		},
+		{
+			name: "unexpected name",
+			src: `package main
+
+func main() {
+	x := (1 y)
+}
+`,
+			want: "x.tgo:4:10: unexpected name y, expected )",
+		},
+		{
+			name: "missing brace",
+			src: `package main
+
+func main() {
+	x := 1
+`,
+			want: "x.tgo:5:1: unexpected EOF, expected }",
+		},
+		{
+			name: "embedded interface",
+			src: `package main
+
+type I interface {
+	M() int
+}
+
+type J interface {
+	I
+	N() int
+}
+`,
+			want: "x.tgo:8:2: embedded interface I is not supported",
+		},
+		{
+			name: "missing package",
+			src: `func main() {
+}
+`,
+			want: "x.tgo:1:1: unexpected func, expected package",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseFile("x.tgo", tt.src)
+			if err == nil {
+				t.Fatalf("no error, want %s", tt.want)
+			}
+			if err.Error() != tt.want {
+				t.Errorf("error:\n%v\nwant:\n%s", err, tt.want)
+			}
+		})
+	}
+}
@@ -1,7 +1,6 @@
 package parser
 
 import (
-	"fmt"
 	"tiny-go/token"
 )
 
@@ -17,6 +16,10 @@ type TokenStream struct {
 func (p *TokenStream) ReadToken() token.Token {
 	if p.pos >= len(p.tokens) {
 		p.width = 0
+		// 词法分析的最后一个记号是 EOF, 返回它以保留文件末尾的位置
+		if n := len(p.tokens); n > 0 && p.tokens[n-1].Type == token.EOF {
+			return p.tokens[n-1]
+		}
 		return token.Token{Type: token.EOF}
 	}
 	tok := p.tokens[p.pos]
@@ -57,22 +60,6 @@ func (p *TokenStream) AcceptTokenList(expectTypes ...token.TokenType) (toks []to
 	}
 }
 
-func (p *TokenStream) MustAcceptToken(expectTypes ...token.TokenType) (tok token.Token) {
-	tok, ok := p.AcceptToken(expectTypes...)
-	if !ok {
-		panic(fmt.Errorf("except %v, got %v", expectTypes, tok))
-	}
-	return tok
-}
-
-func (p *TokenStream) MustAcceptTokenList(expectTypes ...token.TokenType) (toks []token.Token) {
-	toks, ok := p.AcceptTokenList(expectTypes...)
-	if !ok {
-		panic(fmt.Errorf("expect %v, got %v", expectTypes, toks))
-	}
-	return toks
-}
-
 func NewTokenStream(fileName string, src string, tokens []token.Token, comments []token.Token) *TokenStream {
 	return &TokenStream{
 		fileName: fileName,
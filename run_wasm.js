@@ -2,14 +2,532 @@ const fs = require('fs');
 
 var wasmInstance = null
 
+// 简单的 bump 分配器, 从 __heap_base 开始分配
+var heapNext = 0
+
+function memory() {
+    return new Uint8Array(wasmInstance.exports.memory.buffer);
+}
+
+function alloc(n) {
+    if (heapNext === 0) {
+        heapNext = wasmInstance.exports.__heap_base.value;
+    }
+    var p = heapNext;
+    heapNext += (n + 7) & ~7;
+    var need = heapNext - wasmInstance.exports.memory.buffer.byteLength;
+    if (need > 0) {
+        wasmInstance.exports.memory.grow(Math.ceil(need / 65536));
+    }
+    return p;
+}
+
+// 切片头在 wasm32 中的布局为 { ptr, len, cap }, 每个字段 4 字节
+function sliceHeader(p) {
+    return new Int32Array(wasmInstance.exports.memory.buffer, p, 3);
+}
+
+function runtimePanic(msg) {
+    console.log("panic: runtime error: " + msg);
+    throw new Error("exit: 2");
+}
+
+function loadString(p, n) {
+    return Buffer.from(memory().slice(p, p + n)).toString('utf8');
+}
+
+// 接口在 wasm32 中的布局为 { itab, data }, 类型描述符的布局为 { name, n, equal, kind }, 每个字段 4 字节.
+// 种类和 builtin 运行时中的 TINY_GO_KIND_* 一致
+var KIND = {
+    OTHER: 0, BOOL: 1, INT8: 2, INT16: 3, INT32: 4, INT64: 5,
+    UINT8: 6, UINT16: 7, UINT32: 8, UINT64: 9, FLOAT32: 10, FLOAT64: 11,
+    STRING: 12, POINTER: 13, NAMED: 0x100
+};
+
+function view() {
+    return new DataView(wasmInstance.exports.memory.buffer);
+}
+
+// loadArgs 读取 []interface{} 的 n 个元素, 返回 { kind, name, data } 的数组, nil 接口为 null
+function loadArgs(p, n) {
+    var v = view();
+    var args = [];
+    for (var i = 0; i < n; i++) {
+        var itab = v.getInt32(p + i * 8, true);
+        var data = v.getInt32(p + i * 8 + 4, true);
+        if (itab === 0) {
+            args.push(null);
+            continue;
+        }
+        var typ = v.getInt32(itab, true);
+        args.push({
+            kind: v.getInt32(typ + 12, true) & ~KIND.NAMED,
+            name: loadString(v.getInt32(typ, true), v.getInt32(typ + 4, true)),
+            data: data
+        });
+    }
+    return args;
+}
+
+function isIntKind(kind) {
+    return kind >= KIND.INT8 && kind <= KIND.UINT64;
+}
+
+function isFloatKind(kind) {
+    return kind === KIND.FLOAT32 || kind === KIND.FLOAT64;
+}
+
+// 整数统一读取为 BigInt
+function loadInt(arg) {
+    var v = view();
+    switch (arg.kind) {
+    case KIND.INT8: return BigInt(v.getInt8(arg.data));
+    case KIND.INT16: return BigInt(v.getInt16(arg.data, true));
+    case KIND.INT32: return BigInt(v.getInt32(arg.data, true));
+    case KIND.INT64: return v.getBigInt64(arg.data, true);
+    case KIND.UINT8: return BigInt(v.getUint8(arg.data));
+    case KIND.UINT16: return BigInt(v.getUint16(arg.data, true));
+    case KIND.UINT32: return BigInt(v.getUint32(arg.data, true));
+    default: return v.getBigUint64(arg.data, true);
+    }
+}
+
+function loadFloat(arg) {
+    if (arg.kind === KIND.FLOAT32) {
+        return view().getFloat32(arg.data, true);
+    }
+    return view().getFloat64(arg.data, true);
+}
+
+function loadArgString(arg) {
+    var v = view();
+    return loadString(v.getInt32(arg.data, true), v.getInt32(arg.data + 4, true));
+}
+
+function hexPointer(p) {
+    return "0x" + (p >>> 0).toString(16);
+}
+
+function floatSpecial(x) {
+    if (x !== x) {
+        return "NaN";
+    }
+    if (x === Infinity) {
+        return "+Inf";
+    }
+    if (x === -Infinity) {
+        return "-Inf";
+    }
+    return null;
+}
+
+// 指数至少两位, 如 1.5e+00
+function fixExponent(s, upper) {
+    return s.replace(/e([+-])(\d+)$/, function (_, sign, digits) {
+        return (upper ? "E" : "e") + sign + (digits.length < 2 ? "0" : "") + digits;
+    });
+}
+
+// 按 Go 内置的 print 的格式写浮点数, 如 +1.500000e+000
+function printFloat(x) {
+    var special = floatSpecial(x);
+    if (special !== null) {
+        return special;
+    }
+    var s = x.toExponential(6).replace(/e([+-])(\d+)$/, function (_, sign, digits) {
+        return "e" + sign + ("00" + digits).slice(-3);
+    });
+    return (Object.is(x, -0) ? "-" : x >= 0 ? "+" : "") + s;
+}
+
+// 按 Go 内置的 print 和 println 的格式写一个参数
+function printArg(arg) {
+    if (arg === null) {
+        return "<nil>";
+    }
+    if (isIntKind(arg.kind)) {
+        return loadInt(arg).toString();
+    }
+    if (isFloatKind(arg.kind)) {
+        return printFloat(loadFloat(arg));
+    }
+    if (arg.kind === KIND.BOOL) {
+        return view().getUint8(arg.data) ? "true" : "false";
+    }
+    if (arg.kind === KIND.STRING) {
+        return loadArgString(arg);
+    }
+    if (arg.kind === KIND.POINTER) {
+        return hexPointer(arg.data);
+    }
+    return "(" + arg.name + ") " + hexPointer(arg.data);
+}
+
+// 带引号的字符串, 按 Go 的规则转义
+function quote(s, q) {
+    var out = q;
+    for (var c of s) {
+        var r = c.codePointAt(0);
+        if (c === q || c === "\\") {
+            out += "\\" + c;
+        } else if ((r >= 0x20 && r < 0x7f) || r >= 0xa0) {
+            out += c;
+        } else {
+            var esc = { "\x07": "\\a", "\b": "\\b", "\f": "\\f", "\n": "\\n", "\r": "\\r", "\t": "\\t", "\v": "\\v" }[c];
+            out += esc || (r < 0x80 ? "\\x" : "\\u") + r.toString(16).padStart(r < 0x80 ? 2 : 4, "0");
+        }
+    }
+    return out + q;
+}
+
+// 按宽度填充, 宽度按字符计算; 数字的 0 填在符号之后, 由 sign 给出符号的长度
+function pad(sp, s, sign, zero) {
+    var n = sp.width - [...s].length;
+    if (n <= 0) {
+        return s;
+    }
+    if (sp.minus) {
+        return s + " ".repeat(n);
+    }
+    if (zero) {
+        return s.slice(0, sign) + "0".repeat(n) + s.slice(sign);
+    }
+    return " ".repeat(n) + s;
+}
+
+function fmtInt(sp, x) {
+    if (sp.verb === "c" || sp.verb === "q" || sp.verb === "U") {
+        var r = x < 0n || x > 0x10ffffn ? 0xfffd : Number(x);
+        if (sp.verb === "U") {
+            return pad(sp, "U+" + r.toString(16).toUpperCase().padStart(4, "0"), 0, false);
+        }
+        var c = String.fromCodePoint(r >= 0xd800 && r <= 0xdfff ? 0xfffd : r);
+        return pad(sp, sp.verb === "c" ? c : quote(c, "'"), 0, false);
+    }
+    var neg = x < 0n;
+    var base = { b: 2, o: 8, x: 16, X: 16 }[sp.verb] || 10;
+    var digits = (neg ? -x : x).toString(base);
+    if (sp.verb === "X") {
+        digits = digits.toUpperCase();
+    }
+    if (x === 0n) {
+        digits = "";
+    }
+    digits = digits.padStart(sp.prec >= 0 ? sp.prec : 1, "0");
+    var prefix = neg ? "-" : sp.plus ? "+" : sp.space ? " " : "";
+    if (sp.sharp) {
+        if (base === 2) {
+            prefix += "0b";
+        } else if (base === 16) {
+            prefix += "0" + sp.verb;
+        } else if (base === 8 && digits[0] !== "0") {
+            prefix += "0";
+        }
+    }
+    return pad(sp, prefix + digits, prefix.length, sp.zero && sp.prec < 0);
+}
+
+// 按 Go 的 %g 格式化浮点数, 使用能准确还原原值的最少的有效数字
+function formatShortest(x, is32, upper) {
+    var s;
+    for (var prec = 1; prec <= 17; prec++) {
+        s = x.toExponential(prec - 1);
+        if (is32 ? Math.fround(+s) === Math.fround(x) : +s === x) {
+            break;
+        }
+    }
+    var m = /^(-?)(\d)(?:\.(\d+))?e([+-]\d+)$/.exec(s);
+    var digits = (m[2] + (m[3] || "")).replace(/0+$/, "") || "0";
+    var exp = +m[4];
+    var out;
+    if (exp < -4 || exp >= 6) {
+        out = digits[0] + (digits.length > 1 ? "." + digits.slice(1) : "") +
+            (upper ? "E" : "e") + (exp < 0 ? "-" : "+") + String(Math.abs(exp)).padStart(2, "0");
+    } else if (exp < 0) {
+        out = "0." + "0".repeat(-exp - 1) + digits;
+    } else if (digits.length > exp + 1) {
+        out = digits.slice(0, exp + 1) + "." + digits.slice(exp + 1);
+    } else {
+        out = digits + "0".repeat(exp + 1 - digits.length);
+    }
+    return m[1] + out;
+}
+
+// 按 C 的 %g 格式化浮点数, prec 为有效数字的个数, 去掉末尾的 0
+function formatG(x, prec, upper) {
+    prec = prec === 0 ? 1 : prec;
+    var exp = +x.toExponential(prec - 1).split("e")[1];
+    var s;
+    if (exp < -4 || exp >= prec) {
+        s = fixExponent(x.toExponential(prec - 1).replace(/\.?0+e/, "e"), upper);
+    } else {
+        s = x.toFixed(prec - 1 - exp);
+        if (s.indexOf(".") >= 0) {
+            s = s.replace(/\.?0+$/, "");
+        }
+    }
+    return s;
+}
+
+function fmtFloat(sp, x, is32) {
+    var special = floatSpecial(x);
+    if (special !== null) {
+        if (special[0] === "+") {
+            special = sp.plus ? special : sp.space ? " Inf" : "Inf";
+        }
+        return pad(sp, special, 0, false);
+    }
+    var prec = sp.prec >= 0 ? sp.prec : 6;
+    var s;
+    switch (sp.verb) {
+    case "e":
+    case "E":
+        s = fixExponent(x.toExponential(prec), sp.verb === "E");
+        break;
+    case "f":
+    case "F":
+        s = x.toFixed(prec);
+        break;
+    default:
+        var upper = sp.verb === "G";
+        s = sp.prec < 0 ? formatShortest(x, is32, upper) : formatG(x, prec, upper);
+    }
+    if (s[0] !== "-") {
+        s = (sp.plus ? "+" : sp.space ? " " : "") + s;
+    }
+    return pad(sp, s, /^[-+ ]/.test(s) ? 1 : 0, sp.zero);
+}
+
+function fmtString(sp, s) {
+    if (sp.prec >= 0) {
+        s = [...s].slice(0, sp.prec).join("");
+    }
+    switch (sp.verb) {
+    case "q":
+        s = quote(s, '"');
+        break;
+    case "x":
+    case "X":
+        var bytes = [...Buffer.from(s, "utf8")].map(function (c) {
+            var h = c.toString(16).padStart(2, "0");
+            return sp.verb === "X" ? h.toUpperCase() : h;
+        });
+        var prefix = sp.sharp ? (sp.verb === "x" ? "0x" : "0X") : "";
+        s = sp.space ? bytes.map(function (h) { return prefix + h; }).join(" ") : prefix + bytes.join("");
+        break;
+    }
+    return pad(sp, s, 0, false);
+}
+
+// 按格式说明格式化一个参数, 类型和值不匹配时输出 %!verb(type=value)
+function fmtArg(sp, arg) {
+    var verb = sp.verb;
+    if (arg === null) {
+        return verb === "v" || verb === "T" ? pad(sp, "<nil>", 0, false) : "%!" + verb + "(<nil>)";
+    }
+    if (verb === "T") {
+        return pad(sp, arg.name, 0, false);
+    }
+    if (isIntKind(arg.kind) && "vdbcoqxXU".includes(verb)) {
+        return fmtInt(verb === "v" ? Object.assign({}, sp, { verb: "d" }) : sp, loadInt(arg));
+    }
+    if (isFloatKind(arg.kind) && "veEfFgG".includes(verb)) {
+        return fmtFloat(sp, loadFloat(arg), arg.kind === KIND.FLOAT32);
+    }
+    if (arg.kind === KIND.STRING && "vsqxX".includes(verb)) {
+        return fmtString(sp, loadArgString(arg));
+    }
+    if (arg.kind === KIND.BOOL && (verb === "v" || verb === "t")) {
+        return pad(sp, printArg(arg), 0, false);
+    }
+    if (arg.kind === KIND.POINTER && verb === "v" && arg.data === 0) {
+        return pad(sp, "<nil>", 0, false);
+    }
+    if (arg.kind === KIND.POINTER && (verb === "v" || verb === "p")) {
+        return pad(sp, hexPointer(arg.data), 0, false);
+    }
+    if (arg.kind === KIND.OTHER && verb === "v") {
+        return pad(sp, printArg(arg), 0, false);
+    }
+    return "%!" + verb + "(" + arg.name + "=" + fmtArg({ width: -1, prec: -1, verb: "v" }, arg) + ")";
+}
+
+// printf(format, args...), 和 builtin 运行时中的 tiny_go_builtin_printf 一致
+function sprintf(format, args) {
+    var out = "";
+    var argi = 0;
+    var re = /%([-+# 0]*)(\d*)(?:\.(\d*))?([\s\S]?)/g;
+    var last = 0;
+    var m;
+    while ((m = re.exec(format)) !== null) {
+        out += format.slice(last, m.index);
+        last = re.lastIndex;
+        if (m[4] === "") {
+            out += "%!(NOVERB)";
+            break;
+        }
+        var verb = String.fromCodePoint(format.slice(m.index + m[0].length - 1).codePointAt(0));
+        if (verb.length > 1) {
+            last = re.lastIndex = m.index + m[0].length + 1;
+        }
+        if (verb === "%") {
+            out += "%";
+            continue;
+        }
+        var sp = {
+            minus: m[1].includes("-"),
+            plus: m[1].includes("+"),
+            sharp: m[1].includes("#"),
+            zero: m[1].includes("0") && !m[1].includes("-"),
+            space: m[1].includes(" "),
+            width: m[2] === "" ? -1 : +m[2],
+            prec: m[3] === undefined ? -1 : +m[3],
+            verb: verb
+        };
+        if (argi >= args.length) {
+            out += "%!" + verb + "(MISSING)";
+            continue;
+        }
+        out += fmtArg(sp, args[argi++]);
+    }
+    out += format.slice(last);
+    if (argi < args.length) {
+        out += "%!(EXTRA " + args.slice(argi).map(function (arg) {
+            return arg === null ? "<nil>" : arg.name + "=" + fmtArg({ width: -1, prec: -1, verb: "v" }, arg);
+        }).join(", ") + ")";
+    }
+    return out;
+}
+
+// 打印函数的输出直接写到 stdout, 返回写出的字节数
+function writeOut(s) {
+    process.stdout.write(s);
+    return Buffer.byteLength(s, "utf8");
+}
+
 WebAssembly.instantiate(
     new Uint8Array(fs.readFileSync('./a.out.wasm')),
     {
         env: {
-            tiny_go_builtin_println: function (n) {
-                console.log(n);
+            tiny_go_builtin_print: function (p, n) {
+                return writeOut(loadArgs(p, n).map(printArg).join(""));
+            },
+            tiny_go_builtin_println: function (p, n) {
+                return writeOut(loadArgs(p, n).map(printArg).join(" ") + "\n");
+            },
+            tiny_go_builtin_printf: function (format, nformat, p, n) {
+                return writeOut(sprintf(loadString(format, nformat), loadArgs(p, n)));
+            },
+            tiny_go_builtin_string_concat: function (a, na, b, nb) {
+                var s = alloc(na + nb);
+                var mem = memory();
+                mem.copyWithin(s, a, a + na);
+                mem.copyWithin(s + na, b, b + nb);
+                return s;
+            },
+            tiny_go_builtin_string_compare: function (a, na, b, nb) {
+                var mem = memory();
+                var n = Math.min(na, nb);
+                for (var i = 0; i < n; i++) {
+                    if (mem[a + i] !== mem[b + i]) {
+                        return mem[a + i] < mem[b + i] ? -1 : 1;
+                    }
+                }
+                return na === nb ? 0 : (na < nb ? -1 : 1);
+            },
+            tiny_go_builtin_panic_index: function (pos, npos, index, length) {
+                if (index < 0) {
+                    console.log("panic: runtime error: index out of range [" + index + "]");
+                } else {
+                    console.log("panic: runtime error: index out of range [" + index + "] with length " + length);
+                }
+                console.log("\t" + loadString(pos, npos));
+                throw new Error("exit: 2");
+            },
+            tiny_go_builtin_panic_slice: function (pos, npos, low, high, max, isString) {
+                if (high < 0 || high > max) {
+                    console.log("panic: runtime error: slice bounds out of range [:" + high + "] with " +
+                        (isString ? "length " : "capacity ") + max);
+                } else {
+                    console.log("panic: runtime error: slice bounds out of range [" + low + ":" + high + "]");
+                }
+                console.log("\t" + loadString(pos, npos));
+                throw new Error("exit: 2");
+            },
+            tiny_go_builtin_panic_nil: function (pos, npos) {
+                console.log("panic: runtime error: invalid memory address or nil pointer dereference");
+                console.log("\t" + loadString(pos, npos));
+                throw new Error("exit: 2");
+            },
+            tiny_go_builtin_panic_divide: function (pos, npos) {
+                console.log("panic: runtime error: integer divide by zero");
+                console.log("\t" + loadString(pos, npos));
+                throw new Error("exit: 2");
+            },
+            tiny_go_builtin_panic_shift: function (pos, npos) {
+                console.log("panic: runtime error: negative shift amount");
+                console.log("\t" + loadString(pos, npos));
+                throw new Error("exit: 2");
+            },
+            // wasm 中不支持 longjmp, panic 直接结束程序, recover 总是返回 nil
+            tiny_go_builtin_panic: function (itab, data, pos, npos) {
+                if (itab === 0) {
+                    console.log("panic: panic called with nil argument");
+                } else {
+                    // 类型描述符在 wasm32 中的布局为 { name, n, equal, kind }, 每个字段 4 字节
+                    var typ = new Int32Array(wasmInstance.exports.memory.buffer, new Int32Array(wasmInstance.exports.memory.buffer, itab, 1)[0], 4);
+                    var kind = typ[3] & 0xff;
+                    var value = "(" + loadString(typ[0], typ[1]) + ")";
+                    if (kind === 4) {
+                        value = new Int32Array(wasmInstance.exports.memory.buffer, data, 1)[0];
+                    } else if (kind === 12) {
+                        var str = new Int32Array(wasmInstance.exports.memory.buffer, data, 2);
+                        value = loadString(str[0], str[1]);
+                    }
+                    console.log("panic: " + value);
+                }
+                console.log("\t" + loadString(pos, npos));
+                throw new Error("exit: 2");
+            },
+            tiny_go_builtin_recover: function (frame, value) {
+                new Int32Array(wasmInstance.exports.memory.buffer, value, 2).fill(0);
+            },
+            tiny_go_builtin_frame_push: function (frame, name, nname, jmp) {
+            },
+            tiny_go_builtin_frame_pop: function (frame) {
+            },
+            _setjmp: function (buf) {
                 return 0;
             },
+            // 新分配的内存没有被使用过, 内容都是 0
+            tiny_go_builtin_alloc: function (size) {
+                return alloc(size);
+            },
+            tiny_go_builtin_make_slice: function (s, len, cap, elemSize) {
+                if (len < 0) {
+                    runtimePanic("makeslice: len out of range");
+                }
+                if (cap < len) {
+                    runtimePanic("makeslice: cap out of range");
+                }
+                var ptr = alloc(cap * elemSize);
+                var header = sliceHeader(s);
+                header[0] = ptr;
+                header[1] = len;
+                header[2] = cap;
+            },
+            tiny_go_builtin_slice_grow: function (s, newLen, elemSize) {
+                var header = sliceHeader(s);
+                if (newLen > header[2]) {
+                    var newCap = Math.max(header[2] * 2, newLen);
+                    var ptr = alloc(newCap * elemSize);
+                    memory().copyWithin(ptr, header[0], header[0] + header[1] * elemSize);
+                    header = sliceHeader(s);
+                    header[0] = ptr;
+                    header[2] = newCap;
+                }
+                header[1] = newLen;
+            },
             tiny_go_builtin_exit: function (n) {
                 console.log("exit:", n);
                 return 0;
@@ -30,6 +30,7 @@ const (
 	PACKAGE
 	IMPORT
 	VAR
+	CONST
 	FUNC
 	RETURN
 	IF
@@ -39,6 +40,18 @@ const (
 	CONTINUE
 	DEFER
 	GOTO
+	SWITCH
+	CASE
+	DEFAULT
+	FALLTHROUGH
+	TYPE
+	STRUCT
+	INTERFACE
+	MAP
+	CHAN
+	GO
+	SELECT
+	RANGE
 
 	ADD // +
 	SUB // -
@@ -53,9 +66,31 @@ const (
 	GTR // >
 	GEQ // >=
 
-	AND // &&
-	OR  // ||
-	NOT // !
+	AND     // &&
+	OR      // ||
+	NOT     // !
+	BIT_AND // &
+	BIT_OR  // |
+	XOR     // ^
+	SHL     // <<
+	SHR     // >>
+	AND_NOT // &^
+
+	ADD_ASSIGN     // +=
+	SUB_ASSIGN     // -=
+	MUL_ASSIGN     // *=
+	DIV_ASSIGN     // /=
+	MOD_ASSIGN     // %=
+	AND_ASSIGN     // &=
+	OR_ASSIGN      // |=
+	XOR_ASSIGN     // ^=
+	SHL_ASSIGN     // <<=
+	SHR_ASSIGN     // >>=
+	AND_NOT_ASSIGN // &^=
+
+	INC   // ++
+	DEC   // --
+	ARROW // <-
 
 	ASSIGN // =
 	DEFINE // :=
@@ -71,6 +106,7 @@ const (
 	SEMICOLON // ;
 	PERIOD    // .
 	COLON     // :
+	ELLIPSIS  // ...
 )
 
 func (op TokenType) Precedence() int {
@@ -81,14 +117,35 @@ func (op TokenType) Precedence() int {
 		return 2
 	case EQL, NEQ, LSS, LEQ, GTR, GEQ:
 		return 3
-	case ADD, SUB:
+	case ADD, SUB, BIT_OR, XOR:
 		return 4
-	case MUL, DIV, MOD:
+	case MUL, DIV, MOD, BIT_AND, SHL, SHR, AND_NOT:
 		return 5
 	}
 	return 0
 }
 
+// assignOps 复合赋值运算符对应的二元运算符
+var assignOps = map[TokenType]TokenType{
+	ADD_ASSIGN:     ADD,
+	SUB_ASSIGN:     SUB,
+	MUL_ASSIGN:     MUL,
+	DIV_ASSIGN:     DIV,
+	MOD_ASSIGN:     MOD,
+	AND_ASSIGN:     BIT_AND,
+	OR_ASSIGN:      BIT_OR,
+	XOR_ASSIGN:     XOR,
+	SHL_ASSIGN:     SHL,
+	SHR_ASSIGN:     SHR,
+	AND_NOT_ASSIGN: AND_NOT,
+}
+
+// AssignOp 获取复合赋值运算符 (如 +=) 对应的二元运算符, 不是复合赋值时 ok 为 false
+func (op TokenType) AssignOp() (binOp TokenType, ok bool) {
+	binOp, ok = assignOps[op]
+	return
+}
+
 var tokens = [...]string{
 	EOF:     "EOF",
 	ERROR:   "ERROR",
@@ -100,18 +157,31 @@ var tokens = [...]string{
 	CHAR:   "CHAR",
 	STRING: "STRING",
 
-	PACKAGE:  "package",
-	IMPORT:   "import",
-	VAR:      "var",
-	FUNC:     "func",
-	RETURN:   "return",
-	IF:       "if",
-	ELSE:     "else",
-	FOR:      "for",
-	BREAK:    "break",
-	CONTINUE: "continue",
-	DEFER:    "defer",
-	GOTO:     "goto",
+	PACKAGE:     "package",
+	IMPORT:      "import",
+	VAR:         "var",
+	CONST:       "const",
+	FUNC:        "func",
+	RETURN:      "return",
+	IF:          "if",
+	ELSE:        "else",
+	FOR:         "for",
+	BREAK:       "break",
+	CONTINUE:    "continue",
+	DEFER:       "defer",
+	GOTO:        "goto",
+	SWITCH:      "switch",
+	CASE:        "case",
+	DEFAULT:     "default",
+	FALLTHROUGH: "fallthrough",
+	TYPE:        "type",
+	STRUCT:      "struct",
+	INTERFACE:   "interface",
+	MAP:         "map",
+	CHAN:        "chan",
+	GO:          "go",
+	SELECT:      "select",
+	RANGE:       "range",
 
 	ADD: "+",
 	SUB: "-",
@@ -130,6 +200,29 @@ var tokens = [...]string{
 	OR:  "||",
 	NOT: "!",
 
+	BIT_AND: "&",
+	BIT_OR:  "|",
+	XOR:     "^",
+	SHL:     "<<",
+	SHR:     ">>",
+	AND_NOT: "&^",
+
+	ADD_ASSIGN:     "+=",
+	SUB_ASSIGN:     "-=",
+	MUL_ASSIGN:     "*=",
+	DIV_ASSIGN:     "/=",
+	MOD_ASSIGN:     "%=",
+	AND_ASSIGN:     "&=",
+	OR_ASSIGN:      "|=",
+	XOR_ASSIGN:     "^=",
+	SHL_ASSIGN:     "<<=",
+	SHR_ASSIGN:     ">>=",
+	AND_NOT_ASSIGN: "&^=",
+
+	INC:   "++",
+	DEC:   "--",
+	ARROW: "<-",
+
 	ASSIGN: "=",
 	DEFINE: ":=",
 
@@ -144,6 +237,7 @@ var tokens = [...]string{
 	SEMICOLON: ";",
 	PERIOD:    ".",
 	COLON:     ":",
+	ELLIPSIS:  "...",
 }
 
 func (op TokenType) String() string {
@@ -170,18 +264,31 @@ func (t Token) IntValue() int {
 }
 
 var keywords = map[string]TokenType{
-	"package":  PACKAGE,
-	"return":   RETURN,
-	"import":   IMPORT,
-	"func":     FUNC,
-	"var":      VAR,
-	"if":       IF,
-	"else":     ELSE,
-	"for":      FOR,
-	"break":    BREAK,
-	"continue": CONTINUE,
-	"defer":    DEFER,
-	"goto":     GOTO,
+	"package":     PACKAGE,
+	"return":      RETURN,
+	"import":      IMPORT,
+	"func":        FUNC,
+	"var":         VAR,
+	"const":       CONST,
+	"if":          IF,
+	"else":        ELSE,
+	"for":         FOR,
+	"break":       BREAK,
+	"continue":    CONTINUE,
+	"defer":       DEFER,
+	"goto":        GOTO,
+	"switch":      SWITCH,
+	"case":        CASE,
+	"default":     DEFAULT,
+	"fallthrough": FALLTHROUGH,
+	"type":        TYPE,
+	"struct":      STRUCT,
+	"interface":   INTERFACE,
+	"map":         MAP,
+	"chan":        CHAN,
+	"go":          GO,
+	"select":      SELECT,
+	"range":       RANGE,
 }
 
 func LoopUp(ident string) TokenType {
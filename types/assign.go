@@ -0,0 +1,254 @@
+package types
+
+import (
+	"fmt"
+	"tiny-go/ast"
+	"tiny-go/token"
+)
+
+// checkAssignable 检查 from 类型的值 expr 能否赋值给 to 类型, context 说明赋值发生的位置.
+// expr 为常量时还要检查常量的值能否用 to 类型表示
+func (c *Checker) checkAssignable(expr ast.Expr, from, to Type, context string) {
+	tv := c.info.Types[expr]
+	isConst := tv.mode == constant_
+	if !AssignableTo(from, to) {
+		var reason string
+		if iface, ok := Underlying(to).(*Interface); ok && from != Typ[UntypedNil] {
+			reason = fmt.Sprintf(": %s does not implement %s (%s)", Default(from), to, missingMethodReason(Default(from), iface))
+		}
+		if isConst {
+			c.errorf(expr.Pos(), "cannot use %s (%s) as %s value in %s%s", exprString(expr), constDesc(expr, tv.Value, from), to, context, reason)
+		}
+		c.errorf(expr.Pos(), "cannot use value of type %s as %s value in %s%s", from, to, context, reason)
+	}
+	if isConst {
+		// 赋值给接口时常量先转换为默认类型
+		target := to
+		if IsInterface(to) {
+			target = Default(from)
+		}
+		if _, reason := representable(tv.Value, target); reason != "" {
+			c.errorf(expr.Pos(), "cannot use %s (%s) as %s value in %s (%s)", exprString(expr), constDesc(expr, tv.Value, from), target, context, reason)
+		}
+	}
+}
+
+// missingMethodReason 说明 typ 为什么没有实现 iface, 用于错误信息
+func missingMethodReason(typ Type, iface *Interface) string {
+	m, ptrRecv := MissingMethod(typ, iface)
+	if ptrRecv {
+		return fmt.Sprintf("method %s has pointer receiver", m.Name)
+	}
+	return fmt.Sprintf("missing method %s", m.Name)
+}
+
+// varType 获取变量声明的类型, 没有指定类型时由初始值推导
+func (c *Checker) varType(spec *ast.VarSpec) Type {
+	if spec.Type != nil {
+		return c.typExpr(spec.Type)
+	}
+	if spec.Value == nil {
+		c.errorf(spec.VarPos, "missing type or init expr")
+	}
+	return Default(c.valueType(spec.Value))
+}
+
+// varSpec 检查变量声明, 并把变量加入当前作用域
+func (c *Checker) varSpec(spec *ast.VarSpec) {
+	typ := c.varType(spec)
+	if typ == Typ[UntypedNil] {
+		c.errorf(spec.Value.Pos(), "use of untyped nil in variable declaration")
+	}
+	if spec.Value != nil {
+		c.checkAssignable(spec.Value, c.valueType(spec.Value), typ, "variable declaration")
+	}
+	c.declare(spec.Name, &Object{
+		Name: spec.Name.Name,
+		Kind: ObjVar,
+		Type: typ,
+		Node: spec,
+	})
+}
+
+// assign 检查赋值语句, 包括 := 和复合赋值
+func (c *Checker) assign(stmt *ast.AssignStmt) {
+	if op, ok := stmt.Op.AssignOp(); ok {
+		c.opAssign(stmt, op)
+		return
+	}
+	c.assignTargets(stmt, c.assignValues(stmt))
+}
+
+// opAssign 检查复合赋值 x op= y
+func (c *Checker) opAssign(stmt *ast.AssignStmt, op token.TokenType) {
+	target, value := stmt.Target[0], stmt.Value[0]
+	typ, yTyp := c.valueType(target), c.valueType(value)
+	if op != token.SHL && op != token.SHR {
+		c.checkConstOperand(value, typ)
+	}
+	c.checkBinary(op, stmt.OpPos, target, value, typ, yTyp)
+	c.assignTarget(target)
+}
+
+// incDec 检查 x++ 和 x--, 相当于 x += 1 和 x -= 1
+func (c *Checker) incDec(stmt *ast.IncDecStmt) {
+	if typ := c.valueType(stmt.X); !IsNumeric(typ) {
+		c.errorf(stmt.TokPos, "invalid operation: %s%v (non-numeric type %s)", exprString(stmt.X), stmt.Tok, typ)
+	}
+	c.assignTarget(stmt.X)
+}
+
+// assignValues 获取赋值语句右边每个值的类型, 支持 v, ok := x.(T), v, ok := m[k] 和 v, ok := <-ch
+func (c *Checker) assignValues(stmt *ast.AssignStmt) []Type {
+	if len(stmt.Target) == 2 && len(stmt.Value) == 1 {
+		switch x := stmt.Value[0].(type) {
+		case *ast.TypeAssertExpr:
+			return []Type{c.valueType(x), Typ[UntypedBool]}
+		case *ast.IndexExpr:
+			if c.isMapIndex(x) {
+				return []Type{c.valueType(x), Typ[UntypedBool]}
+			}
+		case *ast.UnaryExpr:
+			if x.Op == token.ARROW {
+				return []Type{c.valueType(x), Typ[UntypedBool]}
+			}
+		}
+	}
+	c.checkAssignCount(stmt)
+	return c.values(stmt.Value)
+}
+
+// assignTargets 检查把 types 类型的值赋值给 stmt 左边的变量, := 时先声明新的变量
+func (c *Checker) assignTargets(stmt *ast.AssignStmt, types []Type) {
+	defined := make(map[ast.Expr]Type)
+	if stmt.Op == token.DEFINE {
+		for i, target := range stmt.Target {
+			ident := target.(*ast.Ident)
+			if ident.Name == "_" || c.scope.HasName(ident.Name) {
+				continue
+			}
+			typ := Default(types[i])
+			if typ == Typ[UntypedNil] {
+				c.errorf(valuePos(stmt.Value, i), "use of untyped nil in assignment")
+			}
+			c.declare(ident, &Object{
+				Name: ident.Name,
+				Kind: ObjVar,
+				Type: typ,
+				Node: ident,
+			})
+			c.record(ident, variable, typ, nil)
+			defined[ident] = typ
+		}
+		if len(defined) == 0 {
+			c.errorf(stmt.OpPos, "no new variables on left side of :=")
+		}
+	}
+
+	context := "assignment"
+	if stmt.Op == token.DEFINE {
+		context = "variable declaration"
+	}
+	for i, target := range stmt.Target {
+		// 赋值给 _ 的值只求值不保存
+		if isBlank(target) {
+			if types[i] == Typ[UntypedNil] {
+				c.errorf(stmt.OpPos, "use of untyped nil in assignment")
+			}
+			continue
+		}
+		typ, ok := defined[target]
+		if !ok {
+			typ = c.assignTarget(target)
+		}
+		c.checkAssignable(valueExpr(stmt.Value, i), types[i], typ, context)
+	}
+}
+
+// assignTarget 检查赋值的目标是否可以赋值, 返回目标的类型
+func (c *Checker) assignTarget(target ast.Expr) Type {
+	switch x := target.(type) {
+	case *ast.Ident:
+		obj := c.lookup(x)
+		if obj.Kind != ObjVar {
+			c.errorf(x.NamePos, "cannot assign to %s (neither addressable nor a map index expression)", x.Name)
+		}
+		return c.record(x, variable, obj.Type, nil).Type
+	case *ast.IndexExpr:
+		if typ := c.valueType(x.X); IsString(typ) {
+			c.errorf(x.Lbrack, "cannot assign to element of string (strings are immutable)")
+		}
+	case *ast.SelectorExpr:
+		if !c.operand(x).Addressable() && c.isMapIndex(x.X) {
+			c.errorf(x.Sel.NamePos, "cannot assign to struct field %s in map", exprString(x))
+		}
+	case *ast.ParenExpr:
+		typ := c.assignTarget(x.X)
+		return c.record(x, c.info.Types[x.X].mode, typ, nil).Type
+	}
+	tv := c.operand(target)
+	if !tv.Assignable() {
+		c.errorf(target.Pos(), "cannot assign to value of type %s (neither addressable nor a map index expression)", tv.Type)
+	}
+	return tv.Type
+}
+
+// checkAssignCount 检查赋值语句两边的个数是否一致
+func (c *Checker) checkAssignCount(stmt *ast.AssignStmt) {
+	n := c.valueCount(stmt.Value)
+	if n == len(stmt.Target) {
+		return
+	}
+	if len(stmt.Value) == 1 {
+		if _, ok := stmt.Value[0].(*ast.CallExpr); ok {
+			c.errorf(stmt.OpPos, "assignment mismatch: %s but %s returns %s",
+				plural(len(stmt.Target), "variable"), exprString(stmt.Value[0]), plural(n, "value"))
+		}
+	}
+	c.errorf(stmt.OpPos, "assignment mismatch: %s but %s", plural(len(stmt.Target), "variable"), plural(n, "value"))
+}
+
+// valueCount 获取赋值或 return 右边值的个数, 多返回值的函数调用展开为多个值
+func (c *Checker) valueCount(exprs []ast.Expr) int {
+	if len(exprs) == 1 {
+		if tuple, ok := c.expr(exprs[0]).(*Tuple); ok {
+			return len(tuple.Types)
+		}
+	}
+	return len(exprs)
+}
+
+// values 获取赋值, return 或调用参数中每个值的类型, 多返回值的函数调用展开为多个值
+func (c *Checker) values(exprs []ast.Expr) (types []Type) {
+	if len(exprs) == 1 {
+		if tuple, ok := c.expr(exprs[0]).(*Tuple); ok {
+			return tuple.Types
+		}
+	}
+	for _, expr := range exprs {
+		types = append(types, c.valueType(expr))
+	}
+	return types
+}
+
+// returnStmt 检查 return 语句的值和函数的返回值类型是否一致
+func (c *Checker) returnStmt(stmt *ast.ReturnStmt) {
+	results := c.fn.sig.Results()
+
+	// 命名返回值的函数可以使用不带值的 return
+	if len(stmt.Results) == 0 && c.fn.namedResults {
+		return
+	}
+
+	types := c.values(stmt.Results)
+	if len(types) != len(results) {
+		msg := "not enough return values"
+		if len(types) > len(results) {
+			msg = "too many return values"
+		}
+		c.errorf(stmt.Return, "%s\n\thave %s\n\twant %s", msg, tupleString(types), tupleString(results))
+	}
+	for i, typ := range results {
+		c.checkAssignable(valueExpr(stmt.Results, i), types[i], typ, "return statement")
+	}
+}
@@ -0,0 +1,420 @@
+package types
+
+import (
+	"go/constant"
+	"tiny-go/ast"
+	"tiny-go/token"
+)
+
+// call 检查函数调用, 包括内置函数的调用, 类型转换和方法调用.
+// 没有返回值的函数调用的类型为 int, 和编译器中的 i32 0 一致
+func (c *Checker) call(expr *ast.CallExpr) TypeAndValue {
+	if obj := c.calleeObject(expr); obj != nil && obj.Kind == ObjBuiltin {
+		return c.builtinCall(expr, obj)
+	}
+	if c.isConversion(expr) {
+		return c.conversion(expr)
+	}
+	return c.funcCall(expr)
+}
+
+// funcCall 检查普通函数, 函数值和方法的调用, 也用于 defer 和 go 语句
+func (c *Checker) funcCall(expr *ast.CallExpr) TypeAndValue {
+	sig := c.lookupFunc(expr)
+	c.arguments(expr, sig)
+	if sig.Result == nil {
+		return c.record(expr, value, Typ[Int], nil)
+	}
+	return c.record(expr, value, sig.Result, nil)
+}
+
+// calleeObject 查找按名字调用的函数 f(...) 中 f 对应的对象, 没有找到或不是按名字调用时返回 nil
+func (c *Checker) calleeObject(expr *ast.CallExpr) *Object {
+	if expr.Fun != nil || expr.Pkg != nil || expr.Recv != nil {
+		return nil
+	}
+	_, obj := c.scope.Lookup(expr.FuncName.Name)
+	if obj != nil {
+		c.info.Uses[expr.FuncName] = obj
+	}
+	return obj
+}
+
+// lookupFunc 查找被调用的函数或方法, 返回函数类型. 调用的方法和函数类型的字段记录在 Selections 中
+func (c *Checker) lookupFunc(expr *ast.CallExpr) *Signature {
+	if fn := c.funcValue(expr); fn != nil {
+		return c.funcValueType(expr, fn)
+	}
+	if recv := c.methodRecv(expr); recv != nil {
+		return c.lookupMethod(expr, recv)
+	}
+	if expr.Pkg != nil {
+		_, pkg := c.scope.Lookup(expr.Pkg.Name)
+		if pkg == nil || pkg.Kind != ObjPkg {
+			c.errorf(expr.Pkg.NamePos, "undefined: %s", expr.Pkg.Name)
+		}
+		c.info.Uses[expr.Pkg] = pkg
+
+		// builtin 包中的函数和 Universe 中同名的函数一致
+		if obj := Universe.Objects[expr.FuncName.Name]; obj != nil && obj.Kind == ObjFunc &&
+			pkg.Node.(*ast.ImportSpec).Path == "builtin" {
+			c.info.Uses[expr.FuncName] = obj
+			return obj.Type.(*Signature)
+		}
+		// 目前只能调用 builtin 包中的函数, 其他包的函数签名未知
+		c.errorf(expr.FuncName.NamePos, "undefined: %s.%s", expr.Pkg.Name, expr.FuncName.Name)
+	}
+
+	obj := c.lookup(expr.FuncName)
+	if obj.Kind != ObjFunc {
+		c.errorf(expr.FuncName.NamePos, "cannot call non-function %s", expr.FuncName.Name)
+	}
+	return obj.Type.(*Signature)
+}
+
+// funcValue 获取被调用的函数值, 不是调用函数值时返回 nil.
+// 调用 x.f() 中函数类型的字段 f 时返回 x, 字段记录在 Selections 中
+func (c *Checker) funcValue(expr *ast.CallExpr) ast.Expr {
+	if expr.Fun != nil {
+		return expr.Fun
+	}
+	if recv := c.methodRecv(expr); recv != nil {
+		typ := c.valueType(recv)
+		ptr, indirect := Underlying(typ).(*Pointer)
+		if indirect {
+			typ = ptr.Elem
+		}
+		if s, ok := Underlying(typ).(*Struct); ok {
+			if index := s.FieldIndex(expr.FuncName.Name); index >= 0 {
+				c.info.Selections[expr] = &Selection{
+					Kind:     FieldVal,
+					Recv:     c.expr(recv),
+					Index:    index,
+					Indirect: indirect,
+					Type:     s.Fields[index].Type,
+				}
+				return recv
+			}
+		}
+		return nil
+	}
+	if obj := c.calleeObject(expr); obj != nil && obj.Kind == ObjVar {
+		return expr.FuncName
+	}
+	return nil
+}
+
+// funcValueType 获取被调用的函数值的类型, 调用函数类型的字段 x.f() 时为字段的类型
+func (c *Checker) funcValueType(expr *ast.CallExpr, fn ast.Expr) *Signature {
+	var typ Type
+	desc := exprString(fn)
+	if sel := c.info.Selections[expr]; sel != nil {
+		typ = sel.Type
+		desc += "." + expr.FuncName.Name
+	} else {
+		typ = c.valueType(fn)
+	}
+	sig, ok := Underlying(typ).(*Signature)
+	if !ok {
+		c.errorf(fn.Pos(), "invalid operation: cannot call non-function %s (variable of type %s)", desc, typ)
+	}
+	return sig
+}
+
+// methodRecv 获取方法调用的接收者, 不是方法调用时返回 nil.
+// x.M() 和 pkg.fn() 的形式相同, 由 x 是否为包名区分
+func (c *Checker) methodRecv(expr *ast.CallExpr) ast.Expr {
+	if expr.Recv != nil {
+		return expr.Recv
+	}
+	if expr.Pkg != nil {
+		if _, obj := c.scope.Lookup(expr.Pkg.Name); obj != nil && obj.Kind != ObjPkg {
+			return expr.Pkg
+		}
+	}
+	return nil
+}
+
+// lookupMethod 查找 recv.M 对应的方法. 方法的接收者是指针而 recv 不是时, recv 必须可以取地址
+func (c *Checker) lookupMethod(expr *ast.CallExpr, recv ast.Expr) *Signature {
+	sel := expr.FuncName
+	x := c.operand(recv)
+	typ := c.valueType(recv)
+
+	var m *Object
+	index := -1
+	if iface, ok := Underlying(typ).(*Interface); ok {
+		m, index = iface.Method(sel.Name)
+	} else if named := recvBase(typ); named != nil {
+		m = named.Method(sel.Name)
+	}
+	if m == nil {
+		c.errorf(sel.NamePos, "%s.%s undefined (type %s has no field or method %s)", exprString(recv), sel.Name, typ, sel.Name)
+	}
+
+	sig := m.Type.(*Signature)
+	_, isPtr := typ.(*Pointer)
+	if _, wantPtr := sig.Recv.(*Pointer); wantPtr && !isPtr && !x.Addressable() {
+		c.errorf(sel.NamePos, "cannot call pointer method %s on %s", sel.Name, typ)
+	}
+	c.info.Uses[sel] = m
+	c.info.Selections[expr] = &Selection{
+		Kind:     MethodVal,
+		Recv:     typ,
+		Obj:      m,
+		Index:    index,
+		Indirect: isPtr,
+		Type:     sig,
+	}
+	return sig
+}
+
+// recvBase 获取接收者类型 T 或 *T 中的命名类型 T, 不是命名类型时返回 nil
+func recvBase(typ Type) *Named {
+	if ptr, ok := typ.(*Pointer); ok {
+		typ = ptr.Elem
+	}
+	named, _ := typ.(*Named)
+	return named
+}
+
+// hasMethod 判断 typ 或接口类型 typ 是否有名为 name 的方法
+func hasMethod(typ Type, name string) bool {
+	if iface, ok := Underlying(typ).(*Interface); ok {
+		m, _ := iface.Method(name)
+		return m != nil
+	}
+	named := recvBase(typ)
+	return named != nil && named.Method(name) != nil
+}
+
+// arguments 检查调用参数的个数和类型, 可变参数函数多出的参数检查能否赋值给 ...T 中的 T
+func (c *Checker) arguments(expr *ast.CallExpr, sig *Signature) {
+	c.checkArgs(expr, sig)
+	types := c.values(expr.Args)
+	context := "argument to " + funcString(expr)
+	for i, param := range sig.Params {
+		if sig.Variadic && i == len(sig.Params)-1 && !expr.Ellipsis.IsValid() {
+			for j := i; j < len(types); j++ {
+				c.checkAssignable(valueExpr(expr.Args, j), types[j], param.(*Slice).Elem, context)
+			}
+			break
+		}
+		c.checkAssignable(valueExpr(expr.Args, i), types[i], param, context)
+	}
+}
+
+// checkArgs 检查调用参数的个数, 参数可以是一个多返回值的函数调用.
+// 可变参数函数的参数可以比参数列表少一个或更多, 使用 f(xs...) 时个数必须相同
+func (c *Checker) checkArgs(expr *ast.CallExpr, sig *Signature) {
+	spread := expr.Ellipsis.IsValid()
+	if spread && !sig.Variadic {
+		c.errorf(expr.Ellipsis, "cannot use ... in call to non-variadic %s", funcString(expr))
+	}
+	n := c.valueCount(expr.Args)
+	if sig.Variadic && !spread && n >= len(sig.Params)-1 || n == len(sig.Params) {
+		return
+	}
+	types := c.values(expr.Args)
+	if n < len(sig.Params) {
+		c.errorf(expr.Rparen, "not enough arguments in call to %s\n\thave %s\n\twant %s",
+			funcString(expr), tupleString(types), paramsString(sig))
+	}
+	pos := expr.Args[0].Pos()
+	if len(expr.Args) > len(sig.Params) {
+		pos = expr.Args[len(sig.Params)].Pos()
+	}
+	c.errorf(pos, "too many arguments in call to %s\n\thave %s\n\twant %s",
+		funcString(expr), tupleString(types), paramsString(sig))
+}
+
+// isConversion 判断调用是否为类型转换 T(x)
+func (c *Checker) isConversion(expr *ast.CallExpr) bool {
+	if expr.Fun != nil {
+		return isTypeExpr(expr.Fun)
+	}
+	obj := c.calleeObject(expr)
+	return obj != nil && obj.Kind == ObjType
+}
+
+// conversion 检查类型转换 T(x). 常量转换为基础类型的结果仍是常量, 值必须能用 T 表示
+func (c *Checker) conversion(expr *ast.CallExpr) TypeAndValue {
+	var typ Type
+	if expr.Fun != nil {
+		typ = c.typExpr(expr.Fun)
+	} else {
+		typ = c.typExpr(expr.FuncName)
+	}
+	switch len(expr.Args) {
+	case 0:
+		c.errorf(expr.Rparen, "missing argument in conversion to %s", typ)
+	case 1:
+	default:
+		c.errorf(expr.Args[1].Pos(), "too many arguments in conversion to %s", typ)
+	}
+	arg := expr.Args[0]
+	from := c.valueType(arg)
+	if !ConvertibleTo(from, typ) {
+		c.errorf(arg.Pos(), "cannot convert %s (value of type %s) to type %s", exprString(arg), from, typ)
+	}
+
+	x := c.info.Types[arg]
+	if expr.Fun != nil || x.mode != constant_ ||
+		!(IsNumeric(typ) && IsNumeric(from) || IsString(typ) && IsString(from) || IsBoolean(typ) && IsBoolean(from)) {
+		return c.record(expr, value, typ, nil)
+	}
+	v, reason := representable(x.Value, typ)
+	if reason != "" {
+		c.errorf(arg.Pos(), "cannot convert %s (%s) to type %s (%s)", exprString(arg), constDesc(arg, x.Value, from), typ, reason)
+	}
+	return c.record(expr, constant_, typ, v)
+}
+
+// builtinCall 检查 len 等内置函数的调用
+func (c *Checker) builtinCall(expr *ast.CallExpr, obj *Object) TypeAndValue {
+	switch name := obj.Name; name {
+	case "make":
+		c.checkArgCount(expr, 1, 3)
+		typ := c.typExpr(expr.Args[0])
+		c.checkBuiltinEllipsis(expr)
+		c.checkMake(expr, typ)
+		return c.record(expr, value, typ, nil)
+	case "append":
+		c.checkArgCount(expr, 1, -1)
+		return c.record(expr, value, c.checkAppend(expr), nil)
+	case "new":
+		c.checkArgCount(expr, 1, 1)
+		typ := c.typExpr(expr.Args[0])
+		c.checkBuiltinEllipsis(expr)
+		return c.record(expr, value, &Pointer{Elem: typ}, nil)
+	}
+
+	c.checkBuiltinEllipsis(expr)
+	switch name := obj.Name; name {
+	case "len", "cap":
+		c.checkArgCount(expr, 1, 1)
+		arg := expr.Args[0]
+		x := c.operand(arg)
+		switch typ := Underlying(c.valueType(arg)).(type) {
+		case *Array, *Slice, *Chan:
+		case *Map:
+			if name != "len" {
+				c.errorf(arg.Pos(), "invalid argument: value of type %s for built-in %s", typ, name)
+			}
+		default:
+			if !IsString(typ) || name != "len" {
+				c.errorf(arg.Pos(), "invalid argument: value of type %s for built-in %s", typ, name)
+			}
+			// 常量字符串的长度是常量
+			if x.mode == constant_ {
+				return c.record(expr, constant_, Typ[Int], constant.MakeInt64(int64(len(constant.StringVal(x.Value)))))
+			}
+		}
+	case "delete":
+		c.checkArgCount(expr, 2, 2)
+		typ := c.valueType(expr.Args[0])
+		m, ok := Underlying(typ).(*Map)
+		if !ok {
+			c.errorf(expr.Args[0].Pos(), "invalid argument: %s (variable of type %s) is not a map", exprString(expr.Args[0]), typ)
+		}
+		c.mapKey(expr.Args[1], m, "argument to delete")
+	case "close":
+		c.checkArgCount(expr, 1, 1)
+		arg := expr.Args[0]
+		typ := c.valueType(arg)
+		ch, ok := Underlying(typ).(*Chan)
+		if !ok {
+			c.errorf(arg.Pos(), "invalid argument: %s (variable of type %s) is not a channel", exprString(arg), typ)
+		}
+		if ch.Dir == RecvOnly {
+			c.errorf(arg.Pos(), "invalid operation: cannot close receive-only channel %s (variable of type %s)", exprString(arg), typ)
+		}
+	case "panic":
+		c.checkArgCount(expr, 1, 1)
+		c.valueType(expr.Args[0])
+	case "recover":
+		c.checkArgCount(expr, 0, 0)
+		return c.record(expr, value, &Interface{}, nil)
+	default:
+		c.errorf(expr.FuncName.NamePos, "%s is not a function", name)
+	}
+	return c.record(expr, value, Typ[Int], nil)
+}
+
+// builtinStmt 检查 defer 和 go 语句中的内置函数调用, 只有没有结果的 delete, close 和 panic 可以使用.
+// keyword 为 "defer" 或 "go"
+func (c *Checker) builtinStmt(expr *ast.CallExpr, obj *Object, pos token.Pos, keyword string) {
+	c.checkBuiltinEllipsis(expr)
+	switch obj.Name {
+	case "delete", "close", "panic":
+		c.builtinCall(expr, obj)
+		return
+	}
+	c.errorf(pos, "%s discards result of %s", keyword, obj.Name)
+}
+
+// checkArgCount 检查内置函数的参数个数, max 为 -1 表示不限制
+func (c *Checker) checkArgCount(expr *ast.CallExpr, min, max int) {
+	name := expr.FuncName.Name
+	if n := len(expr.Args); n < min {
+		c.errorf(expr.Rparen, "not enough arguments for %s (expected %d, found %d)", name, min, n)
+	} else if max >= 0 && n > max {
+		c.errorf(expr.Args[max].Pos(), "too many arguments for %s (expected %d, found %d)", name, max, n)
+	}
+}
+
+// checkBuiltinEllipsis 只有 append 可以使用 f(xs...) 的形式调用
+func (c *Checker) checkBuiltinEllipsis(expr *ast.CallExpr) {
+	if name := expr.FuncName.Name; expr.Ellipsis.IsValid() && name != "append" {
+		c.errorf(expr.Ellipsis, "invalid operation: invalid use of ... with built-in %s", name)
+	}
+}
+
+// checkMake 检查 make(T, args...) 的参数, 切片需要长度, map 和 channel 的大小可以省略
+func (c *Checker) checkMake(expr *ast.CallExpr, typ Type) {
+	switch Underlying(typ).(type) {
+	case *Map, *Chan:
+		c.checkArgCount(expr, 1, 2)
+		if len(expr.Args) == 2 {
+			c.index(expr.Args[1])
+		}
+		return
+	}
+	c.checkArgCount(expr, 2, 3)
+	if _, ok := Underlying(typ).(*Slice); !ok {
+		c.errorf(expr.Args[0].Pos(), "invalid argument: cannot make %s; type must be slice, map, or channel", typ)
+	}
+	for _, arg := range expr.Args[1:] {
+		c.index(arg)
+	}
+	if len(expr.Args) == 3 {
+		n, ok1 := c.constInt(expr.Args[1])
+		m, ok2 := c.constInt(expr.Args[2])
+		if ok1 && ok2 && n > m {
+			c.errorf(expr.Args[1].Pos(), "invalid argument: length and capacity swapped")
+		}
+	}
+}
+
+// checkAppend 检查 append(s, x, y, ...) 和 append(s, t...), []byte 还可以追加字符串 append(b, str...).
+// 返回结果的类型, 即 s 的类型
+func (c *Checker) checkAppend(expr *ast.CallExpr) Type {
+	typ := c.valueType(expr.Args[0])
+	s, ok := Underlying(typ).(*Slice)
+	if !ok {
+		c.errorf(expr.Args[0].Pos(), "invalid argument: value of type %s is not a slice", typ)
+	}
+	if expr.Ellipsis.IsValid() {
+		c.checkArgCount(expr, 2, 2)
+		arg := expr.Args[1]
+		argTyp := c.valueType(arg)
+		if !IsString(argTyp) || !IsBasic(s.Elem, Uint8) {
+			c.checkAssignable(arg, argTyp, s, "argument to append")
+		}
+		return typ
+	}
+	for _, elem := range expr.Args[1:] {
+		c.checkAssignable(elem, c.valueType(elem), s.Elem, "argument to append")
+	}
+	return typ
+}
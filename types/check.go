@@ -0,0 +1,229 @@
+package types
+
+import (
+	"fmt"
+	"go/constant"
+	"tiny-go/ast"
+	"tiny-go/token"
+)
+
+// 类型检查在编译之前完成: 每个标识符解析为对象, 每个表达式的类型和常量的值记录在 Info 中,
+// 编译器只根据 Info 生成代码, 不再检查类型. 一条语句或一个声明出错后跳过它的其余部分,
+// 继续检查后面的语句, 出错的声明以 Typ[Invalid] 类型声明, 用到它的地方不再重复报错.
+
+// Info 类型检查的结果
+type Info struct {
+	Types      map[ast.Expr]TypeAndValue   // 表达式的类型, 包括类型表达式
+	Defs       map[*ast.Ident]*Object      // 声明的标识符对应的对象
+	Uses       map[*ast.Ident]*Object      // 引用的标识符对应的对象, 包括调用中的函数名和包名
+	Funcs      map[*ast.FuncDecl]*Object   // 函数和方法声明对应的对象
+	Implicits  map[*ast.CaseClause]*Object // 类型 switch 的每个分支中 x := v.(type) 声明的变量
+	Selections map[ast.Expr]*Selection     // x.f 和 x.f(...) 选择的字段或方法, 键为 *ast.SelectorExpr 或 *ast.CallExpr
+}
+
+// operandMode 表达式的种类
+type operandMode int
+
+const (
+	value     operandMode = iota // 普通的值
+	variable                     // 可以取地址的变量
+	mapindex                     // map 的下标访问 m[k], 可以赋值但不能取地址
+	constant_                    // 常量, 值在 Value 中
+	typexpr                      // 类型表达式
+)
+
+// TypeAndValue 表达式的类型, 常量表达式还有它的值
+type TypeAndValue struct {
+	mode  operandMode
+	Type  Type
+	Value constant.Value
+}
+
+// IsType 判断表达式是否为类型
+func (tv TypeAndValue) IsType() bool {
+	return tv.mode == typexpr
+}
+
+// IsValue 判断表达式是否为值, 包括常量
+func (tv TypeAndValue) IsValue() bool {
+	return tv.mode != typexpr
+}
+
+// IsNil 判断表达式是否为 nil
+func (tv TypeAndValue) IsNil() bool {
+	return tv.Type == Typ[UntypedNil]
+}
+
+// Addressable 判断表达式是否可以取地址
+func (tv TypeAndValue) Addressable() bool {
+	return tv.mode == variable
+}
+
+// Assignable 判断表达式是否可以被赋值
+func (tv TypeAndValue) Assignable() bool {
+	return tv.mode == variable || tv.mode == mapindex
+}
+
+// SelectionKind 选择的种类
+type SelectionKind int
+
+const (
+	FieldVal  SelectionKind = iota // 结构体字段 x.f
+	MethodVal                      // 方法调用 x.M(...)
+)
+
+// Selection x.f 或 x.M 选择的字段或方法
+type Selection struct {
+	Kind     SelectionKind
+	Recv     Type    // x 的类型
+	Obj      *Object // 选择的方法, 字段为 nil
+	Index    int     // 字段在结构体中的下标, 接口方法在 itab 中的下标
+	Indirect bool    // 通过指针访问字段
+	Type     Type    // 字段的类型或方法的签名
+}
+
+// Checker 类型检查的状态
+type Checker struct {
+	file   *ast.File
+	info   *Info
+	scope  *Scope
+	errors ErrorList
+
+	fn   *funcContext   // 当前正在检查的函数
+	iota constant.Value // 当前常量声明中 iota 的值, 不在常量声明中时为 nil
+
+	typeDecls map[*Object]bool // 还没有解析的类型声明, 值为 true 表示正在解析
+}
+
+// funcContext 函数检查过程中的状态
+type funcContext struct {
+	sig          *Signature
+	namedResults bool // 返回值有名字, 可以使用不带值的 return
+}
+
+// bailout 报告错误后跳过当前语句或声明的其余部分
+type bailout struct{}
+
+// Check 检查文件的类型, 返回的错误为按位置排序的 ErrorList
+func Check(file *ast.File) (*Info, error) {
+	c := &Checker{
+		file: file,
+		info: &Info{
+			Types:      make(map[ast.Expr]TypeAndValue),
+			Defs:       make(map[*ast.Ident]*Object),
+			Uses:       make(map[*ast.Ident]*Object),
+			Funcs:      make(map[*ast.FuncDecl]*Object),
+			Implicits:  make(map[*ast.CaseClause]*Object),
+			Selections: make(map[ast.Expr]*Selection),
+		},
+		scope:     NewScope(Universe),
+		typeDecls: make(map[*Object]bool),
+	}
+	c.checkFile(file)
+	if len(c.errors) > 0 {
+		c.errors.Sort()
+		return c.info, c.errors
+	}
+	return c.info, nil
+}
+
+func (c *Checker) enterScope() {
+	c.scope = NewScope(c.scope)
+}
+
+func (c *Checker) restoreScope(scope *Scope) {
+	c.scope = scope
+}
+
+// errorf 报告带源码位置的错误, 并跳过当前语句. 涉及类型错误的表达式的错误不再报告
+func (c *Checker) errorf(pos token.Pos, format string, args ...interface{}) {
+	for _, arg := range args {
+		if typ, ok := arg.(Type); ok && isInvalid(typ) {
+			c.abort()
+		}
+	}
+	err := &Error{
+		Pos: pos.Position(c.file.FileName, c.file.Source),
+		Msg: fmt.Sprintf(format, args...),
+	}
+	// 省略值的常量和上一个常量共用表达式, 表达式中的错误只报告一次
+	for _, e := range c.errors {
+		if e.Pos == err.Pos && e.Msg == err.Msg {
+			panic(bailout{})
+		}
+	}
+	c.errors = append(c.errors, err)
+	panic(bailout{})
+}
+
+// abort 跳过当前语句, 不报告错误. 用于已经报告过错误的声明
+func (c *Checker) abort() {
+	panic(bailout{})
+}
+
+// guard 执行 f, f 中报告的错误只跳过 f 的其余部分
+func (c *Checker) guard(f func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(bailout); !ok {
+				panic(r)
+			}
+		}
+	}()
+	f()
+}
+
+// isInvalid 判断类型是否来自出错的声明
+func isInvalid(t Type) bool {
+	return t != nil && Underlying(t) == Typ[Invalid]
+}
+
+func (c *Checker) posString(pos token.Pos) string {
+	return pos.Position(c.file.FileName, c.file.Source).String()
+}
+
+func (c *Checker) posLine(pos token.Pos) int {
+	return pos.Position(c.file.FileName, c.file.Source).Line
+}
+
+// declare 把对象加入当前作用域, 并记录声明的标识符
+func (c *Checker) declare(ident *ast.Ident, obj *Object) {
+	c.info.Defs[ident] = obj
+	if ident.Name == "_" {
+		// _ 不加入作用域, 不能被引用
+		return
+	}
+	if alt := c.scope.Insert(obj); alt != nil {
+		delete(c.info.Defs, ident)
+		c.errorf(ident.NamePos, "%s redeclared in this block", ident.Name)
+	}
+}
+
+// declareInvalid 声明出错时以无效的类型声明 ident, 后面用到它时不再报错
+func (c *Checker) declareInvalid(ident *ast.Ident, kind ObjKind) {
+	if ident == nil || ident.Name == "_" || c.scope.HasName(ident.Name) {
+		return
+	}
+	obj := &Object{Name: ident.Name, Kind: kind, Type: Typ[Invalid], Node: ident}
+	c.scope.Insert(obj)
+	c.info.Defs[ident] = obj
+}
+
+// lookup 查找标识符对应的对象并记录引用
+func (c *Checker) lookup(ident *ast.Ident) *Object {
+	_, obj := c.scope.Lookup(ident.Name)
+	if obj == nil {
+		c.errorf(ident.NamePos, "undefined: %s", ident.Name)
+	}
+	c.info.Uses[ident] = obj
+	if obj.Type == Typ[Invalid] {
+		c.abort()
+	}
+	return obj
+}
+
+func (c *Checker) record(expr ast.Expr, mode operandMode, typ Type, value constant.Value) TypeAndValue {
+	tv := TypeAndValue{mode: mode, Type: typ, Value: value}
+	c.info.Types[expr] = tv
+	return tv
+}
@@ -0,0 +1,844 @@
+package types
+
+import (
+	"strings"
+	"testing"
+	"tiny-go/parser"
+)
+
+// checkSource 解析并检查源码, 返回所有错误信息, 每个错误一行
+func checkSource(t *testing.T, src string) []string {
+	t.Helper()
+	f, err := parser.ParseFile("x.tgo", src)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if _, err := Check(f); err != nil {
+		return strings.Split(err.Error(), "\n")
+	}
+	return nil
+}
+
+// TestCheckErrors 测试类型检查报告的错误, 错误按位置排序
+func TestCheckErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []string
+	}{
+		{
+			name: "goto",
+			src: `package main
+
+func main() {
+	goto L
+	x := 1
+L:
+	println(x)
+	{
+	M:
+		println(1)
+	}
+	goto M
+N:
+	break
+}
+`,
+			want: []string{
+				"x.tgo:4:2: goto L jumps over variable declaration at line 5",
+				"x.tgo:12:2: goto M jumps into block starting at x.tgo:8:2",
+				"x.tgo:13:1: label N defined and not used",
+				"x.tgo:14:2: break is not in a loop, switch, or select",
+			},
+		},
+		{
+			name: "labels",
+			src: `package main
+
+func main() {
+L:
+	for {
+		break L
+	}
+L:
+	for i := 0; i < 3; i++ {
+		continue M
+	}
+	continue
+}
+`,
+			want: []string{
+				"x.tgo:8:1: label L already defined at x.tgo:4:1",
+				"x.tgo:10:12: continue label not defined: M",
+				"x.tgo:12:2: continue is not in a loop",
+			},
+		},
+		{
+			name: "assignability",
+			src: `package main
+
+func f(a int, b int64) int {
+	return a
+}
+
+type Celsius float
+
+func main() {
+	i := 1
+	var j int64 = i
+	var u uint = i
+	var g float64 = i
+	var k int32 = 2
+	f(i, k)
+	var c Celsius = 1.5
+	var x float = c
+	println(j, u, g, x)
+}
+`,
+			want: []string{
+				"x.tgo:11:16: cannot use value of type int as int64 value in variable declaration",
+				"x.tgo:12:15: cannot use value of type int as uint value in variable declaration",
+				"x.tgo:13:18: cannot use value of type int as float64 value in variable declaration",
+				"x.tgo:15:7: cannot use value of type int32 as int64 value in argument to f",
+				"x.tgo:17:16: cannot use value of type Celsius as float value in variable declaration",
+			},
+		},
+		{
+			name: "arity",
+			src: `package main
+
+func f(a int, b int64) int {
+	return a
+}
+
+func main() {
+	f(1)
+	f(1, 2, 3)
+}
+`,
+			want: []string{
+				"x.tgo:8:5: not enough arguments in call to f",
+				"\thave (untyped int)",
+				"\twant (int, int64)",
+				"x.tgo:9:10: too many arguments in call to f",
+				"\thave (untyped int, untyped int, untyped int)",
+				"\twant (int, int64)",
+			},
+		},
+		{
+			name: "overflow",
+			src: `package main
+
+const big = 1 << 100
+
+func main() {
+	var a int = 99999999999999999999
+	var b uint8 = 256
+	var c int8 = -129
+	var d uint = -1
+	var e int = big
+	var f int64 = 9223372036854775808
+	var ch char = 'é'
+	println(a, b, c, d, e, f, ch)
+}
+`,
+			want: []string{
+				"x.tgo:6:14: cannot use 99999999999999999999 (untyped int constant) as int value in variable declaration (overflows)",
+				"x.tgo:7:16: cannot use 256 (untyped int constant) as uint8 value in variable declaration (overflows)",
+				"x.tgo:8:15: cannot use -129 (untyped int constant) as int8 value in variable declaration (overflows)",
+				"x.tgo:9:15: cannot use -1 (untyped int constant) as uint value in variable declaration (overflows)",
+				"x.tgo:10:14: cannot use big (untyped int constant 1267650600228229401496703205376) as int value in variable declaration (overflows)",
+				"x.tgo:11:16: cannot use 9223372036854775808 (untyped int constant) as int64 value in variable declaration (overflows)",
+				"x.tgo:12:16: cannot use 'é' (untyped rune constant 233) as char value in variable declaration (overflows)",
+			},
+		},
+		{
+			name: "interface constant",
+			src: `package main
+
+func main() {
+	var x any = 1 << 40
+	println(3000000000, '\377', 1.5)
+}
+`,
+			want: []string{
+				"x.tgo:4:14: cannot use 1 << 40 (untyped int constant 1099511627776) as int value in variable declaration (overflows)",
+				"x.tgo:5:10: cannot use 3000000000 (untyped int constant) as int value in argument to println (overflows)",
+			},
+		},
+		{
+			name: "constant index",
+			src: `package main
+
+func main() {
+	var a [3]int
+	a[3] = 1
+	a[-1] = 1
+	x := 1 / 0
+	println(x)
+}
+`,
+			want: []string{
+				"x.tgo:5:4: invalid argument: index 3 out of bounds [0:3]",
+				"x.tgo:6:4: invalid argument: index -1 (constant of type int) must not be negative",
+				"x.tgo:7:11: invalid operation: division by zero",
+			},
+		},
+		{
+			name: "missing return",
+			src: `package main
+
+func f(x int) int {
+	if x > 0 {
+		return 1
+	}
+}
+
+func g(x int) int {
+	for {
+		break
+	}
+}
+
+func h() int {
+L:
+	for {
+		for {
+			break L
+		}
+	}
+}
+
+func main() {
+	k := func() int {
+		println(1)
+	}
+	println(f(1), g(1), h(), k())
+}
+`,
+			want: []string{
+				"x.tgo:7:1: missing return",
+				"x.tgo:13:1: missing return",
+				"x.tgo:22:1: missing return",
+				"x.tgo:27:2: missing return",
+			},
+		},
+		{
+			name: "structs",
+			src: `package main
+
+type Point struct {
+	X, Y int
+}
+
+func main() {
+	p := Point{1, 2, 3}
+	q := Point{Z: 1}
+	r := Point{1, Y: 2}
+	var s Point
+	println(s.Z)
+}
+`,
+			want: []string{
+				"x.tgo:8:19: too many values in struct literal of type Point",
+				"x.tgo:9:13: unknown field Z in struct literal of type Point",
+				"x.tgo:10:16: mixture of field:value and value elements in struct literal",
+				"x.tgo:12:12: s.Z undefined (type Point has no field or method Z)",
+			},
+		},
+		{
+			name: "pointers",
+			src: `package main
+
+func main() {
+	i := 1
+	x := *i
+	p := &i
+	var s string = p
+	println(&1, new(int) == nil)
+}
+`,
+			want: []string{
+				"x.tgo:5:7: invalid operation: cannot indirect i (value of type int)",
+				"x.tgo:7:17: cannot use value of type *int as string value in variable declaration",
+				"x.tgo:8:10: invalid operation: cannot take address of 1",
+			},
+		},
+		{
+			name: "tuples",
+			src: `package main
+
+func pair() (int, int) {
+	return 1, 2
+}
+
+func one() int {
+	return 1, 2
+}
+
+func main() {
+	a, b, c := pair()
+	d := pair()
+	e, f := 1
+	g := one() + pair()
+	println(a, b, c, d, e, f, g)
+}
+`,
+			want: []string{
+				"x.tgo:8:2: too many return values",
+				"\thave (untyped int, untyped int)",
+				"\twant (int)",
+				"x.tgo:12:10: assignment mismatch: 3 variables but pair(...) returns 2 values",
+				"x.tgo:13:4: assignment mismatch: 1 variable but pair(...) returns 2 values",
+				"x.tgo:14:7: assignment mismatch: 2 variables but 1 value",
+				"x.tgo:15:15: multiple-value pair(...) (value of type (int, int)) in single-value context",
+			},
+		},
+		{
+			name: "mismatched operands",
+			src: `package main
+
+func main() {
+	i := 1
+	s := "a"
+	x := i + s
+	y := s + i
+	z := true + 1
+	w := 1 + true
+	println(x, y, z, w)
+}
+`,
+			want: []string{
+				"x.tgo:6:9: invalid operation: mismatched types int and string",
+				"x.tgo:7:9: invalid operation: mismatched types string and int",
+				"x.tgo:8:12: invalid operation: mismatched types untyped bool and untyped int",
+				"x.tgo:9:9: invalid operation: mismatched types untyped int and untyped bool",
+			},
+		},
+		{
+			name: "bools",
+			src: `package main
+
+func main() {
+	i := 1
+	if i {
+	}
+	for i {
+	}
+	b := !i
+	c := true + 1
+	d := i && true
+	println(b, c, d)
+}
+`,
+			want: []string{
+				"x.tgo:5:5: non-boolean condition in if statement",
+				"x.tgo:7:6: non-boolean condition in for statement",
+				"x.tgo:9:7: invalid operation: operator ! not defined on i (value of type int)",
+				"x.tgo:10:12: invalid operation: mismatched types untyped bool and untyped int",
+				"x.tgo:11:9: invalid operation: operator && not defined on i (value of type int)",
+			},
+		},
+		{
+			name: "float remainder",
+			src: `package main
+
+func main() {
+	f := 1.5
+	f %= 2
+	g := 2.5 % 2
+	println(f, g)
+}
+`,
+			want: []string{
+				"x.tgo:5:4: invalid operation: operator % not defined on float",
+				"x.tgo:6:11: invalid operation: operator % not defined on 2.5 (untyped float constant)",
+			},
+		},
+		{
+			name: "bitwise",
+			src: `package main
+
+func main() {
+	f := 1.5
+	s := "a"
+	a := f & 1
+	b := 1 << f
+	c := s << 1
+	d := f % 2
+	x := 1
+	x += "a"
+	s++
+	const big = 1 << 2000
+	println(a, b, c, d)
+}
+`,
+			want: []string{
+				"x.tgo:6:9: invalid operation: operator & not defined on float",
+				"x.tgo:7:12: invalid operation: shift count f (value of type float) must be integer",
+				"x.tgo:8:9: invalid operation: shifted operand s (value of type string) must be integer",
+				"x.tgo:9:9: invalid operation: operator % not defined on float",
+				"x.tgo:11:4: invalid operation: mismatched types int and untyped string",
+				"x.tgo:12:3: invalid operation: s++ (non-numeric type string)",
+				"x.tgo:13:19: invalid shift count 2000 (untyped int constant)",
+			},
+		},
+		{
+			name: "switch",
+			src: `package main
+
+func main() {
+	x := 1
+	switch x {
+	case 1:
+	case 1:
+	case "a":
+	}
+	switch {
+	case x:
+	default:
+	default:
+	}
+	switch x {
+	case 2:
+		fallthrough
+	}
+}
+`,
+			want: []string{
+				"x.tgo:7:7: duplicate case 1 in expression switch",
+				"\tprevious case at x.tgo:6:7",
+				"x.tgo:8:7: invalid case \"a\" in switch on x (mismatched types untyped string and int)",
+				"x.tgo:11:7: invalid case x in switch (mismatched types int and bool)",
+				"x.tgo:13:2: multiple defaults in switch (first at x.tgo:12:2)",
+				"x.tgo:17:3: cannot fallthrough final case in switch",
+			},
+		},
+		{
+			name: "constants",
+			src: `package main
+
+const (
+	a = iota
+	c int8 = 200
+	d = 1 / 0
+	e int = 2.5
+)
+
+func main() {
+	n := 3
+	const b = n
+	a = 2
+	var arr [n]int
+	println(arr, int64(a*1.5))
+}
+`,
+			want: []string{
+				"x.tgo:5:11: cannot use 200 (untyped int constant) as int8 value in constant declaration (overflows)",
+				"x.tgo:6:10: invalid operation: division by zero",
+				"x.tgo:7:10: cannot use 2.5 (untyped float constant) as int value in constant declaration (truncated)",
+				"x.tgo:12:12: n (value of type int) is not constant",
+				"x.tgo:13:2: cannot assign to a (neither addressable nor a map index expression)",
+				"x.tgo:14:11: array length must be a non-negative integer constant",
+			},
+		},
+		{
+			name: "named types",
+			src: `package main
+
+type Celsius float64
+type Fahrenheit float64
+type ID = int
+
+func main() {
+	var c Celsius = 1
+	var f Fahrenheit = 2
+	var g Fahrenheit = c
+	var x float64 = c
+	var id ID = 1
+	var i int = id
+	var u Unknown
+	y := c + f
+	println(g, x, i, u, y)
+}
+`,
+			want: []string{
+				"x.tgo:10:21: cannot use value of type Celsius as Fahrenheit value in variable declaration",
+				"x.tgo:11:18: cannot use value of type Celsius as float64 value in variable declaration",
+				"x.tgo:14:8: undefined: Unknown",
+				"x.tgo:15:9: invalid operation: mismatched types Celsius and Fahrenheit",
+			},
+		},
+		{
+			name: "methods",
+			src: `package main
+
+type P struct {
+	X int
+}
+
+func (p P) M() int {
+	return p.X
+}
+
+func (p *P) Inc() {
+	p.X++
+}
+
+type I interface {
+	M() int
+}
+
+func main() {
+	a := P{1}
+	f := a.M
+	pa := &a
+	g := pa.Inc
+	var i I = a
+	h := i.M
+	P{2}.Inc()
+	a.N()
+	println(f, g, h)
+}
+`,
+			want: []string{
+				"x.tgo:21:9: cannot use a.M as value: method values are not supported",
+				"x.tgo:23:10: cannot use pa.Inc as value: method values are not supported",
+				"x.tgo:25:9: cannot use i.M as value: method values are not supported",
+				"x.tgo:26:7: cannot call pointer method Inc on P",
+				"x.tgo:27:4: a.N undefined (type P has no field or method N)",
+			},
+		},
+		{
+			name: "interfaces",
+			src: `package main
+
+type Shape interface {
+	Area() float64
+}
+
+type Square struct {
+	Side float64
+}
+
+func (s *Square) Area() float64 {
+	return s.Side * s.Side
+}
+
+type Circle struct{}
+
+func main() {
+	var a Shape = Square{1}
+	var b Shape = Circle{}
+	x := 1
+	y := x.(int)
+	var s Shape = &Square{2}
+	z := s.(Circle)
+	switch v := s.(type) {
+	case int:
+	}
+	println(a, b, y, z)
+}
+`,
+			want: []string{
+				"x.tgo:18:16: cannot use value of type Square as Shape value in variable declaration: Square does not implement Shape (method Area has pointer receiver)",
+				"x.tgo:19:16: cannot use value of type Circle as Shape value in variable declaration: Circle does not implement Shape (missing method Area)",
+				"x.tgo:21:7: invalid operation: x (value of type int) is not an interface",
+				"x.tgo:23:10: impossible type assertion: s.(Circle)",
+				"\tCircle does not implement Shape (missing method Area)",
+				"x.tgo:25:7: impossible type switch case: int",
+				"\ts (type Shape) cannot have dynamic type int (missing method Area)",
+			},
+		},
+		{
+			name: "closures",
+			src: `package main
+
+func main() {
+	f := func(x int) int {
+		return x
+	}
+	var g func(string) int = f
+	h := f("a")
+	k := f(1, 2)
+	var n int
+	n()
+	println(g, h, k)
+}
+`,
+			want: []string{
+				"x.tgo:7:27: cannot use value of type func(int) int as func(string) int value in variable declaration",
+				"x.tgo:8:9: cannot use \"a\" (untyped string constant) as int value in argument to f",
+				"x.tgo:9:12: too many arguments in call to f",
+				"\thave (untyped int, untyped int)",
+				"\twant (int)",
+				"x.tgo:11:2: invalid operation: cannot call non-function n (variable of type int)",
+			},
+		},
+		{
+			name: "maps",
+			src: `package main
+
+type Point struct {
+	X, Y int
+}
+
+func main() {
+	dup := map[string]int{"a": 1, "a": 2}
+	var bad map[[]int]int
+	var st map[Point]int
+	m := map[string]int{}
+	m[1] = 2
+	delete(m, 1)
+	x := m["a"]
+	var s string = x
+	println(dup, bad, st, s)
+}
+`,
+			want: []string{
+				"x.tgo:8:32: duplicate key \"a\" in map literal",
+				"x.tgo:9:14: invalid map key type []int",
+				"x.tgo:10:13: unsupported map key type Point (only booleans, numbers, strings, pointers and channels)",
+				"x.tgo:12:4: cannot use 1 (untyped int constant) as string value in map index",
+				"x.tgo:13:12: cannot use 1 (untyped int constant) as string value in argument to delete",
+				"x.tgo:15:17: cannot use value of type int as string value in variable declaration",
+			},
+		},
+		{
+			name: "channels",
+			src: `package main
+
+func main() {
+	var r <-chan int
+	var s chan<- int
+	n := 1
+	r <- 1
+	_ = <-s
+	_ = <-n
+	close(r)
+	close(n)
+	ch := make(chan int)
+	var b bool = <-ch
+	select {
+	default:
+	default:
+	}
+	_, _, _ = s, b, ch
+}
+`,
+			want: []string{
+				"x.tgo:7:2: invalid operation: cannot send to receive-only channel r (variable of type <-chan int)",
+				"x.tgo:8:8: invalid operation: cannot receive from send-only channel s (variable of type chan<- int)",
+				"x.tgo:9:8: invalid operation: cannot receive from non-channel n (variable of type int)",
+				"x.tgo:10:8: invalid operation: cannot close receive-only channel r (variable of type <-chan int)",
+				"x.tgo:11:8: invalid argument: n (variable of type int) is not a channel",
+				"x.tgo:13:15: cannot use value of type int as bool value in variable declaration",
+				"x.tgo:16:2: multiple defaults in select (first at x.tgo:15:2)",
+			},
+		},
+		{
+			name: "range",
+			src: `package main
+
+func main() {
+	arr := [3]int{1, 2, 3}
+	for i := range &arr {
+		_ = i
+	}
+	var f float = 1.5
+	for i := range f {
+		_ = i
+	}
+	var s chan<- int
+	for v := range s {
+		_ = v
+	}
+	ch := make(chan int)
+	for a, b := range ch {
+		_, _ = a, b
+	}
+	for i, v := range 3 {
+		_, _ = i, v
+	}
+	var r string = ""
+	for _, c := range "abc" {
+		r = c
+	}
+	_ = r
+}
+`,
+			want: []string{
+				"x.tgo:5:17: cannot range over &arr (variable of type *[3]int)",
+				"x.tgo:9:17: cannot range over f (variable of type float)",
+				"x.tgo:13:17: invalid operation: cannot range over send-only channel s (variable of type chan<- int)",
+				"x.tgo:17:9: range over ch permits only one iteration variable",
+				"x.tgo:20:9: range over 3 permits only one iteration variable",
+				"x.tgo:25:7: cannot use value of type int32 as string value in assignment",
+			},
+		},
+		{
+			name: "panic and recover",
+			src: `package main
+
+func main() {
+	panic()
+	panic(1, 2)
+	_ = recover(1)
+	var n int = recover()
+	_ = n
+}
+`,
+			want: []string{
+				"x.tgo:4:8: not enough arguments for panic (expected 1, found 0)",
+				"x.tgo:5:11: too many arguments for panic (expected 1, found 2)",
+				"x.tgo:6:14: too many arguments for recover (expected 0, found 1)",
+				"x.tgo:7:14: cannot use value of type interface{} as int value in variable declaration",
+			},
+		},
+		{
+			name: "variadic",
+			src: `package main
+
+func sum(xs ...int) int {
+	return len(xs)
+}
+
+func bad(xs ...int, y int) {
+}
+
+func main() {
+	xs := []int{1}
+	ss := []string{"a"}
+	_ = sum(1, xs...)
+	_ = sum(ss...)
+	_ = sum("a")
+	println(xs...)
+	printf(1)
+	var n int = sum
+	_ = n
+}
+`,
+			want: []string{
+				"x.tgo:7:13: can only use ... with final parameter in list",
+				"x.tgo:13:13: too many arguments in call to sum",
+				"\thave (untyped int, []int)",
+				"\twant (...int)",
+				"x.tgo:14:10: cannot use value of type []string as []int value in argument to sum",
+				"x.tgo:15:10: cannot use \"a\" (untyped string constant) as int value in argument to sum",
+				"x.tgo:16:10: cannot use value of type []int as []interface{} value in argument to println",
+				"x.tgo:17:9: cannot use 1 (untyped int constant) as string value in argument to printf",
+				"x.tgo:18:14: cannot use value of type func(...int) int as int value in variable declaration",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := checkSource(t, tt.src)
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("errors:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+// TestCheckValid 测试没有类型错误的程序
+func TestCheckValid(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+	}{
+		{
+			name: "untyped constants",
+			src: `package main
+
+func f(x int64) int64 {
+	return x
+}
+
+func main() {
+	var u uint64 = 18446744073709551615
+	var i int64 = -9223372036854775808
+	var b byte = 'a'
+	var r rune = 'é'
+	var g float64 = 2
+	s := "abc"
+	println(u, i, b, r, g*1.5, f(1<<40), s[0] == 'a', 'a'+1)
+}
+`,
+		},
+		{
+			name: "explicit conversions",
+			src: `package main
+
+type Celsius float
+
+func main() {
+	i := 1
+	var j int64 = int64(i)
+	var g float64 = float64(i) + 0.5
+	var c Celsius = Celsius(g)
+	var x float = float(c)
+	println(j, g, x, int(s()[0]-'0'))
+}
+
+func s() string {
+	return "7"
+}
+`,
+		},
+		{
+			name: "terminating statements",
+			src: `package main
+
+func a(x int) int {
+	if x > 0 {
+		return 1
+	} else if x < 0 {
+		return -1
+	} else {
+		return 0
+	}
+}
+
+func b(x int) int {
+	for {
+		if x > 3 {
+			return x
+		}
+		x++
+	}
+}
+
+func c(x int) int {
+	switch x {
+	case 1:
+		return 1
+	default:
+		panic("bad")
+	}
+}
+
+func d() int {
+L:
+	for {
+		for {
+			break
+		}
+		goto L
+	}
+}
+
+func main() {
+	println(a(1), b(1), c(1), d())
+}
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := checkSource(t, tt.src); got != nil {
+				t.Errorf("unexpected errors:\n%s", strings.Join(got, "\n"))
+			}
+		})
+	}
+}
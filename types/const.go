@@ -0,0 +1,257 @@
+package types
+
+import (
+	"fmt"
+	"go/constant"
+	gotoken "go/token"
+	"math"
+	"tiny-go/ast"
+	"tiny-go/token"
+)
+
+// 常量表达式在检查时用 go/constant 求值, 值记录在 Info.Types 中.
+// 无类型常量的值是精确的, 在赋值和运算中转换为确定的类型时检查能否表示.
+
+// goOps tGo 运算符对应的 go/token 运算符, 用于 go/constant 的运算
+var goOps = map[token.TokenType]gotoken.Token{
+	token.ADD:     gotoken.ADD,
+	token.SUB:     gotoken.SUB,
+	token.MUL:     gotoken.MUL,
+	token.DIV:     gotoken.QUO,
+	token.MOD:     gotoken.REM,
+	token.BIT_AND: gotoken.AND,
+	token.BIT_OR:  gotoken.OR,
+	token.XOR:     gotoken.XOR,
+	token.AND_NOT: gotoken.AND_NOT,
+	token.SHL:     gotoken.SHL,
+	token.SHR:     gotoken.SHR,
+	token.AND:     gotoken.LAND,
+	token.OR:      gotoken.LOR,
+	token.NOT:     gotoken.NOT,
+	token.EQL:     gotoken.EQL,
+	token.NEQ:     gotoken.NEQ,
+	token.LSS:     gotoken.LSS,
+	token.LEQ:     gotoken.LEQ,
+	token.GTR:     gotoken.GTR,
+	token.GEQ:     gotoken.GEQ,
+}
+
+// declareConst 计算常量声明的值, 并把常量加入当前作用域
+func (c *Checker) declareConst(spec *ast.ConstSpec) {
+	defer func(old constant.Value) { c.iota = old }(c.iota)
+	c.iota = constant.MakeInt64(int64(spec.Iota))
+
+	declared := false
+	defer func() {
+		if !declared {
+			c.declareInvalid(spec.Name, ObjConst)
+		}
+	}()
+
+	// 省略的值和上一个常量共用同一个表达式, iota 不同时值也不同, 需要重新求值
+	c.forget(spec.Value)
+	typ := c.valueType(spec.Value)
+	tv := c.info.Types[spec.Value]
+	if tv.mode != constant_ {
+		c.errorf(spec.Value.Pos(), "%s (value of type %s) is not constant", exprString(spec.Value), typ)
+	}
+	value := tv.Value
+	if spec.Type != nil {
+		t := c.typExpr(spec.Type)
+		if _, ok := Underlying(t).(*Basic); !ok {
+			c.errorf(spec.Type.Pos(), "invalid constant type %s", t)
+		}
+		c.checkAssignable(spec.Value, typ, t, "constant declaration")
+		value, _ = representable(value, t)
+		typ = t
+	}
+
+	c.declare(spec.Name, &Object{
+		Name:  spec.Name.Name,
+		Kind:  ObjConst,
+		Type:  typ,
+		Value: value,
+		Node:  spec,
+	})
+	declared = true
+}
+
+// forget 删除表达式中已经记录的类型, 使表达式可以重新求值
+func (c *Checker) forget(expr ast.Expr) {
+	ast.Inspect(expr, func(node interface{}) bool {
+		if expr, ok := node.(ast.Expr); ok {
+			delete(c.info.Types, expr)
+		}
+		return true
+	})
+}
+
+// constUnary 计算常量的一元运算
+func (c *Checker) constUnary(expr *ast.UnaryExpr, x TypeAndValue) TypeAndValue {
+	var ok bool
+	var prec uint
+	switch expr.Op {
+	case token.ADD, token.SUB:
+		ok = IsNumeric(x.Type)
+	case token.XOR:
+		ok = IsInteger(x.Type)
+		if IsUnsigned(x.Type) {
+			prec = uint(Sizeof(x.Type) * 8)
+		}
+	case token.NOT:
+		ok = IsBoolean(x.Type)
+	}
+	if !ok {
+		c.errorf(expr.OpPos, "invalid operation: operator %v not defined on %s (%s)",
+			expr.Op, exprString(expr.X), constDesc(expr.X, x.Value, x.Type))
+	}
+	return c.typedConst(expr, expr.OpPos, constant.UnaryOp(goOps[expr.Op], x.Value, prec), x.Type)
+}
+
+// constBinary 计算两个常量的二元运算
+func (c *Checker) constBinary(expr *ast.BinaryExpr, xv, yv TypeAndValue) TypeAndValue {
+	x, xTyp := xv.Value, xv.Type
+	y, yTyp := yv.Value, yv.Type
+
+	switch expr.Op {
+	case token.SHL, token.SHR:
+		c.checkBinary(expr.Op, expr.OpPos, expr.X, expr.Y, xTyp, yTyp)
+		s, ok := constant.Uint64Val(constant.ToInt(y))
+		if !ok || s > 1023 {
+			c.errorf(expr.Y.Pos(), "invalid shift count %s (%s)", exprString(expr.Y), constDesc(expr.Y, y, yTyp))
+		}
+		return c.typedConst(expr, expr.OpPos, constant.Shift(constant.ToInt(x), goOps[expr.Op], uint(s)), xTyp)
+	case token.AND, token.OR:
+		c.checkBool(expr.X, expr.OpPos, expr.Op)
+		c.checkBool(expr.Y, expr.OpPos, expr.Op)
+		typ := Type(Typ[UntypedBool])
+		if !IsUntyped(xTyp) || !IsUntyped(yTyp) {
+			typ = Typ[Bool]
+		}
+		return c.record(expr, constant_, typ, constant.BinaryOp(x, goOps[expr.Op], y))
+	}
+
+	// 运算的类型: 有类型的操作数的类型, 都是无类型数值常量时取范围更大的类型 (int < rune < float)
+	typ, other := xTyp, yTyp
+	if IsUntyped(xTyp) && (!IsUntyped(yTyp) || IsNumeric(xTyp) && IsNumeric(yTyp) && yTyp.(*Basic).Kind > xTyp.(*Basic).Kind) {
+		typ, other = yTyp, xTyp
+	}
+	if expr.Op == token.MOD && IsNumeric(typ) && !IsInteger(typ) {
+		c.errorf(expr.OpPos, "invalid operation: operator %v not defined on %s (%s)", expr.Op, exprString(expr.X), constDesc(expr.X, x, xTyp))
+	}
+	c.checkBinary(expr.Op, expr.OpPos, expr.X, expr.Y, typ, other)
+	if !IsUntyped(typ) {
+		x, y = c.constOperand(expr.X, x, xTyp, typ), c.constOperand(expr.Y, y, yTyp, typ)
+	}
+
+	if isComparison(expr.Op) {
+		return c.record(expr, constant_, Typ[UntypedBool], constant.MakeBool(constant.Compare(x, goOps[expr.Op], y)))
+	}
+	op := goOps[expr.Op]
+	switch expr.Op {
+	case token.DIV, token.MOD:
+		if constant.Sign(y) == 0 {
+			c.errorf(expr.Y.Pos(), "invalid operation: division by zero")
+		}
+		if expr.Op == token.DIV && IsInteger(typ) {
+			op = gotoken.QUO_ASSIGN // 整数除法
+		}
+	}
+	return c.typedConst(expr, expr.OpPos, constant.BinaryOp(x, op, y), typ)
+}
+
+// typedConst 检查有类型常量运算的结果能否用该类型表示, 并记录 expr 的值
+func (c *Checker) typedConst(expr ast.Expr, pos token.Pos, value constant.Value, typ Type) TypeAndValue {
+	if !IsUntyped(typ) {
+		v, reason := representable(value, typ)
+		if reason != "" {
+			c.errorf(pos, "constant %s %s %s", value, reason, typ)
+		}
+		value = v
+	}
+	return c.record(expr, constant_, typ, value)
+}
+
+// constOperand 把运算中的常量操作数转换为运算的类型 typ
+func (c *Checker) constOperand(expr ast.Expr, value constant.Value, from, typ Type) constant.Value {
+	v, reason := representable(value, typ)
+	switch reason {
+	case "overflows":
+		c.errorf(expr.Pos(), "%s (%s) overflows %s", exprString(expr), constDesc(expr, value, from), typ)
+	case "truncated":
+		c.errorf(expr.Pos(), "%s (%s) truncated to %s", exprString(expr), constDesc(expr, value, from), typ)
+	}
+	return v
+}
+
+// checkConstOperand 如果 expr 是常量, 检查它能否转换为运算的类型 typ
+func (c *Checker) checkConstOperand(expr ast.Expr, typ Type) {
+	if tv := c.info.Types[expr]; tv.mode == constant_ && IsNumeric(tv.Type) && IsNumeric(typ) {
+		c.constOperand(expr, tv.Value, tv.Type, typ)
+	}
+}
+
+// representable 把常量转换为 typ 类型的值, 不能表示时 reason 为 "overflows" 或 "truncated"
+func representable(value constant.Value, typ Type) (v constant.Value, reason string) {
+	switch {
+	case IsInteger(typ):
+		v = constant.ToInt(value)
+		if v.Kind() != constant.Int {
+			return value, "truncated"
+		}
+		if IsUntyped(typ) {
+			return v, ""
+		}
+		bits := uint(Sizeof(typ) * 8)
+		if IsUnsigned(typ) {
+			if u, ok := constant.Uint64Val(v); !ok || constant.Sign(v) < 0 || bits < 64 && u >= 1<<bits {
+				return value, "overflows"
+			}
+		} else {
+			if i, ok := constant.Int64Val(v); !ok || bits < 64 && (i < -1<<(bits-1) || i >= 1<<(bits-1)) {
+				return value, "overflows"
+			}
+		}
+		return v, ""
+	case IsFloat(typ):
+		v = constant.ToFloat(value)
+		if v.Kind() != constant.Float && v.Kind() != constant.Int {
+			return value, "truncated"
+		}
+		f, _ := constant.Float64Val(v)
+		switch {
+		case IsBasic(typ, Float):
+			if math.IsInf(float64(float32(f)), 0) {
+				return value, "overflows"
+			}
+			return constant.MakeFloat64(float64(float32(f))), ""
+		case IsBasic(typ, Float64):
+			if math.IsInf(f, 0) {
+				return value, "overflows"
+			}
+			return constant.MakeFloat64(f), ""
+		}
+		return v, ""
+	}
+	return value, ""
+}
+
+// constDesc 常量在错误信息中的描述, 如 "untyped int constant" 或 "constant 300 of type int16"
+func constDesc(expr ast.Expr, value constant.Value, typ Type) string {
+	if !IsUntyped(typ) {
+		return fmt.Sprintf("constant %s of type %s", value, typ)
+	}
+	if exprString(expr) == value.String() {
+		return fmt.Sprintf("%s constant", typ)
+	}
+	return fmt.Sprintf("%s constant %s", typ, value)
+}
+
+// isComparison 判断运算符是否为比较运算, 比较的结果为 bool 类型
+func isComparison(op token.TokenType) bool {
+	switch op {
+	case token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,261 @@
+package types
+
+import (
+	"tiny-go/ast"
+)
+
+// checkFile 检查文件中的所有声明. 类型, 常量, 函数和方法的签名, 全局变量依次声明,
+// 最后检查函数体, 函数体中可以引用文件中任何位置声明的名字
+func (c *Checker) checkFile(file *ast.File) {
+	// import
+	for _, x := range file.Imports {
+		name := x.Path
+		if x.Name != nil {
+			name = x.Name.Name
+		}
+		obj := &Object{
+			Name: name,
+			Kind: ObjPkg,
+			Node: x,
+		}
+		c.scope.Insert(obj)
+		if x.Name != nil {
+			c.info.Defs[x.Name] = obj
+		}
+	}
+
+	// types, 常量的类型可以是后面声明的类型, 类型中的数组长度也可以使用常量
+	c.declareTypes(file)
+
+	// consts
+	for _, spec := range file.Consts {
+		spec := spec
+		c.guard(func() { c.declareConst(spec) })
+	}
+	c.resolveTypes(file)
+
+	// funcs, 全局变量的初始值可以调用函数和方法
+	for _, fn := range file.Funcs {
+		fn := fn
+		c.guard(func() {
+			if fn.Recv != nil {
+				c.declareMethod(fn)
+				return
+			}
+			c.declareFunc(fn)
+		})
+	}
+
+	// global vars
+	for _, g := range file.Globals {
+		g := g
+		c.guard(func() { c.declareGlobal(g) })
+	}
+	for _, g := range file.Globals {
+		obj := c.info.Defs[g.Name]
+		if g.Value == nil || obj == nil || isInvalid(obj.Type) {
+			continue
+		}
+		g := g
+		c.guard(func() {
+			c.checkAssignable(g.Value, c.valueType(g.Value), obj.Type, "variable declaration")
+		})
+	}
+
+	// func bodies
+	for _, fn := range file.Funcs {
+		obj := c.info.Funcs[fn]
+		if obj == nil || isInvalid(obj.Type) || fn.Body == nil {
+			continue
+		}
+		sig := obj.Type.(*Signature)
+
+		// 方法的接收者作为第一个参数
+		params, paramTypes := fn.Type.Params.List, sig.Params
+		if fn.Recv != nil {
+			params = append(fn.Recv.List[:1:1], params...)
+			paramTypes = append([]Type{sig.Recv}, paramTypes...)
+		}
+		fn := fn
+		c.guard(func() { c.funcBody(fn.Type, fn.Body, sig, params, paramTypes) })
+	}
+}
+
+// declareTypes 注册文件中声明的类型名字, 类型的定义在用到时或 resolveTypes 中解析
+func (c *Checker) declareTypes(file *ast.File) {
+	for _, spec := range file.Types {
+		obj := &Object{
+			Name: spec.Name.Name,
+			Kind: ObjType,
+			Node: spec,
+		}
+		if !spec.Assign.IsValid() {
+			obj.Type = &Named{Pkg: file.Pkg.Name, Name: spec.Name.Name}
+		}
+		c.guard(func() {
+			c.declare(spec.Name, obj)
+			c.typeDecls[obj] = false
+		})
+	}
+}
+
+// resolveTypes 解析文件中声明的类型, 并检查无限大小的递归类型
+func (c *Checker) resolveTypes(file *ast.File) {
+	for _, spec := range file.Types {
+		if obj := c.info.Defs[spec.Name]; obj != nil {
+			c.guard(func() { c.resolveTypeDecl(obj) })
+		}
+	}
+
+	for _, spec := range file.Types {
+		obj := c.info.Defs[spec.Name]
+		if obj == nil || spec.Assign.IsValid() || isInvalid(obj.Type) {
+			continue
+		}
+		named := obj.Type.(*Named)
+		if containsType(named.Underlying, named) {
+			c.guard(func() {
+				// 递归类型的其他类型不再重复报错
+				named.Underlying = Typ[Invalid]
+				c.errorf(spec.Name.NamePos, "invalid recursive type %s", named.Name)
+			})
+		}
+	}
+}
+
+// resolveTypeDecl 解析类型声明 obj, 已经解析过时直接返回.
+// 类型声明可以引用后面声明的类型, 所以在第一次用到时才解析. 出错的类型为无效的类型
+func (c *Checker) resolveTypeDecl(obj *Object) {
+	resolving, ok := c.typeDecls[obj]
+	if !ok {
+		return
+	}
+	spec := obj.Node.(*ast.TypeSpec)
+	if resolving {
+		c.errorf(spec.Name.NamePos, "invalid recursive type %s", spec.Name.Name)
+	}
+	c.typeDecls[obj] = true
+	defer func() {
+		delete(c.typeDecls, obj)
+		if r := recover(); r != nil {
+			if spec.Assign.IsValid() {
+				obj.Type = Typ[Invalid]
+			} else {
+				obj.Type.(*Named).Underlying = Typ[Invalid]
+			}
+			panic(r)
+		}
+	}()
+
+	if spec.Assign.IsValid() {
+		obj.Type = c.typExpr(spec.Type)
+	} else {
+		obj.Type.(*Named).Underlying = c.resolveUnderlying(spec.Type)
+	}
+}
+
+// resolveUnderlying 解析类型声明右边的类型, 返回它的底层类型.
+// 右边是命名类型时需要先解析它的定义
+func (c *Checker) resolveUnderlying(expr ast.Expr) Type {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		if _, obj := c.scope.Lookup(expr.Name); obj != nil {
+			c.resolveTypeDecl(obj)
+		}
+	case *ast.ParenExpr:
+		return c.resolveUnderlying(expr.X)
+	}
+	return Underlying(c.typExpr(expr))
+}
+
+// containsType 判断 typ 是否直接包含 named 类型的值, 用于检查无限大小的递归类型
+func containsType(typ Type, named *Named) bool {
+	switch typ := typ.(type) {
+	case *Named:
+		return typ == named || containsType(typ.Underlying, named)
+	case *Array:
+		return containsType(typ.Elem, named)
+	case *Struct:
+		for _, f := range typ.Fields {
+			if containsType(f.Type, named) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// declareFunc 声明函数. 签名出错的函数以无效的类型声明, 调用它时不再报错
+func (c *Checker) declareFunc(fn *ast.FuncDecl) {
+	obj := &Object{
+		Name: fn.Name,
+		Kind: ObjFunc,
+		Type: Typ[Invalid],
+		Node: fn,
+	}
+	if alt := c.scope.Insert(obj); alt != nil {
+		c.errorf(fn.NamePos, "%s redeclared in this block", fn.Name)
+	}
+	c.info.Funcs[fn] = obj
+	obj.Type = c.funcType(fn.Type)
+}
+
+// declareMethod 把方法添加到接收者的基础类型中
+func (c *Checker) declareMethod(fn *ast.FuncDecl) {
+	switch len(fn.Recv.List) {
+	case 0:
+		c.errorf(fn.Recv.Closing, "method has no receiver")
+	case 1:
+	default:
+		c.errorf(fn.Recv.List[1].Type.Pos(), "method has multiple receivers")
+	}
+	recv := fn.Recv.List[0]
+	recvType := c.typExpr(recv.Type)
+	named := recvBase(recvType)
+	if named == nil {
+		c.errorf(recv.Type.Pos(), "cannot define new methods on non-local type %s", recvType)
+	}
+	if IsPointer(named) || IsInterface(named) {
+		c.errorf(recv.Type.Pos(), "invalid receiver type %s (pointer or interface type)", recvType)
+	}
+	if prev := named.Method(fn.Name); prev != nil {
+		c.errorf(fn.NamePos, "method %s.%s already declared at %s",
+			named.Name, fn.Name, c.posString(prev.Node.(*ast.FuncDecl).NamePos))
+	}
+	if s, ok := named.Underlying.(*Struct); ok && s.FieldIndex(fn.Name) >= 0 {
+		c.errorf(fn.NamePos, "field and method with the same name %s", fn.Name)
+	}
+
+	sig := c.funcType(fn.Type)
+	sig.Recv = recvType
+	m := &Object{
+		Name: fn.Name,
+		Kind: ObjFunc,
+		Type: sig,
+		Node: fn,
+	}
+	named.Methods = append(named.Methods, m)
+	c.info.Funcs[fn] = m
+}
+
+// declareGlobal 声明全局变量, 初始值在所有全局变量声明后检查
+func (c *Checker) declareGlobal(spec *ast.VarSpec) {
+	declared := false
+	defer func() {
+		if !declared {
+			c.declareInvalid(spec.Name, ObjVar)
+		}
+	}()
+
+	typ := c.varType(spec)
+	if typ == Typ[UntypedNil] {
+		c.errorf(spec.Value.Pos(), "use of untyped nil in variable declaration")
+	}
+	c.declare(spec.Name, &Object{
+		Name: spec.Name.Name,
+		Kind: ObjVar,
+		Type: typ,
+		Node: spec,
+	})
+	declared = true
+}
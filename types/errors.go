@@ -0,0 +1,35 @@
+package types
+
+import (
+	"sort"
+	"strings"
+	"tiny-go/token"
+)
+
+// Error 一个类型错误
+type Error struct {
+	Pos token.Position
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return e.Pos.String() + ": " + e.Msg
+}
+
+// ErrorList 类型检查发现的所有错误, 每行一个
+type ErrorList []*Error
+
+// Sort 按错误在源码中的位置排序
+func (list ErrorList) Sort() {
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].Pos.Offset < list[j].Pos.Offset
+	})
+}
+
+func (list ErrorList) Error() string {
+	var lines []string
+	for _, e := range list {
+		lines = append(lines, e.Error())
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,562 @@
+package types
+
+import (
+	"fmt"
+	"go/constant"
+	gotoken "go/token"
+	"tiny-go/ast"
+	"tiny-go/token"
+)
+
+// operand 检查表达式并返回它的类型和值, 结果记录在 Info.Types 中, 同一个表达式只检查一次.
+// 多返回值的函数调用的类型为 *Tuple
+func (c *Checker) operand(expr ast.Expr) TypeAndValue {
+	if tv, ok := c.info.Types[expr]; ok {
+		if tv.IsType() {
+			c.errorf(expr.Pos(), "%s (type) is not an expression", tv.Type)
+		}
+		return tv
+	}
+	return c.exprInternal(expr)
+}
+
+// expr 获取表达式的类型, 可以是多返回值的 *Tuple
+func (c *Checker) expr(expr ast.Expr) Type {
+	return c.operand(expr).Type
+}
+
+// valueType 获取单个值的类型, 多返回值的函数调用不能作为单个值使用
+func (c *Checker) valueType(expr ast.Expr) Type {
+	typ := c.expr(expr)
+	if _, ok := typ.(*Tuple); ok {
+		c.errorf(expr.Pos(), "multiple-value %s (value of type %s) in single-value context", exprString(expr), typ)
+	}
+	return typ
+}
+
+// constant 检查表达式, 表达式为常量时返回它的值和类型
+func (c *Checker) constant(expr ast.Expr) (value constant.Value, typ Type, ok bool) {
+	tv := c.operand(expr)
+	return tv.Value, tv.Type, tv.mode == constant_
+}
+
+func (c *Checker) exprInternal(expr ast.Expr) TypeAndValue {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		return c.ident(expr)
+	case *ast.Int:
+		v := constant.MakeFromLiteral(expr.Value, gotoken.INT, 0)
+		if v.Kind() == constant.Unknown {
+			c.errorf(expr.ValuePos, "invalid integer literal %s", expr.Value)
+		}
+		return c.record(expr, constant_, Typ[UntypedInt], v)
+	case *ast.Float:
+		v := constant.MakeFromLiteral(expr.Value, gotoken.FLOAT, 0)
+		if v.Kind() == constant.Unknown {
+			c.errorf(expr.ValuePos, "invalid floating-point literal %s", expr.Value)
+		}
+		return c.record(expr, constant_, Typ[UntypedFloat], v)
+	case *ast.Char:
+		return c.record(expr, constant_, Typ[UntypedRune], constant.MakeInt64(int64(expr.Value)))
+	case *ast.StringLit:
+		return c.record(expr, constant_, Typ[UntypedString], constant.MakeString(expr.Value))
+	case *ast.BinaryExpr:
+		return c.binary(expr)
+	case *ast.UnaryExpr:
+		return c.unary(expr)
+	case *ast.ParenExpr:
+		tv := c.operand(expr.X)
+		return c.record(expr, tv.mode, tv.Type, tv.Value)
+	case *ast.StarExpr:
+		return c.record(expr, variable, c.derefType(expr), nil)
+	case *ast.IndexExpr:
+		return c.indexExpr(expr)
+	case *ast.SliceExpr:
+		return c.sliceExpr(expr)
+	case *ast.SelectorExpr:
+		return c.selector(expr)
+	case *ast.CompositeLit:
+		return c.compositeLit(expr)
+	case *ast.FuncLit:
+		sig := c.funcType(expr.Type)
+		c.funcBody(expr.Type, expr.Body, sig, expr.Type.Params.List, sig.Params)
+		return c.record(expr, value, sig, nil)
+	case *ast.TypeAssertExpr:
+		return c.record(expr, value, c.checkTypeAssert(expr), nil)
+	case *ast.CallExpr:
+		return c.call(expr)
+	}
+	if isTypeExpr(expr) {
+		c.errorf(expr.Pos(), "%s (type) is not an expression", c.typExpr(expr))
+	}
+	panic(fmt.Sprintf("unknown: %[1]T, %[1]v", expr))
+}
+
+// ident 检查作为值使用的标识符
+func (c *Checker) ident(expr *ast.Ident) TypeAndValue {
+	if expr.Name == "_" {
+		c.errorf(expr.NamePos, "cannot use _ as value")
+	}
+	obj := c.lookup(expr)
+	switch obj.Kind {
+	case ObjVar:
+		return c.record(expr, variable, obj.Type, nil)
+	case ObjConst:
+		if obj == Universe.Objects["iota"] {
+			if c.iota == nil {
+				c.errorf(expr.NamePos, "cannot use iota outside constant declaration")
+			}
+			return c.record(expr, constant_, obj.Type, c.iota)
+		}
+		return c.record(expr, constant_, obj.Type, obj.Value)
+	case ObjNil:
+		return c.record(expr, value, obj.Type, nil)
+	case ObjFunc:
+		if isPrintFunc(obj) {
+			c.errorf(expr.NamePos, "%s (built-in function) must be called", expr.Name)
+		}
+		return c.record(expr, value, obj.Type, nil)
+	case ObjBuiltin:
+		c.errorf(expr.NamePos, "%s (built-in function) must be called", expr.Name)
+	case ObjPkg:
+		c.errorf(expr.NamePos, "use of package %s without selector", expr.Name)
+	}
+	c.errorf(expr.NamePos, "%s is not a variable", expr.Name)
+	panic("unreachable")
+}
+
+// isPrintFunc 判断是否为 print, println 或 printf, 它们的参数在编译时展开, 不能作为函数值使用
+func isPrintFunc(obj *Object) bool {
+	switch obj.Name {
+	case "print", "println", "printf":
+		return obj == Universe.Objects[obj.Name]
+	}
+	return false
+}
+
+// unary 检查一元运算
+func (c *Checker) unary(expr *ast.UnaryExpr) TypeAndValue {
+	switch expr.Op {
+	case token.BIT_AND:
+		// &T{...} 在堆上分配新的值
+		if _, ok := expr.X.(*ast.CompositeLit); !ok && !c.operand(expr.X).Addressable() {
+			c.errorf(expr.OpPos, "invalid operation: cannot take address of %s", exprString(expr.X))
+		}
+		return c.record(expr, value, &Pointer{Elem: c.valueType(expr.X)}, nil)
+	case token.ARROW:
+		return c.record(expr, value, c.chanOperand(expr.X, "receive from", SendOnly).Elem, nil)
+	}
+
+	x := c.operand(expr.X)
+	if x.mode == constant_ {
+		return c.constUnary(expr, x)
+	}
+	if expr.Op == token.NOT {
+		c.checkBool(expr.X, expr.OpPos, expr.Op)
+		return c.record(expr, value, Typ[Bool], nil)
+	}
+	typ := c.valueType(expr.X)
+	if expr.Op == token.XOR && !IsInteger(typ) || !IsNumeric(typ) {
+		c.errorf(expr.OpPos, "invalid operation: operator %v not defined on %s", expr.Op, typ)
+	}
+	return c.record(expr, value, typ, nil)
+}
+
+// derefType 获取 *x 的类型
+func (c *Checker) derefType(expr *ast.StarExpr) Type {
+	typ := c.valueType(expr.X)
+	ptr, ok := Underlying(typ).(*Pointer)
+	if !ok {
+		c.errorf(expr.Star, "invalid operation: cannot indirect %s (value of type %s)", exprString(expr.X), typ)
+	}
+	return ptr.Elem
+}
+
+// binary 检查二元运算, 两个操作数都是常量时在检查时求值
+func (c *Checker) binary(expr *ast.BinaryExpr) TypeAndValue {
+	x, y := c.operand(expr.X), c.operand(expr.Y)
+	if x.mode == constant_ && y.mode == constant_ {
+		return c.constBinary(expr, x, y)
+	}
+	if expr.Op == token.AND || expr.Op == token.OR {
+		c.checkBool(expr.X, expr.OpPos, expr.Op)
+		c.checkBool(expr.Y, expr.OpPos, expr.Op)
+		return c.record(expr, value, Typ[Bool], nil)
+	}
+	typ := c.comparison(expr.Op, expr.OpPos, expr.X, expr.Y, c.valueType(expr.X), c.valueType(expr.Y))
+	if isComparison(expr.Op) {
+		typ = Typ[Bool]
+	}
+	return c.record(expr, value, typ, nil)
+}
+
+// comparison 检查非常量的二元运算 x op y, 返回运算的类型.
+// 也用于 switch 语句中 tag 和每个 case 的比较
+func (c *Checker) comparison(op token.TokenType, pos token.Pos, x, y ast.Expr, xTyp, yTyp Type) Type {
+	if xTyp == Typ[UntypedNil] || yTyp == Typ[UntypedNil] {
+		typ := xTyp
+		if xTyp == Typ[UntypedNil] {
+			typ = yTyp
+		}
+		if (op != token.EQL && op != token.NEQ) || !IsNillable(typ) {
+			c.errorf(pos, "invalid operation: operator %v not defined on nil", op)
+		}
+		return typ
+	}
+	if IsInterface(xTyp) || IsInterface(yTyp) {
+		typ := xTyp
+		if !IsInterface(xTyp) {
+			typ = yTyp
+		}
+		if op != token.EQL && op != token.NEQ {
+			c.errorf(pos, "invalid operation: operator %v not defined on %s", op, typ)
+		}
+		if !AssignableTo(xTyp, typ) || !AssignableTo(yTyp, typ) {
+			c.errorf(pos, "invalid operation: mismatched types %s and %s", xTyp, yTyp)
+		}
+		for _, other := range []Type{Default(xTyp), Default(yTyp)} {
+			if !IsInterface(other) && !Comparable(other) {
+				c.errorf(pos, "invalid operation: operator %v not defined on %s", op, other)
+			}
+		}
+		return typ
+	}
+	switch Underlying(xTyp).(type) {
+	case *Array, *Struct:
+		if (op == token.EQL || op == token.NEQ) && Identical(xTyp, yTyp) && Comparable(xTyp) {
+			return xTyp
+		}
+		c.errorf(pos, "invalid operation: operator %v not defined on %s", op, xTyp)
+	}
+
+	// 无类型常量和有类型的操作数运算时转换为对方的类型
+	typ := xTyp
+	if op != token.SHL && op != token.SHR {
+		if IsUntyped(xTyp) && !IsUntyped(yTyp) {
+			c.checkConstOperand(x, yTyp)
+			typ = yTyp
+		} else if IsUntyped(yTyp) && !IsUntyped(xTyp) {
+			c.checkConstOperand(y, xTyp)
+		}
+	}
+	c.checkBinary(op, pos, x, y, typ, yTyp)
+	return typ
+}
+
+// checkBinary 检查二元运算的操作数类型, typ 为运算的类型
+func (c *Checker) checkBinary(op token.TokenType, pos token.Pos, x, y ast.Expr, typ, yTyp Type) {
+	switch op {
+	case token.SHL, token.SHR:
+		if !IsInteger(typ) {
+			c.errorf(pos, "invalid operation: shifted operand %s (value of type %s) must be integer", exprString(x), typ)
+		}
+		if !IsInteger(yTyp) {
+			c.errorf(y.Pos(), "invalid operation: shift count %s (value of type %s) must be integer", exprString(y), yTyp)
+		}
+		return
+	}
+
+	if !AssignableTo(yTyp, typ) {
+		c.errorf(pos, "invalid operation: mismatched types %s and %s", typ, yTyp)
+	}
+	var ok bool
+	switch op {
+	case token.EQL, token.NEQ:
+		ok = Comparable(typ) && (!IsPointer(typ) || Identical(typ, yTyp))
+	case token.LSS, token.LEQ, token.GTR, token.GEQ:
+		ok = IsNumeric(typ) || IsString(typ)
+	case token.ADD:
+		ok = IsNumeric(typ) || IsString(typ)
+	case token.SUB, token.MUL, token.DIV:
+		ok = IsNumeric(typ)
+	case token.MOD, token.BIT_AND, token.BIT_OR, token.XOR, token.AND_NOT:
+		ok = IsInteger(typ)
+	}
+	if !ok {
+		c.errorf(pos, "invalid operation: operator %v not defined on %s", op, typ)
+	}
+}
+
+// checkCond 检查 if/for 的条件是否为 bool 类型
+func (c *Checker) checkCond(cond ast.Expr, stmt string) {
+	if typ := c.valueType(cond); !IsBoolean(typ) {
+		c.errorf(cond.Pos(), "non-boolean condition in %s statement", stmt)
+	}
+}
+
+// checkBool 检查逻辑运算的操作数是否为 bool 类型
+func (c *Checker) checkBool(expr ast.Expr, pos token.Pos, op token.TokenType) {
+	if typ := c.valueType(expr); !IsBoolean(typ) {
+		c.errorf(pos, "invalid operation: operator %v not defined on %s (value of type %s)", op, exprString(expr), typ)
+	}
+}
+
+// indexExpr 检查下标访问 x[i]
+func (c *Checker) indexExpr(expr *ast.IndexExpr) TypeAndValue {
+	x := c.operand(expr.X)
+	switch typ := Underlying(c.valueType(expr.X)).(type) {
+	case *Array:
+		c.index(expr.Index)
+		if n, ok := c.constInt(expr.Index); ok && n >= int64(typ.Len) {
+			c.errorf(expr.Index.Pos(), "invalid argument: index %d out of bounds [0:%d]", n, typ.Len)
+		}
+		// 不可取地址的数组 (如函数返回值) 的元素也不能取地址
+		mode := value
+		if x.Addressable() {
+			mode = variable
+		}
+		return c.record(expr, mode, typ.Elem, nil)
+	case *Slice:
+		c.index(expr.Index)
+		return c.record(expr, variable, typ.Elem, nil)
+	case *Map:
+		c.mapKey(expr.Index, typ, "map index")
+		return c.record(expr, mapindex, typ.Elem, nil)
+	default:
+		if !IsString(typ) {
+			c.errorf(expr.Lbrack, "invalid operation: cannot index %s", typ)
+		}
+		c.index(expr.Index)
+		return c.record(expr, value, Typ[Uint8], nil)
+	}
+}
+
+// index 检查下标, 下标必须是整数, 常量不能为负数
+func (c *Checker) index(index ast.Expr) {
+	typ := c.valueType(index)
+	if !IsInteger(typ) {
+		c.errorf(index.Pos(), "invalid argument: index of type %s must be integer", typ)
+	}
+	if n, ok := c.constInt(index); ok && n < 0 {
+		c.errorf(index.Pos(), "invalid argument: index %d (constant of type int) must not be negative", n)
+	}
+}
+
+// constInt 获取整数常量表达式的值, 不是常量或超出 int64 的范围时 ok 为 false
+func (c *Checker) constInt(expr ast.Expr) (n int64, ok bool) {
+	tv := c.info.Types[expr]
+	if tv.mode != constant_ {
+		return 0, false
+	}
+	return constant.Int64Val(constant.ToInt(tv.Value))
+}
+
+// mapKey 检查 map 的键能否赋值给键的类型, context 说明键出现的位置
+func (c *Checker) mapKey(index ast.Expr, typ *Map, context string) {
+	c.checkAssignable(index, c.valueType(index), typ.Key, context)
+}
+
+// isMapIndex 判断表达式是否为 map 的下标访问 m[k]
+func (c *Checker) isMapIndex(expr ast.Expr) bool {
+	switch expr := expr.(type) {
+	case *ast.IndexExpr:
+		_, ok := Underlying(c.expr(expr.X)).(*Map)
+		return ok
+	case *ast.ParenExpr:
+		return c.isMapIndex(expr.X)
+	}
+	return false
+}
+
+// sliceExpr 检查 x[low:high], 数组必须可以取地址
+func (c *Checker) sliceExpr(expr *ast.SliceExpr) TypeAndValue {
+	x := c.operand(expr.X)
+	var typ Type
+	switch u := Underlying(c.valueType(expr.X)).(type) {
+	case *Array:
+		if !x.Addressable() {
+			c.errorf(expr.Lbrack, "invalid operation: slice of unaddressable value")
+		}
+		typ = &Slice{Elem: u.Elem}
+	case *Slice:
+		typ = x.Type
+	default:
+		if !IsString(u) {
+			c.errorf(expr.Lbrack, "cannot slice %s", u)
+		}
+		typ = u
+	}
+	for _, index := range []ast.Expr{expr.Low, expr.High} {
+		if index != nil {
+			c.index(index)
+		}
+	}
+	return c.record(expr, value, typ, nil)
+}
+
+// selector 检查字段访问 x.sel, 通过指针访问字段时自动解引用
+func (c *Checker) selector(expr *ast.SelectorExpr) TypeAndValue {
+	x := c.operand(expr.X)
+	typ := c.valueType(expr.X)
+	ptr, indirect := Underlying(typ).(*Pointer)
+	if indirect {
+		typ = ptr.Elem
+	}
+	s, ok := Underlying(typ).(*Struct)
+	index := -1
+	if ok {
+		index = s.FieldIndex(expr.Sel.Name)
+	}
+	if index < 0 {
+		// 方法只能直接调用, 不支持 x.M 形式的方法值
+		if hasMethod(typ, expr.Sel.Name) {
+			c.errorf(expr.Sel.NamePos, "cannot use %s as value: method values are not supported", exprString(expr))
+		}
+		c.errorf(expr.Sel.NamePos, "%s undefined (type %s has no field or method %s)", exprString(expr), typ, expr.Sel.Name)
+	}
+
+	field := s.Fields[index]
+	c.info.Selections[expr] = &Selection{
+		Kind:     FieldVal,
+		Recv:     x.Type,
+		Index:    index,
+		Indirect: indirect,
+		Type:     field.Type,
+	}
+	mode := value
+	if indirect || x.Addressable() {
+		mode = variable
+	}
+	return c.record(expr, mode, field.Type, nil)
+}
+
+// compositeLit 检查结构体, 数组, 切片和 map 的复合字面值
+func (c *Checker) compositeLit(lit *ast.CompositeLit) TypeAndValue {
+	typ := c.typExpr(lit.Type)
+	switch u := Underlying(typ).(type) {
+	case *Struct:
+		for i, value := range c.structLitValues(lit, typ, u) {
+			if value != nil {
+				c.checkAssignable(value, c.valueType(value), u.Fields[i].Type, "struct literal")
+			}
+		}
+	case *Array:
+		if len(lit.Elts) > u.Len {
+			c.errorf(lit.Elts[u.Len].Pos(), "array index %d out of bounds [0:%d]", u.Len, u.Len)
+		}
+		for _, elt := range c.positionalElts(lit) {
+			c.checkAssignable(elt, c.valueType(elt), u.Elem, "array or slice literal")
+		}
+	case *Slice:
+		for _, elt := range c.positionalElts(lit) {
+			c.checkAssignable(elt, c.valueType(elt), u.Elem, "array or slice literal")
+		}
+	case *Map:
+		c.mapLit(lit, u)
+	default:
+		c.errorf(lit.Type.Pos(), "invalid composite literal type %s", typ)
+	}
+	return c.record(lit, value, typ, nil)
+}
+
+// structLitValues 按字段顺序整理结构体字面值的元素, 没有给出的字段为 nil
+func (c *Checker) structLitValues(lit *ast.CompositeLit, typ Type, s *Struct) []ast.Expr {
+	values := make([]ast.Expr, len(s.Fields))
+	if len(lit.Elts) == 0 {
+		return values
+	}
+
+	if _, keyed := lit.Elts[0].(*ast.KeyValueExpr); !keyed {
+		for i, elt := range lit.Elts {
+			if _, ok := elt.(*ast.KeyValueExpr); ok {
+				c.errorf(elt.Pos(), "mixture of field:value and value elements in struct literal")
+			}
+			if i >= len(s.Fields) {
+				c.errorf(elt.Pos(), "too many values in struct literal of type %s", typ)
+			}
+			values[i] = elt
+		}
+		if len(lit.Elts) < len(s.Fields) {
+			c.errorf(lit.Rbrace, "too few values in struct literal of type %s", typ)
+		}
+		return values
+	}
+
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			c.errorf(elt.Pos(), "mixture of field:value and value elements in struct literal")
+		}
+		key, ok := kv.Key.(*ast.Ident)
+		if !ok {
+			c.errorf(kv.Key.Pos(), "invalid field name in struct literal")
+		}
+		i := s.FieldIndex(key.Name)
+		if i < 0 {
+			c.errorf(key.NamePos, "unknown field %s in struct literal of type %s", key.Name, typ)
+		}
+		if values[i] != nil {
+			c.errorf(key.NamePos, "duplicate field name %s in struct literal", key.Name)
+		}
+		values[i] = kv.Value
+	}
+	return values
+}
+
+// positionalElts 获取数组和切片字面值的元素, 不支持 index: value 形式
+func (c *Checker) positionalElts(lit *ast.CompositeLit) []ast.Expr {
+	for _, elt := range lit.Elts {
+		if kv, ok := elt.(*ast.KeyValueExpr); ok {
+			c.errorf(kv.Colon, "keyed elements are not supported in array or slice literal")
+		}
+	}
+	return lit.Elts
+}
+
+// mapLit 检查 map 的复合字面值 map[K]V{k: v, ...}, 常量键不能重复
+func (c *Checker) mapLit(lit *ast.CompositeLit, typ *Map) {
+	seen := make(map[string]bool)
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			c.errorf(elt.Pos(), "missing key in map literal")
+		}
+		if value, _, ok := c.constant(kv.Key); ok {
+			value, _ = representable(value, typ.Key)
+			key := value.ExactString()
+			if seen[key] {
+				c.errorf(kv.Key.Pos(), "duplicate key %s in map literal", exprString(kv.Key))
+			}
+			seen[key] = true
+		}
+		c.checkAssignable(kv.Value, c.valueType(kv.Value), typ.Elem, "map literal")
+		c.mapKey(kv.Key, typ, "map literal")
+	}
+}
+
+// checkTypeAssert 检查类型断言 x.(T), 返回断言的类型
+func (c *Checker) checkTypeAssert(expr *ast.TypeAssertExpr) Type {
+	if expr.Type == nil {
+		c.errorf(expr.Lparen, "use of .(type) outside type switch")
+	}
+	xTyp := c.valueType(expr.X)
+	iface, ok := Underlying(xTyp).(*Interface)
+	if !ok {
+		c.errorf(expr.X.Pos(), "invalid operation: %s (value of type %s) is not an interface", exprString(expr.X), xTyp)
+	}
+	typ := c.typExpr(expr.Type)
+	if !IsInterface(typ) {
+		if m, _ := MissingMethod(typ, iface); m != nil {
+			c.errorf(expr.Type.Pos(), "impossible type assertion: %s.(%s)\n\t%s does not implement %s (%s)",
+				exprString(expr.X), typ, typ, xTyp, missingMethodReason(typ, iface))
+		}
+	}
+	return typ
+}
+
+// chanOperand 检查 channel 操作的操作数, op 说明操作, 如 "receive from", 方向不允许该操作时报错
+func (c *Checker) chanOperand(expr ast.Expr, op string, forbidden ChanDir) *Chan {
+	typ := c.valueType(expr)
+	ch, ok := Underlying(typ).(*Chan)
+	if !ok {
+		c.errorf(expr.Pos(), "invalid operation: cannot %s non-channel %s (variable of type %s)", op, exprString(expr), typ)
+	}
+	if ch.Dir == forbidden {
+		dir := "send-only"
+		if forbidden == RecvOnly {
+			dir = "receive-only"
+		}
+		c.errorf(expr.Pos(), "invalid operation: cannot %s %s channel %s (variable of type %s)", op, dir, exprString(expr), typ)
+	}
+	return ch
+}
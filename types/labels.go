@@ -0,0 +1,243 @@
+package types
+
+import (
+	"sort"
+	"tiny-go/ast"
+	"tiny-go/token"
+)
+
+// blockIndex 语句在所属块中的位置
+type blockIndex struct {
+	block *ast.BlockStmt
+	index int
+}
+
+// labelInfo 函数中定义的标号
+type labelInfo struct {
+	stmt *ast.LabeledStmt
+	path []blockIndex // 从函数体到标号语句经过的块
+	used bool
+}
+
+// gotoInfo 函数中的 goto 语句
+type gotoInfo struct {
+	stmt *ast.BranchStmt
+	path []blockIndex
+}
+
+// branchInfo 函数中带标号的 break/continue 语句
+type branchInfo struct {
+	stmt    *ast.BranchStmt
+	targets []string // 外层可以作为跳转目标的循环或 switch 的标号
+}
+
+// labelChecker 检查函数中标号的定义和跳转语句的目标
+type labelChecker struct {
+	c        *Checker
+	labels   map[string]*labelInfo
+	gotos    []*gotoInfo
+	branches []*branchInfo
+	loops    []string // 当前所在的循环的标号, 没有标号的循环为空字符串
+	switches []string // 当前所在的 switch 和 select 的标号
+}
+
+// checkLabels 按 Go 的规则检查函数体中的标号和跳转语句:
+// 标号不能重复定义, 必须被使用; goto 不能跳入块内, 也不能跳过变量声明;
+// break 必须在循环或 switch 内, continue 必须在循环内, 标号必须是外层对应语句的标号.
+func (c *Checker) checkLabels(body *ast.BlockStmt) {
+	l := &labelChecker{c: c, labels: make(map[string]*labelInfo)}
+	l.walkBlock(body, nil)
+
+	for _, b := range l.branches {
+		b := b
+		c.guard(func() {
+			name := b.stmt.Label.Name
+			label, ok := l.labels[name]
+			if !ok {
+				c.errorf(b.stmt.Label.NamePos, "%s label not defined: %s", b.stmt.TokType, name)
+			}
+			label.used = true
+			if !containsLabel(b.targets, name) {
+				c.errorf(b.stmt.Label.NamePos, "invalid %s label %s", b.stmt.TokType, name)
+			}
+		})
+	}
+
+	for _, g := range l.gotos {
+		g := g
+		c.guard(func() {
+			label, ok := l.labels[g.stmt.Label.Name]
+			if !ok {
+				c.errorf(g.stmt.Label.NamePos, "label %s not defined", g.stmt.Label.Name)
+			}
+			label.used = true
+			l.checkJump(g, label)
+		})
+	}
+
+	var unused []*labelInfo
+	for _, label := range l.labels {
+		if !label.used {
+			unused = append(unused, label)
+		}
+	}
+	sort.Slice(unused, func(i, j int) bool {
+		return unused[i].stmt.Label.NamePos < unused[j].stmt.Label.NamePos
+	})
+	for _, label := range unused {
+		label := label
+		c.guard(func() {
+			c.errorf(label.stmt.Label.NamePos, "label %s defined and not used", label.stmt.Label.Name)
+		})
+	}
+}
+
+// walkBlock 依次检查块中的语句, 一条语句出错时继续检查后面的语句
+func (l *labelChecker) walkBlock(block *ast.BlockStmt, path []blockIndex) {
+	for i, stmt := range block.List {
+		stmtPath := make([]blockIndex, len(path), len(path)+1)
+		copy(stmtPath, path)
+		stmt, stmtPath := stmt, append(stmtPath, blockIndex{block: block, index: i})
+		l.c.guard(func() { l.walkStmt(stmt, stmtPath) })
+	}
+}
+
+func (l *labelChecker) walkStmt(stmt ast.Stmt, path []blockIndex) {
+	switch stmt := stmt.(type) {
+	case *ast.LabeledStmt:
+		name := stmt.Label.Name
+		if alt, ok := l.labels[name]; ok {
+			// 重复的标号不替换前面的标号, 继续检查它标记的语句
+			l.c.guard(func() {
+				l.c.errorf(stmt.Label.NamePos, "label %s already defined at %s",
+					name, l.c.posString(alt.stmt.Label.NamePos))
+			})
+		} else {
+			l.labels[name] = &labelInfo{stmt: stmt, path: path}
+		}
+		switch s := stmt.Stmt.(type) {
+		case nil:
+		case *ast.ForStmt:
+			l.walkFor(s.Body, path, name)
+		case *ast.RangeStmt:
+			l.walkFor(s.Body, path, name)
+		case *ast.SwitchStmt:
+			l.walkSwitch(s.Body, path, name)
+		case *ast.TypeSwitchStmt:
+			l.walkSwitch(s.Body, path, name)
+		case *ast.SelectStmt:
+			l.walkSwitch(s.Body, path, name)
+		default:
+			l.walkStmt(s, path)
+		}
+	case *ast.BranchStmt:
+		switch stmt.TokType {
+		case token.GOTO:
+			l.gotos = append(l.gotos, &gotoInfo{stmt: stmt, path: path})
+		case token.BREAK:
+			if len(l.loops) == 0 && len(l.switches) == 0 {
+				l.c.errorf(stmt.TokPos, "break is not in a loop, switch, or select")
+			}
+			if stmt.Label != nil {
+				targets := make([]string, 0, len(l.loops)+len(l.switches))
+				targets = append(append(targets, l.loops...), l.switches...)
+				l.branches = append(l.branches, &branchInfo{stmt: stmt, targets: targets})
+			}
+		case token.CONTINUE:
+			if len(l.loops) == 0 {
+				l.c.errorf(stmt.TokPos, "continue is not in a loop")
+			}
+			if stmt.Label != nil {
+				targets := make([]string, len(l.loops))
+				copy(targets, l.loops)
+				l.branches = append(l.branches, &branchInfo{stmt: stmt, targets: targets})
+			}
+		}
+	case *ast.BlockStmt:
+		l.walkBlock(stmt, path)
+	case *ast.IfStmt:
+		l.walkBlock(stmt.Body, path)
+		if stmt.Else != nil {
+			l.walkStmt(stmt.Else, path)
+		}
+	case *ast.ForStmt:
+		l.walkFor(stmt.Body, path, "")
+	case *ast.RangeStmt:
+		l.walkFor(stmt.Body, path, "")
+	case *ast.SwitchStmt:
+		l.walkSwitch(stmt.Body, path, "")
+	case *ast.TypeSwitchStmt:
+		l.walkSwitch(stmt.Body, path, "")
+	case *ast.SelectStmt:
+		l.walkSwitch(stmt.Body, path, "")
+	}
+}
+
+func (l *labelChecker) walkFor(body *ast.BlockStmt, path []blockIndex, label string) {
+	l.loops = append(l.loops, label)
+	l.walkBlock(body, path)
+	l.loops = l.loops[:len(l.loops)-1]
+}
+
+// walkSwitch 检查 switch 和 select 的每个分支, 分支的语句列表看作单独的块
+func (l *labelChecker) walkSwitch(body *ast.BlockStmt, path []blockIndex, label string) {
+	l.switches = append(l.switches, label)
+	for _, x := range body.List {
+		switch clause := x.(type) {
+		case *ast.CaseClause:
+			l.walkBlock(&ast.BlockStmt{Lbrace: clause.Colon, List: clause.Body, Rbrace: clause.Colon}, path)
+		case *ast.CommClause:
+			l.walkBlock(&ast.BlockStmt{Lbrace: clause.Colon, List: clause.Body, Rbrace: clause.Colon}, path)
+		}
+	}
+	l.switches = l.switches[:len(l.switches)-1]
+}
+
+func containsLabel(labels []string, name string) bool {
+	for _, label := range labels {
+		if label == name {
+			return true
+		}
+	}
+	return false
+}
+
+// checkJump 检查 goto 是否跳入块内或跳过变量声明
+func (l *labelChecker) checkJump(g *gotoInfo, label *labelInfo) {
+	// goto 和标号所在块的公共前缀
+	n := 0
+	for n < len(g.path) && n < len(label.path) && g.path[n].block == label.path[n].block {
+		n++
+	}
+	if n < len(label.path) {
+		l.c.errorf(g.stmt.TokPos, "goto %s jumps into block starting at %s",
+			g.stmt.Label.Name, l.c.posString(label.path[n].block.Lbrace))
+	}
+
+	// 标号所在的块包含 goto, 向后跳转时不能越过变量声明
+	block := label.path[n-1].block
+	from, to := g.path[n-1].index, label.path[n-1].index
+	for i := from + 1; i < to; i++ {
+		if pos, ok := declPos(block.List[i]); ok {
+			l.c.errorf(g.stmt.TokPos, "goto %s jumps over variable declaration at line %d",
+				g.stmt.Label.Name, l.c.posLine(pos))
+		}
+	}
+}
+
+// declPos 如果语句声明了变量, 返回声明的位置
+func declPos(stmt ast.Stmt) (pos token.Pos, ok bool) {
+	switch stmt := stmt.(type) {
+	case *ast.VarSpec:
+		return stmt.VarPos, true
+	case *ast.AssignStmt:
+		if stmt.Op == token.DEFINE {
+			return stmt.Target[0].(*ast.Ident).NamePos, true
+		}
+	case *ast.LabeledStmt:
+		if stmt.Stmt != nil {
+			return declPos(stmt.Stmt)
+		}
+	}
+	return token.NoPos, false
+}
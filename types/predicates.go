@@ -0,0 +1,257 @@
+package types
+
+// Identical 判断两个类型是否相同
+func Identical(x, y Type) bool {
+	if x == y {
+		return true
+	}
+	switch x := x.(type) {
+	case *Array:
+		y, ok := y.(*Array)
+		return ok && x.Len == y.Len && Identical(x.Elem, y.Elem)
+	case *Slice:
+		y, ok := y.(*Slice)
+		return ok && Identical(x.Elem, y.Elem)
+	case *Pointer:
+		y, ok := y.(*Pointer)
+		return ok && Identical(x.Elem, y.Elem)
+	case *Map:
+		y, ok := y.(*Map)
+		return ok && Identical(x.Key, y.Key) && Identical(x.Elem, y.Elem)
+	case *Chan:
+		y, ok := y.(*Chan)
+		return ok && x.Dir == y.Dir && Identical(x.Elem, y.Elem)
+	case *Struct:
+		y, ok := y.(*Struct)
+		if !ok || len(x.Fields) != len(y.Fields) {
+			return false
+		}
+		for i := range x.Fields {
+			if x.Fields[i].Name != y.Fields[i].Name || !Identical(x.Fields[i].Type, y.Fields[i].Type) {
+				return false
+			}
+		}
+		return true
+	case *Tuple:
+		y, ok := y.(*Tuple)
+		if !ok || len(x.Types) != len(y.Types) {
+			return false
+		}
+		for i := range x.Types {
+			if !Identical(x.Types[i], y.Types[i]) {
+				return false
+			}
+		}
+		return true
+	case *Interface:
+		y, ok := y.(*Interface)
+		if !ok || len(x.Methods) != len(y.Methods) {
+			return false
+		}
+		for i := range x.Methods {
+			if x.Methods[i].Name != y.Methods[i].Name || !Identical(x.Methods[i].Type, y.Methods[i].Type) {
+				return false
+			}
+		}
+		return true
+	case *Signature:
+		y, ok := y.(*Signature)
+		if !ok || len(x.Params) != len(y.Params) || x.Variadic != y.Variadic {
+			return false
+		}
+		for i := range x.Params {
+			if !Identical(x.Params[i], y.Params[i]) {
+				return false
+			}
+		}
+		if x.Result == nil || y.Result == nil {
+			return x.Result == y.Result
+		}
+		return Identical(x.Result, y.Result)
+	}
+	return false
+}
+
+// AssignableTo 判断 from 类型的值能否赋值给 to 类型. 只有无类型常量会隐式转换,
+// 不同的数值类型之间需要 T(x) 显式转换
+func AssignableTo(from, to Type) bool {
+	if Identical(from, to) {
+		return true
+	}
+	if from == Typ[UntypedNil] {
+		return IsNillable(to)
+	}
+	if iface, ok := Underlying(to).(*Interface); ok {
+		m, _ := MissingMethod(Default(from), iface)
+		return m == nil
+	}
+	if IsBasic(from, UntypedBool) {
+		return IsBoolean(to)
+	}
+	if IsBasic(from, UntypedString) {
+		return IsString(to)
+	}
+	if IsUntyped(from) {
+		return IsNumeric(to)
+	}
+	// 底层类型相同且至少有一个不是命名类型, 如 []int 和 type Ints []int
+	if Identical(Underlying(from), Underlying(to)) && (!IsNamed(from) || !IsNamed(to)) {
+		return true
+	}
+	// 双向的 channel 可以赋值给元素类型相同的单向 channel
+	if fromChan, ok := Underlying(from).(*Chan); ok && fromChan.Dir == SendRecv {
+		if toChan, ok := Underlying(to).(*Chan); ok && Identical(fromChan.Elem, toChan.Elem) {
+			return !IsNamed(from) || !IsNamed(to)
+		}
+	}
+	return false
+}
+
+// IsUntyped 判断是否为无类型常量的类型
+func IsUntyped(t Type) bool {
+	basic, ok := t.(*Basic)
+	return ok && basic.Kind >= UntypedBool
+}
+
+// Default 无类型常量的默认类型, 用于变量声明等需要确定类型的地方
+func Default(t Type) Type {
+	if basic, ok := t.(*Basic); ok {
+		switch basic.Kind {
+		case UntypedBool:
+			return Typ[Bool]
+		case UntypedInt:
+			return Typ[Int]
+		case UntypedRune:
+			return Typ[Int32]
+		case UntypedFloat:
+			return Typ[Float]
+		case UntypedString:
+			return Typ[String]
+		}
+	}
+	return t
+}
+
+// IsNamed 判断是否为命名类型, 基础类型也是命名类型
+func IsNamed(t Type) bool {
+	switch t.(type) {
+	case *Basic, *Named:
+		return true
+	}
+	return false
+}
+
+// Underlying 获取类型的底层类型
+func Underlying(t Type) Type {
+	if named, ok := t.(*Named); ok {
+		return named.Underlying
+	}
+	return t
+}
+
+// MissingMethod 查找 typ 没有实现的 iface 的方法, 全部实现时返回 nil.
+// ptrRecv 表示缺少的方法是指针接收者的方法, 只有 *typ 实现了 iface
+func MissingMethod(typ Type, iface *Interface) (m *Object, ptrRecv bool) {
+	for _, m := range iface.Methods {
+		found, ptrRecv := MethodSetLookup(typ, m.Name)
+		if found == nil || ptrRecv || !Identical(found.Type, m.Type) {
+			return m, found != nil && ptrRecv
+		}
+	}
+	return nil, false
+}
+
+// MethodSetLookup 在 typ 的方法集中查找方法. T 的方法集只包含值接收者的方法,
+// *T 的方法集包含所有方法. 方法只在 *T 的方法集中时 ptrRecv 为 true
+func MethodSetLookup(typ Type, name string) (m *Object, ptrRecv bool) {
+	if iface, ok := Underlying(typ).(*Interface); ok {
+		m, _ := iface.Method(name)
+		return m, false
+	}
+	if ptr, ok := typ.(*Pointer); ok {
+		if named, ok := ptr.Elem.(*Named); ok {
+			return named.Method(name), false
+		}
+		return nil, false
+	}
+	if named, ok := typ.(*Named); ok {
+		if m := named.Method(name); m != nil {
+			return m, IsPointer(m.Type.(*Signature).Recv)
+		}
+	}
+	return nil, false
+}
+
+func IsInterface(t Type) bool {
+	_, ok := Underlying(t).(*Interface)
+	return ok
+}
+
+// IsNillable 判断类型的零值是否为 nil
+func IsNillable(t Type) bool {
+	switch Underlying(t).(type) {
+	case *Pointer, *Slice, *Map, *Chan, *Interface, *Signature:
+		return true
+	}
+	return false
+}
+
+// Comparable 判断类型的值能否用 == 比较
+func Comparable(t Type) bool {
+	switch t := Underlying(t).(type) {
+	case *Slice, *Map, *Signature:
+		return false
+	case *Array:
+		return Comparable(t.Elem)
+	case *Struct:
+		for _, f := range t.Fields {
+			if !Comparable(f.Type) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func IsPointer(t Type) bool {
+	_, ok := Underlying(t).(*Pointer)
+	return ok
+}
+
+func IsBasic(t Type, kind BasicKind) bool {
+	basic, ok := Underlying(t).(*Basic)
+	return ok && basic.Kind == kind
+}
+
+func IsString(t Type) bool {
+	return IsBasic(t, String) || IsBasic(t, UntypedString)
+}
+
+func IsBoolean(t Type) bool {
+	return IsBasic(t, Bool) || IsBasic(t, UntypedBool)
+}
+
+func IsFloat(t Type) bool {
+	return IsBasic(t, Float) || IsBasic(t, Float64) || IsBasic(t, UntypedFloat)
+}
+
+func IsInteger(t Type) bool {
+	basic, ok := Underlying(t).(*Basic)
+	return ok && (basic.Kind == Int || basic.Kind == Char || basic.Kind >= Int8 && basic.Kind <= Uint64 ||
+		basic.Kind == UntypedInt || basic.Kind == UntypedRune)
+}
+
+func IsUnsigned(t Type) bool {
+	basic, ok := Underlying(t).(*Basic)
+	return ok && basic.Kind >= Uint && basic.Kind <= Uint64
+}
+
+func IsNumeric(t Type) bool {
+	return IsInteger(t) || IsFloat(t)
+}
+
+// ConvertibleTo 判断 from 类型的值能否显式转换为 to 类型
+func ConvertibleTo(from, to Type) bool {
+	return AssignableTo(from, to) || Identical(Underlying(from), Underlying(to)) ||
+		IsNumeric(from) && IsNumeric(to)
+}
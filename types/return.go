@@ -0,0 +1,111 @@
+package types
+
+import (
+	"tiny-go/ast"
+	"tiny-go/token"
+)
+
+// isTerminating 按 Go 的规则判断语句是否为终止语句, 即执行后不会继续执行后面的语句.
+// 有返回值的函数体必须以终止语句结束. label 为语句的标号, 没有标号时为空字符串
+func (c *Checker) isTerminating(stmt ast.Stmt, label string) bool {
+	switch stmt := stmt.(type) {
+	case *ast.ReturnStmt:
+		return true
+	case *ast.BranchStmt:
+		return stmt.TokType == token.GOTO || stmt.TokType == token.FALLTHROUGH
+	case *ast.ExprStmt:
+		// panic(v)
+		call, ok := stmt.X.(*ast.CallExpr)
+		if !ok || call.Fun != nil || call.Pkg != nil || call.Recv != nil {
+			return false
+		}
+		obj := c.info.Uses[call.FuncName]
+		return obj != nil && obj.Kind == ObjBuiltin && obj.Name == "panic"
+	case *ast.BlockStmt:
+		return c.isTerminatingList(stmt.List)
+	case *ast.LabeledStmt:
+		return stmt.Stmt != nil && c.isTerminating(stmt.Stmt, stmt.Label.Name)
+	case *ast.IfStmt:
+		return stmt.Else != nil && c.isTerminating(stmt.Body, "") && c.isTerminating(stmt.Else, "")
+	case *ast.ForStmt:
+		return stmt.Cond == nil && !hasBreak(stmt.Body, label, true)
+	case *ast.SwitchStmt:
+		return c.isTerminatingSwitch(stmt.Body, label)
+	case *ast.TypeSwitchStmt:
+		return c.isTerminatingSwitch(stmt.Body, label)
+	case *ast.SelectStmt:
+		for _, x := range stmt.Body.List {
+			clause := x.(*ast.CommClause)
+			if !c.isTerminatingList(clause.Body) || hasBreakList(clause.Body, label, true) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// isTerminatingList 语句列表的最后一条语句是否为终止语句
+func (c *Checker) isTerminatingList(list []ast.Stmt) bool {
+	return len(list) > 0 && c.isTerminating(list[len(list)-1], "")
+}
+
+// isTerminatingSwitch switch 必须有 default 分支, 每个分支都以终止语句结束, 并且没有跳出 switch 的 break
+func (c *Checker) isTerminatingSwitch(body *ast.BlockStmt, label string) bool {
+	hasDefault := false
+	for _, x := range body.List {
+		clause := x.(*ast.CaseClause)
+		if clause.List == nil {
+			hasDefault = true
+		}
+		if !c.isTerminatingList(clause.Body) || hasBreakList(clause.Body, label, true) {
+			return false
+		}
+	}
+	return hasDefault
+}
+
+// hasBreak 判断语句中是否有跳出外层语句的 break: 带 label 标号的 break,
+// implicit 为 true 时还包括不在内层的循环, switch 和 select 中的 break
+func hasBreak(stmt ast.Stmt, label string, implicit bool) bool {
+	switch stmt := stmt.(type) {
+	case *ast.BranchStmt:
+		if stmt.TokType != token.BREAK {
+			return false
+		}
+		if stmt.Label == nil {
+			return implicit
+		}
+		return stmt.Label.Name == label
+	case *ast.BlockStmt:
+		return hasBreakList(stmt.List, label, implicit)
+	case *ast.LabeledStmt:
+		return stmt.Stmt != nil && hasBreak(stmt.Stmt, label, implicit)
+	case *ast.IfStmt:
+		return hasBreak(stmt.Body, label, implicit) || stmt.Else != nil && hasBreak(stmt.Else, label, implicit)
+	case *ast.ForStmt:
+		return label != "" && hasBreak(stmt.Body, label, false)
+	case *ast.RangeStmt:
+		return label != "" && hasBreak(stmt.Body, label, false)
+	case *ast.SwitchStmt:
+		return label != "" && hasBreak(stmt.Body, label, false)
+	case *ast.TypeSwitchStmt:
+		return label != "" && hasBreak(stmt.Body, label, false)
+	case *ast.SelectStmt:
+		return label != "" && hasBreak(stmt.Body, label, false)
+	case *ast.CaseClause:
+		return hasBreakList(stmt.Body, label, implicit)
+	case *ast.CommClause:
+		return hasBreakList(stmt.Body, label, implicit)
+	}
+	return false
+}
+
+func hasBreakList(list []ast.Stmt, label string, implicit bool) bool {
+	for _, stmt := range list {
+		if hasBreak(stmt, label, implicit) {
+			return true
+		}
+	}
+	return false
+}
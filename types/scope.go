@@ -0,0 +1,58 @@
+package types
+
+import (
+	"go/constant"
+	"tiny-go/ast"
+)
+
+type Scope struct {
+	Outer   *Scope
+	Objects map[string]*Object
+}
+
+// ObjKind 对象的种类
+type ObjKind int
+
+const (
+	ObjVar     ObjKind = iota // 变量
+	ObjFunc                   // 函数
+	ObjType                   // 类型
+	ObjPkg                    // 导入的包
+	ObjBuiltin                // 内置函数, 如 len
+	ObjNil                    // 预定义的 nil
+	ObjConst                  // 常量, 如 true
+)
+
+// Object 声明的名字对应的对象, 如变量, 函数和类型. 同一个声明的所有引用都指向同一个对象
+type Object struct {
+	Name     string
+	Kind     ObjKind
+	Type     Type
+	Value    constant.Value // 常量的值
+	ast.Node                // 声明对象的结点, 预定义的对象为 nil
+}
+
+func NewScope(outer *Scope) *Scope {
+	return &Scope{outer, make(map[string]*Object)}
+}
+
+func (s *Scope) HasName(name string) bool {
+	_, ok := s.Objects[name]
+	return ok
+}
+
+func (s *Scope) Lookup(name string) (*Scope, *Object) {
+	for ; s != nil; s = s.Outer {
+		if obj := s.Objects[name]; obj != nil {
+			return s, obj
+		}
+	}
+	return nil, nil
+}
+
+func (s *Scope) Insert(obj *Object) (alt *Object) {
+	if alt = s.Objects[obj.Name]; alt == nil {
+		s.Objects[obj.Name] = obj
+	}
+	return
+}
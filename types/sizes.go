@@ -0,0 +1,95 @@
+package types
+
+// basicSizes 基础类型占用的字节数, int 和 uint 为 32 位
+var basicSizes = [...]int{
+	Int:     4,
+	Float:   4,
+	Char:    1,
+	String:  16,
+	Bool:    1,
+	Int8:    1,
+	Int16:   2,
+	Int32:   4,
+	Int64:   8,
+	Uint:    4,
+	Uint8:   1,
+	Uint16:  2,
+	Uint32:  4,
+	Uint64:  8,
+	Float64: 8,
+
+	UntypedBool:   1,
+	UntypedInt:    4,
+	UntypedRune:   4,
+	UntypedFloat:  4,
+	UntypedString: 16,
+	UntypedNil:    8,
+}
+
+// Alignof 获取类型在内存中的对齐字节数
+func Alignof(t Type) int {
+	switch t := t.(type) {
+	case *Basic:
+		if t.Kind == String || t.Kind == UntypedString {
+			return 8
+		}
+		return basicSizes[t.Kind]
+	case *Array:
+		return Alignof(t.Elem)
+	case *Slice, *Pointer, *Map, *Chan, *Interface, *Signature:
+		return 8
+	case *Struct:
+		align := 1
+		for _, f := range t.Fields {
+			if a := Alignof(f.Type); a > align {
+				align = a
+			}
+		}
+		return align
+	case *Tuple:
+		align := 1
+		for _, typ := range t.Types {
+			if a := Alignof(typ); a > align {
+				align = a
+			}
+		}
+		return align
+	case *Named:
+		return Alignof(t.Underlying)
+	}
+	return 4
+}
+
+// Sizeof 获取类型占用的字节数
+func Sizeof(t Type) int {
+	switch t := t.(type) {
+	case *Basic:
+		return basicSizes[t.Kind]
+	case *Array:
+		return t.Len * Sizeof(t.Elem)
+	case *Slice, *Interface, *Signature:
+		return 16
+	case *Pointer, *Map, *Chan:
+		return 8
+	case *Struct:
+		// 和 LLVM 的结构体布局一致: 字段按各自的对齐排列, 总大小按结构体的对齐补齐
+		size := 0
+		for _, f := range t.Fields {
+			size = alignTo(size, Alignof(f.Type)) + Sizeof(f.Type)
+		}
+		return alignTo(size, Alignof(t))
+	case *Tuple:
+		size := 0
+		for _, typ := range t.Types {
+			size = alignTo(size, Alignof(typ)) + Sizeof(typ)
+		}
+		return alignTo(size, Alignof(t))
+	case *Named:
+		return Sizeof(t.Underlying)
+	}
+	return 4
+}
+
+func alignTo(n, align int) int {
+	return (n + align - 1) / align * align
+}
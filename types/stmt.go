@@ -0,0 +1,502 @@
+package types
+
+import (
+	"tiny-go/ast"
+	"tiny-go/token"
+)
+
+// funcBody 检查函数体, params 和 paramTypes 为参数和它们的类型, 方法的接收者是第一个参数.
+// 参数和函数体中的语句在同一个作用域中
+func (c *Checker) funcBody(ftype *ast.FuncType, body *ast.BlockStmt, sig *Signature, params []*ast.Field, paramTypes []Type) {
+	defer func(outer *funcContext) { c.fn = outer }(c.fn)
+	c.fn = &funcContext{sig: sig}
+
+	defer c.restoreScope(c.scope)
+	c.enterScope()
+
+	c.checkLabels(body)
+	for i, param := range params {
+		if param.Name == nil {
+			continue
+		}
+		param, typ := param, paramTypes[i]
+		c.guard(func() {
+			c.declare(param.Name, &Object{
+				Name: param.Name.Name,
+				Kind: ObjVar,
+				Type: typ,
+				Node: param.Name,
+			})
+		})
+	}
+	if ftype.Results != nil && len(ftype.Results.List) > 0 && ftype.Results.List[0].Name != nil {
+		c.fn.namedResults = true
+		for i, typ := range sig.Results() {
+			name, typ := ftype.Results.List[i].Name, typ
+			c.guard(func() {
+				c.declare(name, &Object{
+					Name: name.Name,
+					Kind: ObjVar,
+					Type: typ,
+					Node: name,
+				})
+			})
+		}
+	}
+	for _, x := range body.List {
+		c.stmt(x)
+	}
+	if sig.Result != nil && !c.isTerminatingList(body.List) {
+		c.errorf(body.Rbrace, "missing return")
+	}
+}
+
+// stmt 检查一条语句, 语句中的错误只跳过这条语句的其余部分.
+// 出错的变量声明以无效的类型声明变量, 后面用到它时不再报错
+func (c *Checker) stmt(stmt ast.Stmt) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		if _, ok := r.(bailout); !ok {
+			panic(r)
+		}
+		switch stmt := stmt.(type) {
+		case *ast.VarSpec:
+			c.declareInvalid(stmt.Name, ObjVar)
+		case *ast.AssignStmt:
+			if stmt.Op == token.DEFINE {
+				for _, target := range stmt.Target {
+					if ident, ok := target.(*ast.Ident); ok {
+						c.declareInvalid(ident, ObjVar)
+					}
+				}
+			}
+		}
+	}()
+	c.stmtInternal(stmt)
+}
+
+func (c *Checker) stmtInternal(stmt ast.Stmt) {
+	switch stmt := stmt.(type) {
+	case *ast.VarSpec:
+		c.varSpec(stmt)
+	case *ast.ConstDecl:
+		for _, spec := range stmt.Specs {
+			spec := spec
+			c.guard(func() { c.declareConst(spec) })
+		}
+	case *ast.AssignStmt:
+		c.assign(stmt)
+	case *ast.IncDecStmt:
+		c.incDec(stmt)
+	case *ast.ReturnStmt:
+		c.returnStmt(stmt)
+	case *ast.DeferStmt:
+		c.callStmt(stmt.Call, stmt.DeferPos, "defer")
+	case *ast.GoStmt:
+		c.callStmt(stmt.Call, stmt.GoPos, "go")
+	case *ast.SendStmt:
+		c.send(stmt)
+	case *ast.IfStmt:
+		c.ifStmt(stmt)
+	case *ast.ForStmt:
+		c.forStmt(stmt)
+	case *ast.RangeStmt:
+		c.rangeStmt(stmt)
+	case *ast.SwitchStmt:
+		c.switchStmt(stmt)
+	case *ast.TypeSwitchStmt:
+		c.typeSwitchStmt(stmt)
+	case *ast.SelectStmt:
+		c.selectStmt(stmt)
+	case *ast.BranchStmt:
+		// switch 分支末尾的 fallthrough 由 switchStmt 处理, 标号由 checkLabels 检查
+		if stmt.TokType == token.FALLTHROUGH {
+			c.errorf(stmt.TokPos, "fallthrough statement out of place")
+		}
+	case *ast.LabeledStmt:
+		if stmt.Stmt != nil {
+			c.stmt(stmt.Stmt)
+		}
+	case *ast.BlockStmt:
+		c.block(stmt.List)
+	case *ast.ExprStmt:
+		c.expr(stmt.X)
+	default:
+		panic("unreachable")
+	}
+}
+
+// block 在新的作用域中检查语句列表
+func (c *Checker) block(list []ast.Stmt) {
+	defer c.restoreScope(c.scope)
+	c.enterScope()
+	for _, x := range list {
+		c.stmt(x)
+	}
+}
+
+// callStmt 检查 defer 和 go 语句中的调用, keyword 为 "defer" 或 "go"
+func (c *Checker) callStmt(call *ast.CallExpr, pos token.Pos, keyword string) {
+	if obj := c.calleeObject(call); obj != nil && obj.Kind == ObjBuiltin {
+		c.builtinStmt(call, obj, pos, keyword)
+		return
+	}
+	c.funcCall(call)
+}
+
+// send 检查 ch <- v
+func (c *Checker) send(stmt *ast.SendStmt) {
+	ch := c.chanOperand(stmt.Chan, "send to", RecvOnly)
+	c.checkAssignable(stmt.Value, c.valueType(stmt.Value), ch.Elem, "send")
+}
+
+func (c *Checker) ifStmt(stmt *ast.IfStmt) {
+	defer c.restoreScope(c.scope)
+	c.enterScope()
+
+	if stmt.Init != nil {
+		c.stmt(stmt.Init)
+	}
+	c.guard(func() { c.checkCond(stmt.Cond, "if") })
+	c.stmt(stmt.Body)
+	if stmt.Else != nil {
+		c.stmt(stmt.Else)
+	}
+}
+
+func (c *Checker) forStmt(stmt *ast.ForStmt) {
+	defer c.restoreScope(c.scope)
+	c.enterScope()
+
+	if stmt.Init != nil {
+		c.stmt(stmt.Init)
+	}
+	if stmt.Cond != nil {
+		c.guard(func() { c.checkCond(stmt.Cond, "for") })
+	}
+	c.stmt(stmt.Body)
+	if stmt.Post != nil {
+		c.stmt(stmt.Post)
+	}
+}
+
+// rangeStmt 检查 for range 语句, := 声明的迭代变量在循环体外层的作用域中
+func (c *Checker) rangeStmt(stmt *ast.RangeStmt) {
+	defer c.restoreScope(c.scope)
+	c.enterScope()
+
+	keyTyp, valueTyp := c.rangeTypes(stmt)
+	vars := []ast.Expr{stmt.Key, stmt.Value}
+	types := []Type{keyTyp, valueTyp}
+	for i, x := range vars {
+		if x == nil || isBlank(x) {
+			continue
+		}
+		if stmt.Tok == token.DEFINE {
+			ident := x.(*ast.Ident)
+			c.declare(ident, &Object{
+				Name: ident.Name,
+				Kind: ObjVar,
+				Type: types[i],
+				Node: ident,
+			})
+			c.record(ident, variable, types[i], nil)
+			continue
+		}
+		c.checkAssignable(x, types[i], c.assignTarget(x), "range clause")
+	}
+	c.stmt(stmt.Body)
+}
+
+// rangeTypes 获取两个迭代变量的类型, 并检查被迭代的值的类型和迭代变量的个数
+func (c *Checker) rangeTypes(stmt *ast.RangeStmt) (key, value Type) {
+	typ := c.valueType(stmt.X)
+	switch u := Underlying(typ).(type) {
+	case *Array:
+		return Typ[Int], u.Elem
+	case *Slice:
+		return Typ[Int], u.Elem
+	case *Map:
+		return u.Key, u.Elem
+	case *Chan:
+		c.chanOperand(stmt.X, "range over", SendOnly)
+		if stmt.Value != nil {
+			c.errorf(stmt.Value.Pos(), "range over %s permits only one iteration variable", exprString(stmt.X))
+		}
+		return u.Elem, nil
+	default:
+		if IsString(u) {
+			return Typ[Int], Typ[Int32]
+		}
+		if IsInteger(u) {
+			if stmt.Value != nil {
+				c.errorf(stmt.Value.Pos(), "range over %s permits only one iteration variable", exprString(stmt.X))
+			}
+			return Default(typ), nil
+		}
+	}
+	c.errorf(stmt.X.Pos(), "cannot range over %s (variable of type %s)", exprString(stmt.X), typ)
+	panic("unreachable")
+}
+
+// switchStmt 检查 switch 语句. 有 tag 时每个 case 的值必须能和 tag 比较, 常量的值不能重复
+func (c *Checker) switchStmt(stmt *ast.SwitchStmt) {
+	defer c.restoreScope(c.scope)
+	c.enterScope()
+
+	clauses := c.caseClauses(stmt.Body)
+	if stmt.Init != nil {
+		c.stmt(stmt.Init)
+	}
+	if stmt.Tag == nil {
+		for _, clause := range clauses {
+			for _, x := range clause.List {
+				x := x
+				c.guard(func() {
+					if typ := c.valueType(x); !IsBoolean(typ) {
+						c.errorf(x.Pos(), "invalid case %s in switch (mismatched types %s and bool)", exprString(x), typ)
+					}
+				})
+			}
+		}
+	} else {
+		c.guard(func() { c.switchTag(stmt.Tag, clauses) })
+	}
+
+	for i, clause := range clauses {
+		body := clause.Body
+		if n := len(body); n > 0 && isFallthrough(body[n-1]) {
+			if i == len(clauses)-1 {
+				pos := body[n-1].(*ast.BranchStmt).TokPos
+				c.guard(func() { c.errorf(pos, "cannot fallthrough final case in switch") })
+			}
+			body = body[:n-1]
+		}
+		c.block(body)
+	}
+}
+
+// switchTag 检查 switch 的 tag 和每个 case 的值, tag 的类型为无类型常量时使用默认类型
+func (c *Checker) switchTag(tag ast.Expr, clauses []*ast.CaseClause) {
+	tagTyp := c.valueType(tag)
+	if tagTyp == Typ[UntypedNil] {
+		c.errorf(tag.Pos(), "use of untyped nil in switch expression")
+	}
+	typ := Default(tagTyp)
+	for _, clause := range clauses {
+		for _, x := range clause.List {
+			x := x
+			c.guard(func() { c.checkCase(tag, typ, x) })
+		}
+	}
+	c.checkDuplicateCases(clauses, typ)
+}
+
+// caseClauses 获取 switch 的分支, 检查 default 分支是否唯一
+func (c *Checker) caseClauses(body *ast.BlockStmt) (clauses []*ast.CaseClause) {
+	var first *ast.CaseClause
+	for _, x := range body.List {
+		clause := x.(*ast.CaseClause)
+		if clause.List == nil {
+			if first != nil {
+				c.guard(func() {
+					c.errorf(clause.Case, "multiple defaults in switch (first at %s)", c.posString(first.Case))
+				})
+			} else {
+				first = clause
+			}
+		}
+		clauses = append(clauses, clause)
+	}
+	return clauses
+}
+
+// isFallthrough 判断语句是否为 fallthrough
+func isFallthrough(stmt ast.Stmt) bool {
+	branch, ok := stmt.(*ast.BranchStmt)
+	return ok && branch.TokType == token.FALLTHROUGH
+}
+
+// checkCase 检查 case 的值能否和 tag 比较
+func (c *Checker) checkCase(tag ast.Expr, typ Type, x ast.Expr) {
+	xTyp := c.valueType(x)
+	if xTyp == Typ[UntypedNil] {
+		if !IsNillable(typ) {
+			c.errorf(x.Pos(), "invalid case %s in switch on %s (mismatched types untyped nil and %s)",
+				exprString(x), exprString(tag), typ)
+		}
+		return
+	}
+	if !AssignableTo(xTyp, typ) {
+		c.errorf(x.Pos(), "invalid case %s in switch on %s (mismatched types %s and %s)",
+			exprString(x), exprString(tag), xTyp, typ)
+	}
+	if !Comparable(typ) {
+		c.errorf(x.Pos(), "invalid case %s in switch on %s (operator == not defined on %s)",
+			exprString(x), exprString(tag), typ)
+	}
+	c.checkConstOperand(x, typ)
+}
+
+// checkDuplicateCases 检查 switch 中重复的常量, 只检查已经通过 checkCase 的值
+func (c *Checker) checkDuplicateCases(clauses []*ast.CaseClause, typ Type) {
+	seen := make(map[string]token.Pos)
+	for _, clause := range clauses {
+		for _, x := range clause.List {
+			tv, ok := c.info.Types[x]
+			if !ok || tv.mode != constant_ {
+				continue
+			}
+			value, _ := representable(tv.Value, typ)
+			key := value.ExactString()
+			if prev, ok := seen[key]; ok {
+				x := x
+				c.guard(func() {
+					c.errorf(x.Pos(), "duplicate case %s in expression switch\n\tprevious case at %s",
+						exprString(x), c.posString(prev))
+				})
+				continue
+			}
+			seen[key] = x.Pos()
+		}
+	}
+}
+
+// typeSwitchStmt 检查类型 switch 语句. 每个分支中 x := v.(type) 声明的变量在只有一个类型的分支中
+// 为该类型, 否则为 v 的类型, 变量记录在 Implicits 中
+func (c *Checker) typeSwitchStmt(stmt *ast.TypeSwitchStmt) {
+	defer c.restoreScope(c.scope)
+	c.enterScope()
+
+	clauses := c.caseClauses(stmt.Body)
+	if stmt.Init != nil {
+		c.stmt(stmt.Init)
+	}
+
+	// x := v.(type) 或 v.(type)
+	var symbol *ast.Ident
+	var assert *ast.TypeAssertExpr
+	switch guard := stmt.Assign.(type) {
+	case *ast.AssignStmt:
+		symbol, assert = guard.Target[0].(*ast.Ident), guard.Value[0].(*ast.TypeAssertExpr)
+	case *ast.ExprStmt:
+		assert = guard.X.(*ast.TypeAssertExpr)
+	}
+	xTyp := c.valueType(assert.X)
+	iface, ok := Underlying(xTyp).(*Interface)
+	if !ok {
+		c.errorf(assert.X.Pos(), "%s (value of type %s) is not an interface", exprString(assert.X), xTyp)
+	}
+	c.record(assert, value, xTyp, nil)
+
+	// 每个 case 的类型, nil 为 nil
+	types := make([][]Type, len(clauses))
+	seen := make(map[string]ast.Expr)
+	for i, clause := range clauses {
+		for _, x := range clause.List {
+			x := x
+			c.guard(func() {
+				var typ Type
+				if _, obj := c.scope.Lookup(identName(x)); obj != nil && obj.Kind == ObjNil {
+					c.operand(x)
+				} else {
+					typ = c.typExpr(x)
+					if m, _ := MissingMethod(typ, iface); !IsInterface(typ) && m != nil {
+						c.errorf(x.Pos(), "impossible type switch case: %s\n\t%s (type %s) cannot have dynamic type %s (%s)",
+							exprString(x), exprString(assert.X), xTyp, typ, missingMethodReason(typ, iface))
+					}
+				}
+				key := "nil"
+				if typ != nil {
+					key = typ.String()
+				}
+				if prev, ok := seen[key]; ok {
+					c.errorf(x.Pos(), "duplicate case %s in type switch\n\tprevious case at %s", exprString(x), c.posString(prev.Pos()))
+				}
+				seen[key] = x
+				types[i] = append(types[i], typ)
+			})
+		}
+	}
+
+	for i, clause := range clauses {
+		func() {
+			defer c.restoreScope(c.scope)
+			c.enterScope()
+
+			if symbol != nil && symbol.Name != "_" {
+				typ := xTyp
+				if len(types[i]) == 1 && types[i][0] != nil {
+					typ = types[i][0]
+				}
+				obj := &Object{
+					Name: symbol.Name,
+					Kind: ObjVar,
+					Type: typ,
+					Node: symbol,
+				}
+				c.scope.Insert(obj)
+				c.info.Implicits[clause] = obj
+			}
+			for _, x := range clause.Body {
+				if isFallthrough(x) {
+					pos := x.(*ast.BranchStmt).TokPos
+					c.guard(func() { c.errorf(pos, "cannot fallthrough in type switch") })
+					continue
+				}
+				c.stmt(x)
+			}
+		}()
+	}
+}
+
+// identName 标识符的名字, 不是标识符时为空
+func identName(expr ast.Expr) string {
+	if ident, ok := expr.(*ast.Ident); ok {
+		return ident.Name
+	}
+	return ""
+}
+
+// selectStmt 检查 select 语句, case v, ok := <-ch 的变量在分支的作用域中
+func (c *Checker) selectStmt(stmt *ast.SelectStmt) {
+	var first *ast.CommClause
+	for _, x := range stmt.Body.List {
+		clause := x.(*ast.CommClause)
+		if clause.Comm == nil {
+			if first != nil {
+				c.guard(func() {
+					c.errorf(clause.Case, "multiple defaults in select (first at %s)", c.posString(first.Case))
+				})
+			} else {
+				first = clause
+			}
+		}
+	}
+
+	for _, x := range stmt.Body.List {
+		clause := x.(*ast.CommClause)
+		func() {
+			defer c.restoreScope(c.scope)
+			c.enterScope()
+
+			if clause.Comm != nil {
+				c.stmt(clause.Comm)
+			}
+			for _, x := range clause.Body {
+				c.stmt(x)
+			}
+		}()
+	}
+}
+
+// commRecv 获取 select 接收分支中的 <-ch, 语法已经由 parser 检查过
+func commRecv(comm ast.Stmt) *ast.UnaryExpr {
+	if assign, ok := comm.(*ast.AssignStmt); ok {
+		return assign.Value[0].(*ast.UnaryExpr)
+	}
+	return comm.(*ast.ExprStmt).X.(*ast.UnaryExpr)
+}
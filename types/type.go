@@ -0,0 +1,276 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Type 表示 tGo 中的类型
+type Type interface {
+	String() string
+}
+
+// BasicKind 基础类型的种类
+type BasicKind int
+
+const (
+	Invalid BasicKind = iota // 类型错误的表达式的类型
+
+	Int
+	Float
+	Char
+	String
+	Bool
+	Int8
+	Int16
+	Int32
+	Int64
+	Uint
+	Uint8
+	Uint16
+	Uint32
+	Uint64
+	Float64
+
+	// 无类型常量的类型
+	UntypedBool
+	UntypedInt
+	UntypedRune // 字符字面值的类型, 默认类型为 rune (int32)
+	UntypedFloat
+	UntypedString
+	UntypedNil // nil 的类型, 可以赋值给指针, 切片, map, channel, 接口和函数
+)
+
+// Basic 基础类型
+type Basic struct {
+	Kind BasicKind
+	Name string
+}
+
+// Signature 函数类型, Result 为 nil 表示没有返回值, 多个返回值时为 *Tuple
+type Signature struct {
+	Recv     Type // 方法的接收者类型, 函数为 nil
+	Params   []Type
+	Result   Type
+	Variadic bool // 最后一个参数为 ...T, 在 Params 中的类型为 []T
+}
+
+// Tuple 多个返回值的类型, 在 LLVM 中表示为结构体
+type Tuple struct {
+	Types []Type
+}
+
+// Array 数组类型
+type Array struct {
+	Len  int
+	Elem Type
+}
+
+// Slice 切片类型
+type Slice struct {
+	Elem Type
+}
+
+// Map map 类型
+type Map struct {
+	Key  Type
+	Elem Type
+}
+
+// ChanDir channel 的方向
+type ChanDir int
+
+const (
+	SendRecv ChanDir = iota // 双向
+	SendOnly                // chan<- T
+	RecvOnly                // <-chan T
+)
+
+// Chan channel 类型
+type Chan struct {
+	Dir  ChanDir
+	Elem Type
+}
+
+// Pointer 指针类型
+type Pointer struct {
+	Elem Type
+}
+
+// Struct 结构体类型
+type Struct struct {
+	Fields []*Field
+}
+
+// Field 结构体字段
+type Field struct {
+	Name string
+	Type Type
+}
+
+// Interface 接口类型, 方法按名字排序, 下标即方法在 itab 中的位置
+type Interface struct {
+	Methods []*Object // 方法的类型为 *Signature, 接收者为接口本身
+}
+
+// Named 由 type 声明定义的类型
+type Named struct {
+	Pkg        string // 声明类型的包名
+	Name       string
+	Underlying Type      // 底层类型
+	Methods    []*Object // 方法, 包括指针接收者的方法
+}
+
+// Typ 预定义的基础类型
+var Typ = [...]*Basic{
+	Invalid: {Invalid, "invalid type"},
+
+	Int:    {Int, "int"},
+	Float:  {Float, "float"},
+	Char:   {Char, "char"},
+	String: {String, "string"},
+	Bool:   {Bool, "bool"},
+
+	Int8:    {Int8, "int8"},
+	Int16:   {Int16, "int16"},
+	Int32:   {Int32, "int32"},
+	Int64:   {Int64, "int64"},
+	Uint:    {Uint, "uint"},
+	Uint8:   {Uint8, "uint8"},
+	Uint16:  {Uint16, "uint16"},
+	Uint32:  {Uint32, "uint32"},
+	Uint64:  {Uint64, "uint64"},
+	Float64: {Float64, "float64"},
+
+	UntypedBool:   {UntypedBool, "untyped bool"},
+	UntypedInt:    {UntypedInt, "untyped int"},
+	UntypedRune:   {UntypedRune, "untyped rune"},
+	UntypedFloat:  {UntypedFloat, "untyped float"},
+	UntypedString: {UntypedString, "untyped string"},
+	UntypedNil:    {UntypedNil, "untyped nil"},
+}
+
+func (t *Basic) String() string {
+	return t.Name
+}
+
+func (t *Array) String() string {
+	return fmt.Sprintf("[%d]%s", t.Len, t.Elem)
+}
+
+func (t *Slice) String() string {
+	return "[]" + t.Elem.String()
+}
+
+func (t *Map) String() string {
+	return "map[" + t.Key.String() + "]" + t.Elem.String()
+}
+
+func (t *Chan) String() string {
+	switch t.Dir {
+	case SendOnly:
+		return "chan<- " + t.Elem.String()
+	case RecvOnly:
+		return "<-chan " + t.Elem.String()
+	}
+	// chan (<-chan T) 需要括号, 否则会解析为 chan<- chan T
+	if elem, ok := t.Elem.(*Chan); ok && elem.Dir == RecvOnly {
+		return "chan (" + elem.String() + ")"
+	}
+	return "chan " + t.Elem.String()
+}
+
+func (t *Pointer) String() string {
+	return "*" + t.Elem.String()
+}
+
+func (t *Tuple) String() string {
+	var types []string
+	for _, typ := range t.Types {
+		types = append(types, typ.String())
+	}
+	return "(" + strings.Join(types, ", ") + ")"
+}
+
+func (t *Struct) String() string {
+	var fields []string
+	for _, f := range t.Fields {
+		fields = append(fields, f.Name+" "+f.Type.String())
+	}
+	return "struct{" + strings.Join(fields, "; ") + "}"
+}
+
+// FieldIndex 查找字段的下标, 没有找到时返回 -1
+func (t *Struct) FieldIndex(name string) int {
+	for i, f := range t.Fields {
+		if f.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (t *Interface) String() string {
+	var methods []string
+	for _, m := range t.Methods {
+		methods = append(methods, m.Name+strings.TrimPrefix(m.Type.String(), "func"))
+	}
+	return "interface{" + strings.Join(methods, "; ") + "}"
+}
+
+// Method 查找接口的方法和它在 itab 中的下标, 没有找到时返回 nil
+func (t *Interface) Method(name string) (*Object, int) {
+	for i, m := range t.Methods {
+		if m.Name == name {
+			return m, i
+		}
+	}
+	return nil, -1
+}
+
+func (t *Named) String() string {
+	return t.Name
+}
+
+// Method 查找类型的方法, 没有找到时返回 nil
+func (t *Named) Method(name string) *Object {
+	for _, m := range t.Methods {
+		if m.Name == name {
+			return m
+		}
+	}
+	return nil
+}
+
+func (t *Signature) String() string {
+	s := "func" + paramsString(t)
+	if t.Result != nil {
+		s += " " + t.Result.String()
+	}
+	return s
+}
+
+// Results 获取函数返回值的类型列表
+func (t *Signature) Results() []Type {
+	switch result := t.Result.(type) {
+	case nil:
+		return nil
+	case *Tuple:
+		return result.Types
+	default:
+		return []Type{result}
+	}
+}
+
+// paramsString 函数的参数列表, 如 (string, ...int)
+func paramsString(sig *Signature) string {
+	var params []string
+	for i, param := range sig.Params {
+		if sig.Variadic && i == len(sig.Params)-1 {
+			params = append(params, "..."+param.(*Slice).Elem.String())
+			continue
+		}
+		params = append(params, param.String())
+	}
+	return "(" + strings.Join(params, ", ") + ")"
+}
@@ -0,0 +1,170 @@
+package types
+
+import (
+	"go/constant"
+	"math"
+	"sort"
+	"tiny-go/ast"
+)
+
+// typExpr 解析类型表达式对应的类型, 并记录在 Info.Types 中
+func (c *Checker) typExpr(expr ast.Expr) Type {
+	typ := c.resolveType(expr)
+	c.record(expr, typexpr, typ, nil)
+	return typ
+}
+
+func (c *Checker) resolveType(expr ast.Expr) Type {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		_, obj := c.scope.Lookup(expr.Name)
+		if obj == nil {
+			c.errorf(expr.NamePos, "undefined: %s", expr.Name)
+		}
+		c.info.Uses[expr] = obj
+		if obj.Kind != ObjType {
+			c.errorf(expr.NamePos, "%s is not a type", expr.Name)
+		}
+		if resolving, ok := c.typeDecls[obj]; ok && (!resolving || obj.Type == nil) {
+			// 还没有解析的类型声明, 正在解析的命名类型可以在字段等位置引用自己
+			c.resolveTypeDecl(obj)
+		}
+		if isInvalid(obj.Type) {
+			c.abort()
+		}
+		return obj.Type
+	case *ast.StructType:
+		return c.structType(expr)
+	case *ast.InterfaceType:
+		return c.interfaceType(expr)
+	case *ast.FuncType:
+		return c.funcType(expr)
+	case *ast.MapType:
+		return c.mapType(expr)
+	case *ast.ChanType:
+		typ := &Chan{Dir: SendRecv, Elem: c.typExpr(expr.Value)}
+		switch expr.Dir {
+		case ast.SEND:
+			typ.Dir = SendOnly
+		case ast.RECV:
+			typ.Dir = RecvOnly
+		}
+		return typ
+	case *ast.StarExpr:
+		return &Pointer{Elem: c.typExpr(expr.X)}
+	case *ast.ParenExpr:
+		return c.typExpr(expr.X)
+	case *ast.Ellipsis:
+		c.errorf(expr.Ellipsis, "invalid use of ...")
+	case *ast.ArrayType:
+		if expr.Len == nil {
+			return &Slice{Elem: c.typExpr(expr.Elem)}
+		}
+		value, typ, ok := c.constant(expr.Len)
+		if !ok || !IsNumeric(typ) || constant.ToInt(value).Kind() != constant.Int {
+			c.errorf(expr.Len.Pos(), "array length must be a non-negative integer constant")
+		}
+		n, ok := constant.Int64Val(constant.ToInt(value))
+		if !ok || n < 0 || n > math.MaxInt32 {
+			c.errorf(expr.Len.Pos(), "invalid array length %s", exprString(expr.Len))
+		}
+		return &Array{Len: int(n), Elem: c.typExpr(expr.Elem)}
+	}
+	c.errorf(expr.Pos(), "%T is not a type", expr)
+	panic("unreachable")
+}
+
+// funcType 解析函数类型, 最后一个参数可以是 ...T
+func (c *Checker) funcType(typ *ast.FuncType) *Signature {
+	sig := &Signature{}
+	for i, param := range typ.Params.List {
+		if ellipsis, ok := param.Type.(*ast.Ellipsis); ok {
+			if i != len(typ.Params.List)-1 {
+				c.errorf(ellipsis.Ellipsis, "can only use ... with final parameter in list")
+			}
+			sig.Params = append(sig.Params, &Slice{Elem: c.typExpr(ellipsis.Elt)})
+			sig.Variadic = true
+			continue
+		}
+		sig.Params = append(sig.Params, c.typExpr(param.Type))
+	}
+	if typ.Results != nil {
+		var results []Type
+		for _, result := range typ.Results.List {
+			results = append(results, c.typExpr(result.Type))
+		}
+		switch len(results) {
+		case 0:
+		case 1:
+			sig.Result = results[0]
+		default:
+			sig.Result = &Tuple{Types: results}
+		}
+	}
+	return sig
+}
+
+func (c *Checker) structType(expr *ast.StructType) *Struct {
+	typ := &Struct{}
+	for _, f := range expr.Fields.List {
+		if typ.FieldIndex(f.Name.Name) >= 0 {
+			c.errorf(f.Name.NamePos, "%s redeclared", f.Name.Name)
+		}
+		typ.Fields = append(typ.Fields, &Field{Name: f.Name.Name, Type: c.typExpr(f.Type)})
+	}
+	return typ
+}
+
+// interfaceType 解析接口类型, 方法按名字排序, 方法的接收者为接口本身
+func (c *Checker) interfaceType(expr *ast.InterfaceType) *Interface {
+	iface := &Interface{}
+	for _, f := range expr.Methods.List {
+		if m, _ := iface.Method(f.Name.Name); m != nil {
+			c.errorf(f.Name.NamePos, "duplicate method %s", f.Name.Name)
+		}
+		sig := c.funcType(f.Type.(*ast.FuncType))
+		sig.Recv = iface
+		iface.Methods = append(iface.Methods, &Object{
+			Name: f.Name.Name,
+			Kind: ObjFunc,
+			Type: sig,
+			Node: f.Name,
+		})
+	}
+	sort.Slice(iface.Methods, func(i, j int) bool {
+		return iface.Methods[i].Name < iface.Methods[j].Name
+	})
+	return iface
+}
+
+// mapType 解析 map[K]V, 键必须是可以比较且运行时支持的类型
+func (c *Checker) mapType(expr *ast.MapType) *Map {
+	typ := &Map{Key: c.typExpr(expr.Key), Elem: c.typExpr(expr.Value)}
+	if !Comparable(typ.Key) {
+		c.errorf(expr.Key.Pos(), "invalid map key type %s", typ.Key)
+	}
+	if !validMapKey(typ.Key) {
+		c.errorf(expr.Key.Pos(), "unsupported map key type %s (only booleans, numbers, strings, pointers and channels)", typ.Key)
+	}
+	return typ
+}
+
+// validMapKey 判断 builtin 运行时能否计算键的哈希, 只支持基础类型, 指针和 channel
+func validMapKey(key Type) bool {
+	switch Underlying(key).(type) {
+	case *Basic, *Pointer, *Chan:
+		return true
+	}
+	return false
+}
+
+// isTypeExpr 判断表达式是否为类型字面值, 如 []int 和 func(int)
+func isTypeExpr(expr ast.Expr) bool {
+	switch expr := expr.(type) {
+	case *ast.ArrayType, *ast.MapType, *ast.ChanType, *ast.StructType, *ast.InterfaceType, *ast.FuncType:
+		return true
+	case *ast.ParenExpr:
+		return isTypeExpr(expr.X)
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+package types
+
+import "go/constant"
+
+var Universe *Scope = NewScope(nil)
+
+var builtinObjects = []*Object{
+	{Name: "print", Kind: ObjFunc,
+		Type: &Signature{Params: []Type{&Slice{Elem: &Interface{}}}, Result: Typ[Int], Variadic: true}},
+	{Name: "println", Kind: ObjFunc,
+		Type: &Signature{Params: []Type{&Slice{Elem: &Interface{}}}, Result: Typ[Int], Variadic: true}},
+	{Name: "printf", Kind: ObjFunc,
+		Type: &Signature{Params: []Type{Typ[String], &Slice{Elem: &Interface{}}}, Result: Typ[Int], Variadic: true}},
+	{Name: "exit", Kind: ObjFunc,
+		Type: &Signature{Params: []Type{Typ[Int]}, Result: Typ[Int]}},
+	{Name: "len", Kind: ObjBuiltin},
+	{Name: "cap", Kind: ObjBuiltin},
+	{Name: "make", Kind: ObjBuiltin},
+	{Name: "append", Kind: ObjBuiltin},
+	{Name: "new", Kind: ObjBuiltin},
+	{Name: "delete", Kind: ObjBuiltin},
+	{Name: "close", Kind: ObjBuiltin},
+	{Name: "panic", Kind: ObjBuiltin},
+	{Name: "recover", Kind: ObjBuiltin},
+	{Name: "nil", Kind: ObjNil, Type: Typ[UntypedNil]},
+	{Name: "true", Kind: ObjConst, Type: Typ[UntypedBool], Value: constant.MakeBool(true)},
+	{Name: "false", Kind: ObjConst, Type: Typ[UntypedBool], Value: constant.MakeBool(false)},
+	{Name: "iota", Kind: ObjConst, Type: Typ[UntypedInt]}, // 值由所在的常量声明决定
+}
+
+func init() {
+	for _, obj := range builtinObjects {
+		Universe.Insert(obj)
+	}
+	for _, typ := range Typ {
+		if typ.Kind == Invalid || IsUntyped(typ) {
+			continue
+		}
+		Universe.Insert(&Object{Name: typ.Name, Kind: ObjType, Type: typ})
+	}
+	// byte 和 rune 是 uint8 和 int32 的别名
+	Universe.Insert(&Object{Name: "byte", Kind: ObjType, Type: Typ[Uint8]})
+	Universe.Insert(&Object{Name: "rune", Kind: ObjType, Type: Typ[Int32]})
+	// any 是 interface{} 的别名
+	Universe.Insert(&Object{Name: "any", Kind: ObjType, Type: &Interface{}})
+}
@@ -0,0 +1,90 @@
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"tiny-go/ast"
+	"tiny-go/token"
+)
+
+// exprString 表达式的源码形式, 用于错误信息
+func exprString(expr ast.Expr) string {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		return expr.Name
+	case *ast.Int:
+		return expr.Value
+	case *ast.Float:
+		return expr.Value
+	case *ast.Char:
+		return strconv.QuoteRune(rune(expr.Value))
+	case *ast.StringLit:
+		return strconv.Quote(expr.Value)
+	case *ast.BinaryExpr:
+		return exprString(expr.X) + " " + expr.Op.String() + " " + exprString(expr.Y)
+	case *ast.UnaryExpr:
+		return expr.Op.String() + exprString(expr.X)
+	case *ast.SelectorExpr:
+		return exprString(expr.X) + "." + expr.Sel.Name
+	case *ast.IndexExpr:
+		return exprString(expr.X) + "[" + exprString(expr.Index) + "]"
+	case *ast.ParenExpr:
+		return "(" + exprString(expr.X) + ")"
+	case *ast.StarExpr:
+		return "*" + exprString(expr.X)
+	case *ast.CallExpr:
+		return funcString(expr) + "(...)"
+	case *ast.FuncLit:
+		return "func literal"
+	case *ast.TypeAssertExpr:
+		if expr.Type == nil {
+			return exprString(expr.X) + ".(type)"
+		}
+		return exprString(expr.X) + ".(" + exprString(expr.Type) + ")"
+	}
+	return "expression"
+}
+
+// funcString 被调用函数的名字, 如 pkg.fn 或 x.method
+func funcString(expr *ast.CallExpr) string {
+	if expr.Fun != nil {
+		return exprString(expr.Fun)
+	}
+	if expr.Recv != nil {
+		return exprString(expr.Recv) + "." + expr.FuncName.Name
+	}
+	if expr.Pkg != nil {
+		return expr.Pkg.Name + "." + expr.FuncName.Name
+	}
+	return expr.FuncName.Name
+}
+
+// valuePos 获取第 i 个值的位置, 多返回值的函数调用展开的值都使用调用的位置
+func valuePos(exprs []ast.Expr, i int) token.Pos {
+	return valueExpr(exprs, i).Pos()
+}
+
+// valueExpr 第 i 个值对应的表达式, 多个值来自一个多返回值的函数调用时为该调用
+func valueExpr(exprs []ast.Expr, i int) ast.Expr {
+	if i < len(exprs) {
+		return exprs[i]
+	}
+	return exprs[0]
+}
+
+// isBlank 判断表达式是否为空白标识符 _
+func isBlank(expr ast.Expr) bool {
+	ident, ok := expr.(*ast.Ident)
+	return ok && ident.Name == "_"
+}
+
+func tupleString(types []Type) string {
+	return (&Tuple{Types: types}).String()
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, word)
+	}
+	return fmt.Sprintf("%d %ss", n, word)
+}